@@ -0,0 +1,117 @@
+package caches_test
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/allegro/bigcache/v3"
+	"github.com/kevin07696/receipt-processor/adapters/caches"
+	"github.com/kevin07696/receipt-processor/domain"
+)
+
+const (
+	benchCapacity = 1 << 16
+	benchKeys     = 1 << 15
+)
+
+type benchCache interface {
+	Get(ctx context.Context, key string) (interface{}, domain.StatusCode)
+	Set(ctx context.Context, key string, value interface{}) domain.StatusCode
+}
+
+// benchCaches returns a fresh instance of every cache adapter. BigCache stores int64 scores with Int64Codec.
+func benchCaches(b *testing.B) map[string]benchCache {
+	lru := caches.NewLRUCache(benchCapacity)
+	sharded := caches.NewShardedLRUCache(benchCapacity, 64, 0)
+
+	config := bigcache.DefaultConfig(time.Hour)
+	config.Shards = 64
+	config.MaxEntriesInWindow = benchCapacity
+	config.Verbose = false
+	big, err := bigcache.New(context.Background(), config)
+	if err != nil {
+		b.Fatalf("Failed to create bigcache: %v", err)
+	}
+	b.Cleanup(func() { big.Close() })
+	bigCache := caches.NewBigCache(big, caches.Int64Codec{})
+
+	return map[string]benchCache{
+		"LRUCache":        &lru,
+		"ShardedLRUCache": &sharded,
+		"BigCache":        &bigCache,
+	}
+}
+
+func benchKeySet() []string {
+	keys := make([]string, benchKeys)
+	for i := range keys {
+		keys[i] = fmt.Sprintf("%08x-7dc5-4b56-97a1-b0007f3d8355", i)
+	}
+	return keys
+}
+
+// runParallel runs op from every benchmark goroutine, each starting at a different random key.
+func runParallel(b *testing.B, keys []string, op func(key string)) {
+	var seed atomic.Int64
+	b.ReportAllocs()
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		i := rand.New(rand.NewSource(seed.Add(1))).Intn(len(keys))
+		for pb.Next() {
+			op(keys[i])
+			i = (i + 1) % len(keys)
+		}
+	})
+}
+
+func BenchmarkCacheSet(b *testing.B) {
+	ctx := context.Background()
+	keys := benchKeySet()
+	for name, cache := range benchCaches(b) {
+		b.Run(name, func(b *testing.B) {
+			runParallel(b, keys, func(key string) {
+				cache.Set(ctx, key, int64(42))
+			})
+		})
+	}
+}
+
+func BenchmarkCacheGet(b *testing.B) {
+	ctx := context.Background()
+	keys := benchKeySet()
+	for name, cache := range benchCaches(b) {
+		for _, key := range keys {
+			cache.Set(ctx, key, int64(42))
+		}
+		b.Run(name, func(b *testing.B) {
+			runParallel(b, keys, func(key string) {
+				cache.Get(ctx, key)
+			})
+		})
+	}
+}
+
+// BenchmarkCacheMixed reads nine times for every write, like clients polling points after processing a receipt.
+func BenchmarkCacheMixed(b *testing.B) {
+	ctx := context.Background()
+	keys := benchKeySet()
+	for name, cache := range benchCaches(b) {
+		for _, key := range keys {
+			cache.Set(ctx, key, int64(42))
+		}
+		b.Run(name, func(b *testing.B) {
+			var ops atomic.Uint64
+			runParallel(b, keys, func(key string) {
+				if ops.Add(1)%10 == 0 {
+					cache.Set(ctx, key, int64(42))
+					return
+				}
+				cache.Get(ctx, key)
+			})
+		})
+	}
+}
@@ -2,33 +2,117 @@ package caches
 
 import (
 	"context"
-	"log"
+	"errors"
+	"log/slog"
+	"time"
 
 	"github.com/allegro/bigcache/v3"
 	"github.com/kevin07696/receipt-processor/domain"
 )
 
+// BigCache stores values as bytes in BigCache, so each value goes through codec.
 type BigCache struct {
 	cache *bigcache.BigCache
+	codec Codec
+	stats *counters
 }
 
-func NewBigCache(cache *bigcache.BigCache) BigCache {
-	return BigCache{cache: cache}
+func NewBigCache(cache *bigcache.BigCache, codec Codec) BigCache {
+	return BigCache{cache: cache, codec: codec, stats: &counters{}}
+}
+
+type BigCacheConfig struct {
+	Shards int
+	// LifeWindow is how long an entry lives. Expired entries are removed every LifeWindow.
+	LifeWindow time.Duration
+	// MaxEntries sizes the initial allocation of the shards.
+	MaxEntries int
+	// MaxSizeMB limits the memory of the cache. 0 has no limit.
+	MaxSizeMB int
+}
+
+// NewBigCacheFromConfig creates a BigCache that counts its evictions and expirations.
+func NewBigCacheFromConfig(ctx context.Context, config BigCacheConfig, codec Codec) (BigCache, error) {
+	stats := &counters{}
+
+	bigConfig := bigcache.DefaultConfig(config.LifeWindow)
+	bigConfig.Shards = config.Shards
+	bigConfig.CleanWindow = config.LifeWindow
+	bigConfig.MaxEntriesInWindow = config.MaxEntries
+	bigConfig.HardMaxCacheSize = config.MaxSizeMB
+	bigConfig.Verbose = false
+	bigConfig.OnRemoveWithReason = func(key string, entry []byte, reason bigcache.RemoveReason) {
+		switch reason {
+		case bigcache.Expired:
+			stats.expirations.Add(1)
+		case bigcache.NoSpace:
+			stats.evictions.Add(1)
+		}
+	}
+
+	cache, err := bigcache.New(ctx, bigConfig)
+	if err != nil {
+		return BigCache{}, err
+	}
+	return BigCache{cache: cache, codec: codec, stats: stats}, nil
 }
 
 func (c *BigCache) Set(ctx context.Context, key string, value interface{}) domain.StatusCode {
-	valueString := value.(string)
-	valBytes := []byte(valueString)
-	if err := c.cache.Set(key, valBytes); err != nil {
-		log.Fatalf("Failed to store value: %v: %v", value, err)
+	data, err := c.codec.Encode(value)
+	if err != nil {
+		slog.ErrorContext(ctx, "Encode Error: Failed to encode value.", slog.String("key", key), slog.Any("error", err))
+		return domain.ErrInternal
+	}
+	if err := c.cache.Set(key, data); err != nil {
+		slog.ErrorContext(ctx, "Store Error: Failed to store value.", slog.String("key", key), slog.Any("error", err))
+		return domain.ErrInternal
 	}
 	return domain.StatusOK
 }
 
 func (c BigCache) Get(ctx context.Context, key string) (interface{}, domain.StatusCode) {
-	value, err := c.cache.Get(key)
-	if err != nil {
+	data, err := c.cache.Get(key)
+	if errors.Is(err, bigcache.ErrEntryNotFound) {
 		return nil, domain.ErrNotFound
 	}
+	if err != nil {
+		slog.ErrorContext(ctx, "Read Error: Failed to read value.", slog.String("key", key), slog.Any("error", err))
+		return nil, domain.ErrInternal
+	}
+
+	value, err := c.codec.Decode(data)
+	if err != nil {
+		slog.ErrorContext(ctx, "Decode Error: Failed to decode value.", slog.String("key", key), slog.Any("error", err))
+		return nil, domain.ErrInternal
+	}
 	return value, domain.StatusOK
 }
+
+func (c BigCache) Keys(ctx context.Context) ([]string, domain.StatusCode) {
+	var keys []string
+	iterator := c.cache.Iterator()
+	for iterator.SetNext() {
+		entry, err := iterator.Value()
+		if err != nil {
+			slog.ErrorContext(ctx, "Read Error: Failed to iterate keys.", slog.Any("error", err))
+			return nil, domain.ErrInternal
+		}
+		keys = append(keys, entry.Key())
+	}
+	return keys, domain.StatusOK
+}
+
+func (c BigCache) Stats() Stats {
+	stats := c.cache.Stats()
+	return Stats{
+		Size:        c.cache.Len(),
+		Hits:        uint64(stats.Hits),
+		Misses:      uint64(stats.Misses),
+		Evictions:   c.stats.evictions.Load(),
+		Expirations: c.stats.expirations.Load(),
+	}
+}
+
+func (c BigCache) Close() error {
+	return c.cache.Close()
+}
@@ -0,0 +1,114 @@
+package caches_test
+
+import (
+	"context"
+	"encoding/gob"
+	"testing"
+	"time"
+
+	"github.com/kevin07696/receipt-processor/adapters/caches"
+	"github.com/kevin07696/receipt-processor/domain"
+	"github.com/kevin07696/receipt-processor/domain/receipt"
+	"github.com/stretchr/testify/assert"
+)
+
+func init() {
+	gob.Register(receipt.ScoreRecord{})
+}
+
+func newBigCache(t *testing.T, codec caches.Codec) caches.BigCache {
+	t.Helper()
+	cache, err := caches.NewBigCacheFromConfig(context.Background(), caches.BigCacheConfig{
+		Shards:     4,
+		LifeWindow: time.Minute,
+		MaxEntries: 16,
+	}, codec)
+	assert.NoError(t, err)
+	t.Cleanup(func() { cache.Close() })
+	return cache
+}
+
+func TestCodecs(t *testing.T) {
+	record := receipt.ScoreRecord{
+		Points:    28,
+		Breakdown: []receipt.RuleScore{{Rule: receipt.RuleRetailer, Points: 6, Reason: "6 points - retailer name has 6 characters"}},
+		Receipt:   receipt.Receipt{Retailer: "Target", Total: "35.35", Items: []receipt.Item{{ShortDescription: "Pepsi", Price: "1.25"}}},
+	}
+
+	testCases := []struct {
+		title         string
+		codec         caches.Codec
+		value         interface{}
+		expectEncoded bool
+	}{
+		{
+			title:         "GivenAScore_Int64CodecRoundTrips",
+			codec:         caches.Int64Codec{},
+			value:         int64(-42),
+			expectEncoded: true,
+		},
+		{
+			title: "GivenAString_Int64CodecFails",
+			codec: caches.Int64Codec{},
+			value: "42",
+		},
+		{
+			title:         "GivenAScoreRecord_GobCodecRoundTrips",
+			codec:         caches.GobCodec{},
+			value:         record,
+			expectEncoded: true,
+		},
+		{
+			title:         "GivenAScore_GobCodecRoundTrips",
+			codec:         caches.GobCodec{},
+			value:         int64(42),
+			expectEncoded: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.title, func(t *testing.T) {
+			data, err := tc.codec.Encode(tc.value)
+			if !tc.expectEncoded {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+
+			value, err := tc.codec.Decode(data)
+			assert.NoError(t, err)
+			assert.Equal(t, tc.value, value)
+		})
+	}
+}
+
+func TestBigCacheRepository(t *testing.T) {
+	ctx := context.Background()
+	cache := newBigCache(t, caches.GobCodec{})
+	repository := receipt.NewReceiptProcessorRepository(&cache)
+
+	record := receipt.ScoreRecord{Points: 109, Breakdown: []receipt.RuleScore{{Rule: receipt.RuleRoundTotal, Points: 50}}}
+	assert.Equal(t, domain.StatusOK, repository.WriteReceiptScore(ctx, "id", record))
+
+	actual, status := repository.ReadReceiptScore(ctx, "id")
+	assert.Equal(t, domain.StatusOK, status)
+	assert.Equal(t, record, actual)
+
+	_, status = repository.ReadReceiptScore(ctx, "missing")
+	assert.Equal(t, domain.ErrNotFound, status)
+
+	stats := cache.Stats()
+	assert.Equal(t, 1, stats.Size)
+	assert.Equal(t, uint64(1), stats.Hits)
+	assert.Equal(t, uint64(1), stats.Misses)
+}
+
+func TestBigCacheEncodeError(t *testing.T) {
+	cache := newBigCache(t, caches.Int64Codec{})
+
+	status := cache.Set(context.Background(), "id", receipt.ScoreRecord{})
+	assert.Equal(t, domain.ErrInternal, status)
+
+	_, status = cache.Get(context.Background(), "id")
+	assert.Equal(t, domain.ErrNotFound, status)
+}
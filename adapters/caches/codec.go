@@ -0,0 +1,52 @@
+package caches
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/gob"
+	"fmt"
+)
+
+// Codec converts values to bytes and back for caches that only store bytes, like BigCache.
+type Codec interface {
+	Encode(value interface{}) ([]byte, error)
+	Decode(data []byte) (interface{}, error)
+}
+
+// Int64Codec stores an int64 score in 8 big endian bytes.
+type Int64Codec struct{}
+
+func (Int64Codec) Encode(value interface{}) ([]byte, error) {
+	score, ok := value.(int64)
+	if !ok {
+		return nil, fmt.Errorf("int64 codec can not encode %T", value)
+	}
+	return binary.BigEndian.AppendUint64(make([]byte, 0, 8), uint64(score)), nil
+}
+
+func (Int64Codec) Decode(data []byte) (interface{}, error) {
+	if len(data) != 8 {
+		return nil, fmt.Errorf("int64 codec expected 8 bytes, got %d", len(data))
+	}
+	return int64(binary.BigEndian.Uint64(data)), nil
+}
+
+// GobCodec stores any value with its type, so richer records like receipt.ScoreRecord decode to their own type.
+// Concrete types must be registered with gob.Register.
+type GobCodec struct{}
+
+func (GobCodec) Encode(value interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(&value); err != nil {
+		return nil, fmt.Errorf("gob codec can not encode %T: %w", value, err)
+	}
+	return buf.Bytes(), nil
+}
+
+func (GobCodec) Decode(data []byte) (interface{}, error) {
+	var value interface{}
+	if err := gob.NewDecoder(bytes.NewReader(data)).Decode(&value); err != nil {
+		return nil, fmt.Errorf("gob codec can not decode: %w", err)
+	}
+	return value, nil
+}
@@ -4,61 +4,202 @@ import (
 	"container/list"
 	"context"
 	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/kevin07696/receipt-processor/domain"
 )
 
+// Cache is an in-memory repository that evicts and expires its entries.
+type Cache interface {
+	Get(ctx context.Context, key string) (interface{}, domain.StatusCode)
+	Set(ctx context.Context, key string, value interface{}) domain.StatusCode
+	SetWithTTL(ctx context.Context, key string, value interface{}, ttl time.Duration) domain.StatusCode
+	StartJanitor(interval time.Duration) (stop func())
+	Stats() Stats
+}
+
 type LRUCache struct {
 	cache    *sync.Map
 	lruList  *LRUList
 	capacity int
+	ttl      time.Duration
+	stats    *counters
 }
 
 func NewLRUCache(capacity int) LRUCache {
+	return NewLRUCacheWithTTL(capacity, 0)
+}
+
+// NewLRUCacheWithTTL creates a cache whose entries expire ttl after they were last set. A ttl of 0 never expires entries.
+func NewLRUCacheWithTTL(capacity int, ttl time.Duration) LRUCache {
 	return LRUCache{
 		cache:    &sync.Map{},
 		lruList:  NewLRUList(),
 		capacity: capacity,
+		ttl:      ttl,
+		stats:    &counters{},
+	}
+}
+
+// Stats are the cumulative counters of a cache since it was created.
+type Stats struct {
+	Size        int
+	Hits        uint64
+	Misses      uint64
+	Evictions   uint64
+	Expirations uint64
+}
+
+type counters struct {
+	hits        atomic.Uint64
+	misses      atomic.Uint64
+	evictions   atomic.Uint64
+	expirations atomic.Uint64
+}
+
+func (c LRUCache) Stats() Stats {
+	return Stats{
+		Size:        c.lruList.Len(),
+		Hits:        c.stats.hits.Load(),
+		Misses:      c.stats.misses.Load(),
+		Evictions:   c.stats.evictions.Load(),
+		Expirations: c.stats.expirations.Load(),
 	}
 }
 
 type entry struct {
 	key   string
 	value interface{}
+	// expiresAt is the expiry in unix nanoseconds, 0 when the entry never expires
+	expiresAt atomic.Int64
+}
+
+func (e *entry) expired(now time.Time) bool {
+	expiresAt := e.expiresAt.Load()
+	return expiresAt != 0 && now.UnixNano() >= expiresAt
 }
 
 func (c LRUCache) Get(ctx context.Context, key string) (interface{}, domain.StatusCode) {
 	if elem, ok := c.cache.Load(key); ok {
+		e := elem.(*list.Element).Value.(*entry)
+		// Expired entries are removed lazily, the janitor only bounds how long they take up space
+		if e.expired(time.Now()) {
+			c.expire(elem.(*list.Element))
+			c.stats.misses.Add(1)
+			return nil, domain.ErrNotFound
+		}
+
 		// Move the accessed item to the front of the LRU list
 		c.lruList.MoveToFront(elem.(*list.Element))
-		return elem.(*list.Element).Value.(*entry).value, domain.StatusOK
+		c.stats.hits.Add(1)
+		return e.value, domain.StatusOK
 	}
+	c.stats.misses.Add(1)
 	return nil, domain.ErrNotFound
 }
 
 func (c *LRUCache) Set(ctx context.Context, key string, value interface{}) domain.StatusCode {
-	if elem, ok := c.cache.Load(key); ok {
-		// Update the value and move the item to the front of the LRU list
-		c.lruList.MoveToFront(elem.(*list.Element))
-		elem.(*list.Element).Value.(*entry).value = value
-		return domain.StatusOK
+	return c.SetWithTTL(ctx, key, value, c.ttl)
+}
+
+// SetWithTTL stores value with its own ttl instead of the default of the cache. A ttl of 0 never expires the entry.
+func (c *LRUCache) SetWithTTL(ctx context.Context, key string, value interface{}, ttl time.Duration) domain.StatusCode {
+	var expiresAt int64
+	if ttl > 0 {
+		expiresAt = time.Now().Add(ttl).UnixNano()
 	}
 
-	// Add the new item to the front of the LRU list
-	newElem := c.lruList.PushFront(&entry{key: key, value: value})
-	c.cache.Store(key, newElem)
+	// Entries are never changed once stored, an update replaces the element of the key instead, so Get reads
+	// values without a lock and an expiry or a concurrent Set of the same key can not lose the update
+	newEntry := &entry{key: key, value: value}
+	newEntry.expiresAt.Store(expiresAt)
+	newElem := c.lruList.PushFront(newEntry)
+	if previous, loaded := c.cache.Swap(key, newElem); loaded {
+		c.lruList.Remove(previous.(*list.Element))
+	}
 
 	// Evict the least recently used item if the cache exceeds its capacity
 	if c.lruList.Len() > c.capacity {
 		backElem := c.lruList.Back()
 		if backElem != nil {
 			c.lruList.Remove(backElem)
-			c.cache.Delete(backElem.Value.(*entry).key)
+			// The key may already point to a newer entry
+			if c.cache.CompareAndDelete(backElem.Value.(*entry).key, backElem) {
+				c.stats.evictions.Add(1)
+			}
 		}
 	}
 	return domain.StatusOK
 }
 
+// Keys lists the keys that have not expired.
+func (c LRUCache) Keys(ctx context.Context) ([]string, domain.StatusCode) {
+	now := time.Now()
+	var keys []string
+	c.cache.Range(func(key, elem any) bool {
+		if !elem.(*list.Element).Value.(*entry).expired(now) {
+			keys = append(keys, key.(string))
+		}
+		return true
+	})
+	return keys, domain.StatusOK
+}
+
+func (c LRUCache) expire(elem *list.Element) {
+	c.lruList.Remove(elem)
+	// The key may already point to a newer entry
+	if c.cache.CompareAndDelete(elem.Value.(*entry).key, elem) {
+		c.stats.expirations.Add(1)
+	}
+}
+
+// RemoveExpired removes every expired entry and returns how many were removed.
+func (c LRUCache) RemoveExpired() int {
+	now := time.Now()
+	removed := c.lruList.RemoveIf(func(value interface{}) bool {
+		return value.(*entry).expired(now)
+	})
+	for _, elem := range removed {
+		if c.cache.CompareAndDelete(elem.Value.(*entry).key, elem) {
+			c.stats.expirations.Add(1)
+		}
+	}
+	return len(removed)
+}
+
+// StartJanitor removes expired entries every interval in a background goroutine.
+// The returned stop function ends the goroutine and waits for it to return.
+func (c LRUCache) StartJanitor(interval time.Duration) (stop func()) {
+	return startJanitor(interval, c.RemoveExpired)
+}
+
+func startJanitor(interval time.Duration, removeExpired func() int) (stop func()) {
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				removeExpired()
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			close(done)
+			<-stopped
+		})
+	}
+}
+
 type LRUList struct {
 	mu      sync.Mutex
 	lruList *list.List
@@ -96,6 +237,22 @@ func (l *LRUList) Remove(elem *list.Element) {
 	l.lruList.Remove(elem)
 }
 
+// RemoveIf removes every element whose value matches and returns the removed elements.
+func (l *LRUList) RemoveIf(match func(value interface{}) bool) []*list.Element {
+	l.Lock()
+	defer l.Unlock()
+	var removed []*list.Element
+	for elem := l.lruList.Front(); elem != nil; {
+		next := elem.Next()
+		if match(elem.Value) {
+			l.lruList.Remove(elem)
+			removed = append(removed, elem)
+		}
+		elem = next
+	}
+	return removed
+}
+
 func (l *LRUList) Back() *list.Element {
 	l.Lock()
 	defer l.Unlock()
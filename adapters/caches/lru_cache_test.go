@@ -0,0 +1,159 @@
+package caches_test
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/kevin07696/receipt-processor/adapters/caches"
+	"github.com/kevin07696/receipt-processor/domain"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLRUCacheTTL(t *testing.T) {
+	ctx := context.Background()
+
+	testCases := []struct {
+		title          string
+		defaultTTL     time.Duration
+		entryTTL       time.Duration
+		perEntry       bool
+		wait           time.Duration
+		expectedStatus domain.StatusCode
+	}{
+		{
+			title:          "GivenNoTTL_ReturnValue",
+			wait:           20 * time.Millisecond,
+			expectedStatus: domain.StatusOK,
+		},
+		{
+			title:          "GivenAnUnexpiredDefaultTTL_ReturnValue",
+			defaultTTL:     time.Minute,
+			wait:           20 * time.Millisecond,
+			expectedStatus: domain.StatusOK,
+		},
+		{
+			title:          "GivenAnExpiredDefaultTTL_ReturnNotFoundError",
+			defaultTTL:     10 * time.Millisecond,
+			wait:           30 * time.Millisecond,
+			expectedStatus: domain.ErrNotFound,
+		},
+		{
+			title:          "GivenAnExpiredEntryTTL_ReturnNotFoundError",
+			defaultTTL:     time.Minute,
+			entryTTL:       10 * time.Millisecond,
+			perEntry:       true,
+			wait:           30 * time.Millisecond,
+			expectedStatus: domain.ErrNotFound,
+		},
+		{
+			title:          "GivenAnEntryWithoutTTL_IgnoreDefaultTTL",
+			defaultTTL:     10 * time.Millisecond,
+			perEntry:       true,
+			wait:           30 * time.Millisecond,
+			expectedStatus: domain.StatusOK,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.title, func(t *testing.T) {
+			cache := caches.NewLRUCacheWithTTL(2, tc.defaultTTL)
+			if tc.perEntry {
+				cache.SetWithTTL(ctx, "key", 42, tc.entryTTL)
+			} else {
+				cache.Set(ctx, "key", 42)
+			}
+
+			time.Sleep(tc.wait)
+
+			value, status := cache.Get(ctx, "key")
+			assert.Equal(t, tc.expectedStatus, status)
+			if tc.expectedStatus == domain.StatusOK {
+				assert.Equal(t, 42, value)
+				return
+			}
+			assert.Equal(t, 0, cache.Stats().Size)
+			assert.Equal(t, uint64(1), cache.Stats().Expirations)
+		})
+	}
+}
+
+func TestLRUCacheSetRefreshesTTL(t *testing.T) {
+	ctx := context.Background()
+	cache := caches.NewLRUCacheWithTTL(2, 40*time.Millisecond)
+
+	cache.Set(ctx, "key", 1)
+	time.Sleep(25 * time.Millisecond)
+	cache.Set(ctx, "key", 2)
+	time.Sleep(25 * time.Millisecond)
+
+	value, status := cache.Get(ctx, "key")
+	assert.Equal(t, domain.StatusOK, status)
+	assert.Equal(t, 2, value)
+}
+
+func TestLRUCacheJanitor(t *testing.T) {
+	ctx := context.Background()
+	cache := caches.NewLRUCacheWithTTL(10, 10*time.Millisecond)
+	cache.Set(ctx, "expiring", 1)
+	cache.Set(ctx, "expiring too", 2)
+	cache.SetWithTTL(ctx, "kept", 3, 0)
+
+	stop := cache.StartJanitor(5 * time.Millisecond)
+	assert.Eventually(t, func() bool {
+		return cache.Stats().Size == 1
+	}, time.Second, 5*time.Millisecond)
+	stop()
+	stop()
+
+	assert.Equal(t, uint64(2), cache.Stats().Expirations)
+	value, status := cache.Get(ctx, "kept")
+	assert.Equal(t, domain.StatusOK, status)
+	assert.Equal(t, 3, value)
+}
+
+func TestLRUCacheKeys(t *testing.T) {
+	ctx := context.Background()
+	cache := caches.NewLRUCache(3)
+
+	cache.Set(ctx, "a", 1)
+	cache.SetWithTTL(ctx, "expired", 2, time.Millisecond)
+	time.Sleep(5 * time.Millisecond)
+	cache.Set(ctx, "b", 3)
+
+	keys, status := cache.Keys(ctx)
+	assert.Equal(t, domain.StatusOK, status)
+	assert.ElementsMatch(t, []string{"a", "b"}, keys)
+}
+
+func TestLRUCacheConcurrentSetsOfTheSameKeys(t *testing.T) {
+	ctx := context.Background()
+	cache := caches.NewLRUCacheWithTTL(4, time.Millisecond)
+
+	var wg sync.WaitGroup
+	for worker := 0; worker < 8; worker++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 500; i++ {
+				key := fmt.Sprint(i % 3)
+				cache.Set(ctx, key, i)
+				cache.Get(ctx, key)
+			}
+		}()
+	}
+	wg.Wait()
+
+	// Every element of the list belongs to a key, none is left behind by a replaced or expired entry
+	for i := 0; i < 3; i++ {
+		cache.SetWithTTL(ctx, fmt.Sprint(i), i, 0)
+	}
+	assert.Equal(t, 3, cache.Stats().Size)
+	for i := 0; i < 3; i++ {
+		value, status := cache.Get(ctx, fmt.Sprint(i))
+		assert.Equal(t, domain.StatusOK, status)
+		assert.Equal(t, i, value)
+	}
+}
@@ -0,0 +1,189 @@
+package caches
+
+import (
+	"container/list"
+	"context"
+	"sync"
+	"time"
+
+	"github.com/kevin07696/receipt-processor/domain"
+)
+
+// ShardedLRUCache splits the keys over independent LRU shards, each with its own map, list and lock,
+// so requests for keys of different shards never wait on each other.
+// Eviction is least recently used per shard, not across the whole cache.
+type ShardedLRUCache struct {
+	shards []*lruShard
+	mask   uint64
+	ttl    time.Duration
+}
+
+type lruShard struct {
+	mu          sync.Mutex
+	items       map[string]*list.Element
+	lruList     *list.List
+	capacity    int
+	hits        uint64
+	misses      uint64
+	evictions   uint64
+	expirations uint64
+}
+
+// NewShardedLRUCache creates a cache of shards shards that together hold capacity entries.
+// shards is rounded up to a power of two, but not above capacity so every shard holds an entry. A ttl of 0 never expires entries.
+func NewShardedLRUCache(capacity int, shards int, ttl time.Duration) ShardedLRUCache {
+	count := 1
+	for count < shards && count*2 <= capacity {
+		count <<= 1
+	}
+
+	c := ShardedLRUCache{
+		shards: make([]*lruShard, count),
+		mask:   uint64(count - 1),
+		ttl:    ttl,
+	}
+	for i := range c.shards {
+		// The first shards take the remainder, so the shards hold exactly capacity entries together
+		shardCapacity := max(capacity/count, 1)
+		if i < capacity%count {
+			shardCapacity++
+		}
+		c.shards[i] = &lruShard{
+			items:    make(map[string]*list.Element),
+			lruList:  list.New(),
+			capacity: shardCapacity,
+		}
+	}
+	return c
+}
+
+// shard picks the shard of key with the FNV-1a hash of key.
+func (c ShardedLRUCache) shard(key string) *lruShard {
+	const (
+		offset = 14695981039346656037
+		prime  = 1099511628211
+	)
+	hash := uint64(offset)
+	for i := 0; i < len(key); i++ {
+		hash ^= uint64(key[i])
+		hash *= prime
+	}
+	return c.shards[hash&c.mask]
+}
+
+func (c ShardedLRUCache) Get(ctx context.Context, key string) (interface{}, domain.StatusCode) {
+	s := c.shard(key)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	elem, ok := s.items[key]
+	if !ok {
+		s.misses++
+		return nil, domain.ErrNotFound
+	}
+
+	e := elem.Value.(*entry)
+	if e.expired(time.Now()) {
+		s.remove(elem)
+		s.expirations++
+		s.misses++
+		return nil, domain.ErrNotFound
+	}
+
+	s.lruList.MoveToFront(elem)
+	s.hits++
+	return e.value, domain.StatusOK
+}
+
+func (c *ShardedLRUCache) Set(ctx context.Context, key string, value interface{}) domain.StatusCode {
+	return c.SetWithTTL(ctx, key, value, c.ttl)
+}
+
+// SetWithTTL stores value in the shard of key, evicting from that shard only.
+func (c *ShardedLRUCache) SetWithTTL(ctx context.Context, key string, value interface{}, ttl time.Duration) domain.StatusCode {
+	var expiresAt int64
+	if ttl > 0 {
+		expiresAt = time.Now().Add(ttl).UnixNano()
+	}
+
+	s := c.shard(key)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if elem, ok := s.items[key]; ok {
+		s.lruList.MoveToFront(elem)
+		e := elem.Value.(*entry)
+		e.value = value
+		e.expiresAt.Store(expiresAt)
+		return domain.StatusOK
+	}
+
+	newEntry := &entry{key: key, value: value}
+	newEntry.expiresAt.Store(expiresAt)
+	s.items[key] = s.lruList.PushFront(newEntry)
+
+	if s.lruList.Len() > s.capacity {
+		s.remove(s.lruList.Back())
+		s.evictions++
+	}
+	return domain.StatusOK
+}
+
+func (s *lruShard) remove(elem *list.Element) {
+	s.lruList.Remove(elem)
+	delete(s.items, elem.Value.(*entry).key)
+}
+
+// Keys locks one shard at a time, so the keys are not a snapshot of the whole cache.
+func (c ShardedLRUCache) Keys(ctx context.Context) ([]string, domain.StatusCode) {
+	now := time.Now()
+	var keys []string
+	for _, s := range c.shards {
+		s.mu.Lock()
+		for key, elem := range s.items {
+			if !elem.Value.(*entry).expired(now) {
+				keys = append(keys, key)
+			}
+		}
+		s.mu.Unlock()
+	}
+	return keys, domain.StatusOK
+}
+
+// RemoveExpired sweeps the shards one at a time, so only one shard is blocked at any moment.
+func (c ShardedLRUCache) RemoveExpired() int {
+	removed := 0
+	for _, s := range c.shards {
+		now := time.Now()
+		s.mu.Lock()
+		for elem := s.lruList.Front(); elem != nil; {
+			next := elem.Next()
+			if elem.Value.(*entry).expired(now) {
+				s.remove(elem)
+				s.expirations++
+				removed++
+			}
+			elem = next
+		}
+		s.mu.Unlock()
+	}
+	return removed
+}
+
+func (c ShardedLRUCache) StartJanitor(interval time.Duration) (stop func()) {
+	return startJanitor(interval, c.RemoveExpired)
+}
+
+func (c ShardedLRUCache) Stats() Stats {
+	var stats Stats
+	for _, s := range c.shards {
+		s.mu.Lock()
+		stats.Size += s.lruList.Len()
+		stats.Hits += s.hits
+		stats.Misses += s.misses
+		stats.Evictions += s.evictions
+		stats.Expirations += s.expirations
+		s.mu.Unlock()
+	}
+	return stats
+}
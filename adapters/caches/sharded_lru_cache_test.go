@@ -0,0 +1,131 @@
+package caches_test
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/kevin07696/receipt-processor/adapters/caches"
+	"github.com/kevin07696/receipt-processor/domain"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestShardedLRUCache(t *testing.T) {
+	ctx := context.Background()
+
+	testCases := []struct {
+		title          string
+		capacity       int
+		shards         int
+		keys           int
+		expectedSize   int
+		expectedEvicts uint64
+	}{
+		{
+			title:        "GivenKeysWithinCapacity_KeepEveryKey",
+			capacity:     64,
+			shards:       4,
+			keys:         16,
+			expectedSize: 16,
+		},
+		{
+			title:          "GivenOneShard_EvictLeastRecentlyUsed",
+			capacity:       4,
+			shards:         1,
+			keys:           10,
+			expectedSize:   4,
+			expectedEvicts: 6,
+		},
+		{
+			title:          "GivenACapacityThatDoesNotSplitEvenly_HoldCapacity",
+			capacity:       10,
+			shards:         4,
+			keys:           1000,
+			expectedSize:   10,
+			expectedEvicts: 990,
+		},
+		{
+			title:          "GivenMoreShardsThanCapacity_HoldCapacity",
+			capacity:       3,
+			shards:         8,
+			keys:           1000,
+			expectedSize:   3,
+			expectedEvicts: 997,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.title, func(t *testing.T) {
+			cache := caches.NewShardedLRUCache(tc.capacity, tc.shards, 0)
+			for i := 0; i < tc.keys; i++ {
+				assert.Equal(t, domain.StatusOK, cache.Set(ctx, fmt.Sprintf("key-%d", i), i))
+			}
+
+			stats := cache.Stats()
+			assert.Equal(t, tc.expectedSize, stats.Size)
+			assert.Equal(t, tc.expectedEvicts, stats.Evictions)
+
+			value, status := cache.Get(ctx, fmt.Sprintf("key-%d", tc.keys-1))
+			assert.Equal(t, domain.StatusOK, status)
+			assert.Equal(t, tc.keys-1, value)
+		})
+	}
+}
+
+func TestShardedLRUCacheKeepsRecentlyUsed(t *testing.T) {
+	ctx := context.Background()
+	cache := caches.NewShardedLRUCache(2, 1, 0)
+
+	cache.Set(ctx, "a", 1)
+	cache.Set(ctx, "b", 2)
+	cache.Get(ctx, "a")
+	cache.Set(ctx, "c", 3)
+
+	_, status := cache.Get(ctx, "b")
+	assert.Equal(t, domain.ErrNotFound, status)
+	value, status := cache.Get(ctx, "a")
+	assert.Equal(t, domain.StatusOK, status)
+	assert.Equal(t, 1, value)
+}
+
+func TestShardedLRUCacheTTL(t *testing.T) {
+	ctx := context.Background()
+	cache := caches.NewShardedLRUCache(16, 4, 10*time.Millisecond)
+	cache.Set(ctx, "expiring", 1)
+	cache.Set(ctx, "expiring too", 2)
+	cache.SetWithTTL(ctx, "kept", 3, 0)
+
+	time.Sleep(30 * time.Millisecond)
+	_, status := cache.Get(ctx, "expiring")
+	assert.Equal(t, domain.ErrNotFound, status)
+
+	assert.Equal(t, 1, cache.RemoveExpired())
+	stats := cache.Stats()
+	assert.Equal(t, 1, stats.Size)
+	assert.Equal(t, uint64(2), stats.Expirations)
+}
+
+func TestShardedLRUCacheConcurrentAccess(t *testing.T) {
+	ctx := context.Background()
+	cache := caches.NewShardedLRUCache(1024, 8, 0)
+
+	var wg sync.WaitGroup
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for i := 0; i < 100; i++ {
+				key := fmt.Sprintf("key-%d-%d", g, i)
+				cache.Set(ctx, key, i)
+				value, status := cache.Get(ctx, key)
+				assert.Equal(t, domain.StatusOK, status)
+				assert.Equal(t, i, value)
+			}
+		}(g)
+	}
+	wg.Wait()
+
+	assert.Equal(t, 800, cache.Stats().Size)
+}
@@ -0,0 +1,28 @@
+package stores
+
+import (
+	"github.com/kevin07696/receipt-processor/domain/receipt"
+)
+
+// CampaignFile keeps the campaigns of a receipt.CampaignRegistry in a JSON file.
+type CampaignFile struct {
+	path string
+}
+
+func NewCampaignFile(path string) CampaignFile {
+	return CampaignFile{path: path}
+}
+
+// LoadCampaigns reads the campaigns of the file. A file that does not exist yet has no campaigns.
+func (f CampaignFile) LoadCampaigns() ([]receipt.Campaign, error) {
+	var campaigns []receipt.Campaign
+	if err := readJSONFile(f.path, &campaigns); err != nil {
+		return nil, err
+	}
+	return campaigns, nil
+}
+
+// SaveCampaigns replaces the file with the campaigns.
+func (f CampaignFile) SaveCampaigns(campaigns []receipt.Campaign) error {
+	return writeJSONFile(f.path, campaigns)
+}
@@ -0,0 +1,29 @@
+package stores_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/kevin07696/receipt-processor/adapters/stores"
+	"github.com/kevin07696/receipt-processor/domain/receipt"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCampaignFile(t *testing.T) {
+	file := stores.NewCampaignFile(filepath.Join(t.TempDir(), "campaigns.json"))
+
+	// A file that does not exist yet has no campaigns
+	campaigns, err := file.LoadCampaigns()
+	assert.NoError(t, err)
+	assert.Empty(t, campaigns)
+
+	expected := []receipt.Campaign{
+		{ID: "december-weekends", Name: "December weekends", Start: "2024-12-01", End: "2024-12-31", Match: receipt.CampaignMatch{Weekdays: []string{"saturday", "sunday"}}, Factor: 2},
+		{ID: "doritos", Name: "Doritos", Start: "2024-01-01", End: "2024-12-31", Match: receipt.CampaignMatch{Items: []string{"Doritos"}}, Bonus: 100},
+	}
+	assert.NoError(t, file.SaveCampaigns(expected))
+
+	campaigns, err = file.LoadCampaigns()
+	assert.NoError(t, err)
+	assert.Equal(t, expected, campaigns)
+}
@@ -0,0 +1,346 @@
+package stores
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"maps"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/kevin07696/receipt-processor/domain"
+)
+
+const (
+	logFileName      = "receipts.log"
+	snapshotFileName = "receipts.snapshot"
+	// maxEntrySize bounds an encoded entry, so a corrupt length header can not make a replay allocate gigabytes
+	maxEntrySize = 16 << 20
+)
+
+var errCorruptEntry = errors.New("corrupt store entry")
+
+// FileStore keeps every value in memory and appends each write to a log on disk.
+// Once the log holds compactEvery entries it is compacted into a snapshot in the background.
+// Values are gob encoded, so their concrete types must be registered with gob.Register.
+type FileStore struct {
+	mu           sync.RWMutex
+	data         map[string]interface{}
+	dir          string
+	log          *os.File
+	logEntries   int
+	compactEvery int
+	// compactMu lets one compaction run at a time, compactions asks the background compactor for one
+	compactMu   sync.Mutex
+	compactions chan struct{}
+	compacted   chan struct{}
+	closeOnce   sync.Once
+}
+
+type fileEntry struct {
+	Key   string
+	Value interface{}
+}
+
+// NewFileStore replays the snapshot and log found in dir and opens the log for appending.
+func NewFileStore(dir string, compactEvery int) (*FileStore, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("create store directory: %w", err)
+	}
+
+	s := &FileStore{
+		data:         map[string]interface{}{},
+		dir:          dir,
+		compactEvery: compactEvery,
+	}
+
+	// The snapshot is only ever replaced whole, so unlike the log it can not end in a torn write
+	if _, err := s.replay(filepath.Join(dir, snapshotFileName), false); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("replay snapshot: %w", err)
+	}
+
+	logPath := filepath.Join(dir, logFileName)
+	entries, err := s.replay(logPath, true)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("replay log: %w", err)
+	}
+	s.logEntries = entries
+
+	s.log, err = os.OpenFile(logPath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("open log: %w", err)
+	}
+
+	if compactEvery > 0 {
+		s.compactions = make(chan struct{}, 1)
+		s.compacted = make(chan struct{})
+		go s.compactor()
+	}
+	return s, nil
+}
+
+func (s *FileStore) Get(ctx context.Context, key string) (interface{}, domain.StatusCode) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	value, ok := s.data[key]
+	if !ok {
+		return nil, domain.ErrNotFound
+	}
+	return value, domain.StatusOK
+}
+
+func (s *FileStore) Set(ctx context.Context, key string, value interface{}) domain.StatusCode {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := writeEntry(s.log, fileEntry{Key: key, Value: value}); err != nil {
+		slog.ErrorContext(ctx, "Failed to append to store log.", slog.String("key", key), slog.Any("error", err))
+		return domain.ErrInternal
+	}
+	if err := s.log.Sync(); err != nil {
+		slog.ErrorContext(ctx, "Failed to sync store log.", slog.String("key", key), slog.Any("error", err))
+		return domain.ErrInternal
+	}
+
+	s.data[key] = value
+	s.logEntries++
+
+	if s.compactEvery > 0 && s.logEntries >= s.compactEvery {
+		// A compaction already asked for covers this write too
+		select {
+		case s.compactions <- struct{}{}:
+		default:
+		}
+	}
+
+	return domain.StatusOK
+}
+
+// compactor compacts the log whenever Set asks for it, until Close.
+func (s *FileStore) compactor() {
+	defer close(s.compacted)
+	for range s.compactions {
+		if err := s.Compact(); err != nil {
+			// The log still holds every entry, so the store stays durable and compaction is retried on the next write.
+			slog.Error("Failed to compact store log.", slog.Any("error", err))
+		}
+	}
+}
+
+// Close waits for a running compaction and closes the log. The store can not be used after it is closed.
+func (s *FileStore) Close() error {
+	s.closeOnce.Do(func() {
+		if s.compactions != nil {
+			close(s.compactions)
+			<-s.compacted
+		}
+	})
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.log.Close()
+}
+
+func (s *FileStore) Keys(ctx context.Context) ([]string, domain.StatusCode) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	keys := make([]string, 0, len(s.data))
+	for key := range s.data {
+		keys = append(keys, key)
+	}
+	return keys, domain.StatusOK
+}
+
+func (s *FileStore) Len() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return len(s.data)
+}
+
+// Compact writes every value to a new snapshot and drops the log entries it holds. Writes only wait while the
+// values are copied and while the entries appended during the compaction are moved to a new log.
+func (s *FileStore) Compact() error {
+	s.compactMu.Lock()
+	defer s.compactMu.Unlock()
+
+	s.mu.RLock()
+	data := maps.Clone(s.data)
+	entries := s.logEntries
+	info, err := s.log.Stat()
+	s.mu.RUnlock()
+	if err != nil {
+		return err
+	}
+
+	if err := s.writeSnapshot(data); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.dropLog(info.Size(), entries)
+}
+
+// writeSnapshot replaces the snapshot with data.
+func (s *FileStore) writeSnapshot(data map[string]interface{}) error {
+	tmpPath := filepath.Join(s.dir, snapshotFileName+".tmp")
+	tmp, err := os.Create(tmpPath)
+	if err != nil {
+		return err
+	}
+
+	w := bufio.NewWriter(tmp)
+	for key, value := range data {
+		if err := writeEntry(w, fileEntry{Key: key, Value: value}); err != nil {
+			tmp.Close()
+			return err
+		}
+	}
+	if err := w.Flush(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	// The rename is atomic, so a crash leaves either the old snapshot or the new one, each followed by the full log.
+	// Replaying the full log over the new snapshot ends with the same values.
+	return os.Rename(tmpPath, filepath.Join(s.dir, snapshotFileName))
+}
+
+// dropLog replaces the log with the entries appended after its first size bytes, which held entries entries.
+func (s *FileStore) dropLog(size int64, entries int) error {
+	logPath := filepath.Join(s.dir, logFileName)
+	current, err := os.Open(logPath)
+	if err != nil {
+		return err
+	}
+	defer current.Close()
+	if _, err := current.Seek(size, io.SeekStart); err != nil {
+		return err
+	}
+
+	// The new log is appended to through the file it was written with, so it can not fail to open after the rename
+	tmpPath := logPath + ".tmp"
+	tmp, err := os.OpenFile(tmpPath, os.O_CREATE|os.O_TRUNC|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(tmp, current); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := os.Rename(tmpPath, logPath); err != nil {
+		tmp.Close()
+		return err
+	}
+
+	s.log.Close()
+	s.log = tmp
+	s.logEntries -= entries
+
+	return nil
+}
+
+// replay loads the entries of a file into memory and returns how many it read. When truncate is set a last entry
+// that runs past the end of the file is taken for a torn write and cut off. Any other corrupt entry fails the replay.
+func (s *FileStore) replay(path string, truncate bool) (int, error) {
+	flag := os.O_RDONLY
+	if truncate {
+		flag = os.O_RDWR
+	}
+	f, err := os.OpenFile(path, flag, 0o644)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return 0, err
+	}
+
+	r := bufio.NewReader(f)
+	var entries int
+	var offset int64
+	for {
+		entry, n, err := readEntry(r, info.Size()-offset)
+		if err == io.EOF {
+			return entries, nil
+		}
+		if err == io.ErrUnexpectedEOF && truncate {
+			slog.Warn("Truncating torn store entry.", slog.String("file", path), slog.Int64("offset", offset))
+			return entries, f.Truncate(offset)
+		}
+		if err != nil {
+			return entries, fmt.Errorf("entry at offset %d: %w", offset, err)
+		}
+
+		s.data[entry.Key] = entry.Value
+		offset += n
+		entries++
+	}
+}
+
+// writeEntry frames each entry with its length. Every entry gets its own gob encoder
+// so that entries appended after a restart can be decoded on their own.
+func writeEntry(w io.Writer, entry fileEntry) error {
+	// Reserve the length header so the entry is written in a single call
+	buf := bytes.NewBuffer(make([]byte, 4))
+	if err := gob.NewEncoder(buf).Encode(&entry); err != nil {
+		return err
+	}
+
+	frame := buf.Bytes()
+	if len(frame)-4 > maxEntrySize {
+		return fmt.Errorf("entry of %d bytes is over the limit of %d bytes", len(frame)-4, maxEntrySize)
+	}
+	binary.BigEndian.PutUint32(frame[:4], uint32(len(frame)-4))
+	_, err := w.Write(frame)
+	return err
+}
+
+// readEntry reads the next entry of the remaining bytes of a file. It returns io.ErrUnexpectedEOF when the entry
+// runs past the end of the file and errCorruptEntry when the entry can not be one that writeEntry wrote.
+func readEntry(r io.Reader, remaining int64) (fileEntry, int64, error) {
+	var header [4]byte
+	if _, err := io.ReadFull(r, header[:]); err != nil {
+		return fileEntry{}, 0, err
+	}
+
+	size := binary.BigEndian.Uint32(header[:])
+	if size > maxEntrySize {
+		return fileEntry{}, 0, fmt.Errorf("%w: length %d is over the limit of %d bytes", errCorruptEntry, size, maxEntrySize)
+	}
+	if int64(size) > remaining-int64(len(header)) {
+		return fileEntry{}, 0, io.ErrUnexpectedEOF
+	}
+
+	body := make([]byte, size)
+	if _, err := io.ReadFull(r, body); err != nil {
+		return fileEntry{}, 0, err
+	}
+
+	var entry fileEntry
+	if err := gob.NewDecoder(bytes.NewReader(body)).Decode(&entry); err != nil {
+		return fileEntry{}, 0, fmt.Errorf("%w: %v", errCorruptEntry, err)
+	}
+
+	return entry, int64(len(header) + len(body)), nil
+}
@@ -0,0 +1,235 @@
+package stores_test
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/gob"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/kevin07696/receipt-processor/adapters/stores"
+	"github.com/kevin07696/receipt-processor/domain"
+	"github.com/kevin07696/receipt-processor/domain/receipt"
+	"github.com/stretchr/testify/assert"
+)
+
+func init() {
+	gob.Register(receipt.ScoreRecord{})
+}
+
+func TestFileStoreReplay(t *testing.T) {
+	testCases := []struct {
+		title        string
+		compactEvery int
+		writes       int
+	}{
+		{
+			title:        "GivenOnlyLogEntries_ReplayLog",
+			compactEvery: 0,
+			writes:       5,
+		},
+		{
+			title:        "GivenACompactedStore_ReplaySnapshotAndLog",
+			compactEvery: 3,
+			writes:       5,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.title, func(t *testing.T) {
+			dir := t.TempDir()
+			store, err := stores.NewFileStore(dir, tc.compactEvery)
+			if err != nil {
+				t.Fatalf("Failed to open store: %v", err)
+			}
+
+			for i := 0; i < tc.writes; i++ {
+				record := receipt.ScoreRecord{Points: int64(i), Breakdown: []receipt.RuleScore{{Rule: receipt.RuleRetailer, Points: int64(i)}}}
+				assert.Equal(t, domain.StatusOK, store.Set(context.TODO(), string(rune('a'+i)), record))
+			}
+			// Overwrites must win on replay
+			store.Set(context.TODO(), "a", receipt.ScoreRecord{Points: 100})
+			store.Close()
+
+			reopened, err := stores.NewFileStore(dir, tc.compactEvery)
+			if err != nil {
+				t.Fatalf("Failed to reopen store: %v", err)
+			}
+			defer reopened.Close()
+
+			assert.Equal(t, tc.writes, reopened.Len())
+			value, status := reopened.Get(context.TODO(), "a")
+			assert.Equal(t, domain.StatusOK, status)
+			assert.Equal(t, receipt.ScoreRecord{Points: 100}, value)
+
+			value, status = reopened.Get(context.TODO(), "b")
+			assert.Equal(t, domain.StatusOK, status)
+			assert.Equal(t, int64(1), value.(receipt.ScoreRecord).Points)
+
+			_, status = reopened.Get(context.TODO(), "unknown")
+			assert.Equal(t, domain.ErrNotFound, status)
+		})
+	}
+}
+
+func TestFileStoreTornWrite(t *testing.T) {
+	dir := t.TempDir()
+	store, err := stores.NewFileStore(dir, 0)
+	if err != nil {
+		t.Fatalf("Failed to open store: %v", err)
+	}
+	store.Set(context.TODO(), "a", receipt.ScoreRecord{Points: 1})
+	store.Close()
+
+	// Simulate a crash in the middle of appending an entry
+	logFile, err := os.OpenFile(filepath.Join(dir, "receipts.log"), os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		t.Fatalf("Failed to open log: %v", err)
+	}
+	logFile.Write([]byte{0, 0, 1, 0, 42})
+	logFile.Close()
+
+	reopened, err := stores.NewFileStore(dir, 0)
+	if err != nil {
+		t.Fatalf("Failed to reopen store: %v", err)
+	}
+
+	assert.Equal(t, 1, reopened.Len())
+	assert.Equal(t, domain.StatusOK, reopened.Set(context.TODO(), "b", receipt.ScoreRecord{Points: 2}))
+	reopened.Close()
+
+	reopened, err = stores.NewFileStore(dir, 0)
+	if err != nil {
+		t.Fatalf("Failed to reopen store: %v", err)
+	}
+	defer reopened.Close()
+
+	value, status := reopened.Get(context.TODO(), "b")
+	assert.Equal(t, domain.StatusOK, status)
+	assert.Equal(t, receipt.ScoreRecord{Points: 2}, value)
+}
+
+func TestFileStoreCorruptLogEntry(t *testing.T) {
+	testCases := []struct {
+		title   string
+		corrupt func(frame []byte)
+	}{
+		{
+			title: "GivenACorruptBody_FailToOpen",
+			corrupt: func(frame []byte) {
+				for i := 4; i < len(frame); i++ {
+					frame[i] = 0xFF
+				}
+			},
+		},
+		{
+			title:   "GivenALengthOverTheLimit_FailToOpen",
+			corrupt: func(frame []byte) { binary.BigEndian.PutUint32(frame[:4], 0xFFFFFFFF) },
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.title, func(t *testing.T) {
+			dir := t.TempDir()
+			store, err := stores.NewFileStore(dir, 0)
+			if err != nil {
+				t.Fatalf("Failed to open store: %v", err)
+			}
+			for _, key := range []string{"a", "b", "c"} {
+				store.Set(context.TODO(), key, receipt.ScoreRecord{Points: 1})
+			}
+			store.Close()
+
+			// Corrupt the entry in the middle of the log, followed by a valid one
+			logPath := filepath.Join(dir, "receipts.log")
+			data, err := os.ReadFile(logPath)
+			if err != nil {
+				t.Fatalf("Failed to read log: %v", err)
+			}
+			start := 4 + int(binary.BigEndian.Uint32(data[:4]))
+			end := start + 4 + int(binary.BigEndian.Uint32(data[start:start+4]))
+			tc.corrupt(data[start:end])
+			if err := os.WriteFile(logPath, data, 0o644); err != nil {
+				t.Fatalf("Failed to write log: %v", err)
+			}
+
+			_, err = stores.NewFileStore(dir, 0)
+			assert.Error(t, err)
+
+			// The valid entries after the corrupt one are kept
+			kept, _ := os.ReadFile(logPath)
+			assert.Equal(t, data, kept)
+		})
+	}
+}
+
+func TestFileStoreCorruptSnapshot(t *testing.T) {
+	dir := t.TempDir()
+	store, err := stores.NewFileStore(dir, 0)
+	if err != nil {
+		t.Fatalf("Failed to open store: %v", err)
+	}
+	store.Set(context.TODO(), "a", receipt.ScoreRecord{Points: 1})
+	assert.NoError(t, store.Compact())
+	store.Close()
+
+	snapshotPath := filepath.Join(dir, "receipts.snapshot")
+	snapshot, err := os.ReadFile(snapshotPath)
+	if err != nil {
+		t.Fatalf("Failed to read snapshot: %v", err)
+	}
+	corrupt := append(snapshot, 0, 0, 1, 0, 42)
+	if err := os.WriteFile(snapshotPath, corrupt, 0o644); err != nil {
+		t.Fatalf("Failed to write snapshot: %v", err)
+	}
+
+	_, err = stores.NewFileStore(dir, 0)
+	assert.Error(t, err)
+
+	// The snapshot is left as it was for an operator to inspect
+	kept, _ := os.ReadFile(snapshotPath)
+	assert.Equal(t, corrupt, kept)
+}
+
+func TestFileStoreCompactDuringWrites(t *testing.T) {
+	dir := t.TempDir()
+	store, err := stores.NewFileStore(dir, 0)
+	if err != nil {
+		t.Fatalf("Failed to open store: %v", err)
+	}
+
+	const writes = 200
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < writes; i++ {
+			assert.Equal(t, domain.StatusOK, store.Set(context.TODO(), fmt.Sprint(i), receipt.ScoreRecord{Points: int64(i)}))
+		}
+	}()
+	for i := 0; i < 5; i++ {
+		assert.NoError(t, store.Compact())
+	}
+	wg.Wait()
+	store.Close()
+
+	reopened, err := stores.NewFileStore(dir, 0)
+	if err != nil {
+		t.Fatalf("Failed to reopen store: %v", err)
+	}
+	defer reopened.Close()
+
+	assert.Equal(t, writes, reopened.Len())
+	value, status := reopened.Get(context.TODO(), fmt.Sprint(writes-1))
+	assert.Equal(t, domain.StatusOK, status)
+	assert.Equal(t, receipt.ScoreRecord{Points: writes - 1}, value)
+
+	// Once compacted without concurrent writes the log is empty
+	assert.NoError(t, reopened.Compact())
+	info, err := os.Stat(filepath.Join(dir, "receipts.log"))
+	assert.NoError(t, err)
+	assert.Zero(t, info.Size())
+}
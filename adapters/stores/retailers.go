@@ -0,0 +1,71 @@
+package stores
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+
+	"github.com/kevin07696/receipt-processor/domain/receipt"
+)
+
+// RetailerFile keeps the retailers of a receipt.RetailerRegistry in a JSON file.
+type RetailerFile struct {
+	path string
+}
+
+func NewRetailerFile(path string) RetailerFile {
+	return RetailerFile{path: path}
+}
+
+// LoadRetailers reads the retailers of the file. A file that does not exist yet has no retailers.
+func (f RetailerFile) LoadRetailers() ([]receipt.Retailer, error) {
+	var retailers []receipt.Retailer
+	if err := readJSONFile(f.path, &retailers); err != nil {
+		return nil, err
+	}
+	return retailers, nil
+}
+
+// SaveRetailers replaces the file with the retailers.
+func (f RetailerFile) SaveRetailers(retailers []receipt.Retailer) error {
+	return writeJSONFile(f.path, retailers)
+}
+
+// readJSONFile decodes the file into value. A file that does not exist leaves value unchanged.
+func readJSONFile(path string, value any) error {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, value)
+}
+
+// writeJSONFile replaces the file with value encoded as indented JSON.
+func writeJSONFile(path string, value any) error {
+	data, err := json.MarshalIndent(value, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	tmpPath := path + ".tmp"
+	tmp, err := os.Create(tmpPath)
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmpPath, path)
+}
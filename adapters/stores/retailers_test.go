@@ -0,0 +1,29 @@
+package stores_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/kevin07696/receipt-processor/adapters/stores"
+	"github.com/kevin07696/receipt-processor/domain/receipt"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRetailerFile(t *testing.T) {
+	file := stores.NewRetailerFile(filepath.Join(t.TempDir(), "retailers.json"))
+
+	// A file that does not exist yet has no retailers
+	retailers, err := file.LoadRetailers()
+	assert.NoError(t, err)
+	assert.Empty(t, retailers)
+
+	expected := []receipt.Retailer{
+		{ID: "target", Name: "Target", Aliases: []string{"Target Store"}},
+		{ID: "walmart", Name: "Walmart"},
+	}
+	assert.NoError(t, file.SaveRetailers(expected))
+
+	retailers, err = file.LoadRetailers()
+	assert.NoError(t, err)
+	assert.Equal(t, expected, retailers)
+}
@@ -0,0 +1,442 @@
+package stores
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/kevin07696/receipt-processor/domain"
+	"github.com/kevin07696/receipt-processor/domain/receipt"
+
+	// Pure Go driver, so the server still builds with CGO_ENABLED=0
+	_ "modernc.org/sqlite"
+)
+
+// migrations are applied in order at startup. Append new migrations, never edit applied ones.
+var migrations = []string{
+	`CREATE TABLE receipts (
+		id            TEXT PRIMARY KEY,
+		retailer      TEXT NOT NULL,
+		purchase_date TEXT NOT NULL,
+		purchase_time TEXT NOT NULL,
+		total         TEXT NOT NULL,
+		created_at    TEXT NOT NULL
+	);
+	CREATE TABLE items (
+		receipt_id        TEXT NOT NULL REFERENCES receipts(id) ON DELETE CASCADE,
+		position          INTEGER NOT NULL,
+		short_description TEXT NOT NULL,
+		price             TEXT NOT NULL,
+		PRIMARY KEY (receipt_id, position)
+	);
+	CREATE TABLE scores (
+		receipt_id TEXT PRIMARY KEY REFERENCES receipts(id) ON DELETE CASCADE,
+		points     INTEGER NOT NULL
+	);
+	CREATE TABLE rule_scores (
+		receipt_id TEXT NOT NULL REFERENCES scores(receipt_id) ON DELETE CASCADE,
+		position   INTEGER NOT NULL,
+		rule       TEXT NOT NULL,
+		points     INTEGER NOT NULL,
+		reason     TEXT NOT NULL,
+		PRIMARY KEY (receipt_id, position)
+	);`,
+	`ALTER TABLE scores ADD COLUMN rule_set_version TEXT NOT NULL DEFAULT '';`,
+	`ALTER TABLE scores ADD COLUMN scored_at TEXT NOT NULL DEFAULT '';
+	CREATE TABLE score_history (
+		receipt_id       TEXT NOT NULL REFERENCES scores(receipt_id) ON DELETE CASCADE,
+		revision         INTEGER NOT NULL,
+		points           INTEGER NOT NULL,
+		rule_set_version TEXT NOT NULL,
+		scored_at        TEXT NOT NULL,
+		PRIMARY KEY (receipt_id, revision)
+	);`,
+	`ALTER TABLE receipts ADD COLUMN subtotal TEXT NOT NULL DEFAULT '';
+	ALTER TABLE receipts ADD COLUMN tax TEXT NOT NULL DEFAULT '';
+	ALTER TABLE receipts ADD COLUMN tip TEXT NOT NULL DEFAULT '';
+	ALTER TABLE receipts ADD COLUMN payment_method TEXT NOT NULL DEFAULT '';
+	CREATE TABLE discounts (
+		receipt_id  TEXT NOT NULL REFERENCES receipts(id) ON DELETE CASCADE,
+		position    INTEGER NOT NULL,
+		description TEXT NOT NULL,
+		amount      TEXT NOT NULL,
+		coupon      INTEGER NOT NULL,
+		PRIMARY KEY (receipt_id, position)
+	);`,
+	`ALTER TABLE items ADD COLUMN quantity TEXT NOT NULL DEFAULT '';
+	ALTER TABLE items ADD COLUMN unit_price TEXT NOT NULL DEFAULT '';
+	ALTER TABLE items ADD COLUMN sku TEXT NOT NULL DEFAULT '';
+	ALTER TABLE items ADD COLUMN upc TEXT NOT NULL DEFAULT '';
+	ALTER TABLE items ADD COLUMN category TEXT NOT NULL DEFAULT '';`,
+	`ALTER TABLE scores ADD COLUMN retailer_id TEXT NOT NULL DEFAULT '';`,
+	`CREATE TABLE score_campaigns (
+		receipt_id  TEXT NOT NULL REFERENCES scores(receipt_id) ON DELETE CASCADE,
+		position    INTEGER NOT NULL,
+		campaign_id TEXT NOT NULL,
+		PRIMARY KEY (receipt_id, position)
+	);`,
+	`ALTER TABLE receipts ADD COLUMN user_id TEXT NOT NULL DEFAULT '';
+	ALTER TABLE rule_scores ADD COLUMN capped INTEGER NOT NULL DEFAULT 0;`,
+}
+
+// SQLiteRepository stores the full receipt, its items and its score breakdown in an embedded SQLite database.
+type SQLiteRepository struct {
+	db *sql.DB
+}
+
+// NewSQLiteRepository opens the database at path and runs any pending migrations.
+func NewSQLiteRepository(ctx context.Context, path string) (*SQLiteRepository, error) {
+	db, err := sql.Open("sqlite", path+"?_pragma=foreign_keys(1)&_pragma=journal_mode(WAL)&_pragma=busy_timeout(5000)")
+	if err != nil {
+		return nil, fmt.Errorf("open sqlite: %w", err)
+	}
+	// SQLite allows a single writer, so share one connection instead of queueing on busy errors
+	db.SetMaxOpenConns(1)
+
+	if err := migrate(ctx, db); err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return &SQLiteRepository{db: db}, nil
+}
+
+func migrate(ctx context.Context, db *sql.DB) error {
+	if _, err := db.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS schema_migrations (version INTEGER PRIMARY KEY, applied_at TEXT NOT NULL)`); err != nil {
+		return fmt.Errorf("create schema_migrations: %w", err)
+	}
+
+	var version int
+	if err := db.QueryRowContext(ctx, `SELECT COALESCE(MAX(version), 0) FROM schema_migrations`).Scan(&version); err != nil {
+		return fmt.Errorf("read schema version: %w", err)
+	}
+
+	for i := version; i < len(migrations); i++ {
+		tx, err := db.BeginTx(ctx, nil)
+		if err != nil {
+			return err
+		}
+		if _, err := tx.ExecContext(ctx, migrations[i]); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("apply migration %d: %w", i+1, err)
+		}
+		if _, err := tx.ExecContext(ctx, `INSERT INTO schema_migrations (version, applied_at) VALUES (?, ?)`, i+1, time.Now().UTC().Format(time.RFC3339)); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("record migration %d: %w", i+1, err)
+		}
+		if err := tx.Commit(); err != nil {
+			return fmt.Errorf("commit migration %d: %w", i+1, err)
+		}
+		slog.Info(fmt.Sprintf("Applied sqlite migration %d", i+1))
+	}
+
+	return nil
+}
+
+func (r *SQLiteRepository) Close() error {
+	return r.db.Close()
+}
+
+func (r *SQLiteRepository) WriteReceiptScore(ctx context.Context, id string, record receipt.ScoreRecord) domain.StatusCode {
+	if err := r.writeReceiptScore(ctx, id, record); err != nil {
+		slog.ErrorContext(ctx, "Failed to write receipt score.", slog.String("id", id), slog.Any("error", err))
+		return domain.ErrInternal
+	}
+	return domain.StatusOK
+}
+
+func (r *SQLiteRepository) writeReceiptScore(ctx context.Context, id string, record receipt.ScoreRecord) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	rcpt := record.Receipt
+	if _, err := tx.ExecContext(ctx,
+		`INSERT INTO receipts (id, retailer, purchase_date, purchase_time, total, subtotal, tax, tip, payment_method, user_id, created_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT (id) DO UPDATE SET retailer = excluded.retailer, purchase_date = excluded.purchase_date, purchase_time = excluded.purchase_time, total = excluded.total,
+		subtotal = excluded.subtotal, tax = excluded.tax, tip = excluded.tip, payment_method = excluded.payment_method, user_id = excluded.user_id`,
+		id, rcpt.Retailer, rcpt.PurchaseDate, rcpt.PurchaseTime, rcpt.Total, rcpt.Subtotal, rcpt.Tax, rcpt.Tip, rcpt.PaymentMethod, rcpt.UserID, time.Now().UTC().Format(time.RFC3339)); err != nil {
+		return fmt.Errorf("write receipt: %w", err)
+	}
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM items WHERE receipt_id = ?`, id); err != nil {
+		return fmt.Errorf("clear items: %w", err)
+	}
+	for i, item := range rcpt.Items {
+		if _, err := tx.ExecContext(ctx, `INSERT INTO items (receipt_id, position, short_description, price, quantity, unit_price, sku, upc, category) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+			id, i, item.ShortDescription, item.Price, item.Quantity, item.UnitPrice, item.SKU, item.UPC, item.Category); err != nil {
+			return fmt.Errorf("write item %d: %w", i, err)
+		}
+	}
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM discounts WHERE receipt_id = ?`, id); err != nil {
+		return fmt.Errorf("clear discounts: %w", err)
+	}
+	for i, discount := range rcpt.Discounts {
+		if _, err := tx.ExecContext(ctx, `INSERT INTO discounts (receipt_id, position, description, amount, coupon) VALUES (?, ?, ?, ?, ?)`,
+			id, i, discount.Description, discount.Amount, discount.Coupon); err != nil {
+			return fmt.Errorf("write discount %d: %w", i, err)
+		}
+	}
+
+	if _, err := tx.ExecContext(ctx, `INSERT INTO scores (receipt_id, points, rule_set_version, scored_at, retailer_id) VALUES (?, ?, ?, ?, ?)
+		ON CONFLICT (receipt_id) DO UPDATE SET points = excluded.points, rule_set_version = excluded.rule_set_version, scored_at = excluded.scored_at, retailer_id = excluded.retailer_id`,
+		id, record.Points, record.RuleSetVersion, formatTime(record.ScoredAt), record.RetailerID); err != nil {
+		return fmt.Errorf("write score: %w", err)
+	}
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM score_campaigns WHERE receipt_id = ?`, id); err != nil {
+		return fmt.Errorf("clear score campaigns: %w", err)
+	}
+	for i, campaign := range record.Campaigns {
+		if _, err := tx.ExecContext(ctx, `INSERT INTO score_campaigns (receipt_id, position, campaign_id) VALUES (?, ?, ?)`, id, i, campaign); err != nil {
+			return fmt.Errorf("write score campaign %d: %w", i, err)
+		}
+	}
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM score_history WHERE receipt_id = ?`, id); err != nil {
+		return fmt.Errorf("clear score history: %w", err)
+	}
+	for i, revision := range record.History {
+		if _, err := tx.ExecContext(ctx, `INSERT INTO score_history (receipt_id, revision, points, rule_set_version, scored_at) VALUES (?, ?, ?, ?, ?)`,
+			id, i, revision.Points, revision.RuleSetVersion, formatTime(revision.ScoredAt)); err != nil {
+			return fmt.Errorf("write score revision %d: %w", i, err)
+		}
+	}
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM rule_scores WHERE receipt_id = ?`, id); err != nil {
+		return fmt.Errorf("clear rule scores: %w", err)
+	}
+	for i, score := range record.Breakdown {
+		if _, err := tx.ExecContext(ctx, `INSERT INTO rule_scores (receipt_id, position, rule, points, reason, capped) VALUES (?, ?, ?, ?, ?, ?)`,
+			id, i, score.Rule, score.Points, score.Reason, score.Capped); err != nil {
+			return fmt.Errorf("write rule score %d: %w", i, err)
+		}
+	}
+
+	return tx.Commit()
+}
+
+func (r *SQLiteRepository) ReadReceiptScore(ctx context.Context, id string) (receipt.ScoreRecord, domain.StatusCode) {
+	record, err := r.readReceiptScore(ctx, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return receipt.ScoreRecord{}, domain.ErrNotFound
+	}
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to read receipt score.", slog.String("id", id), slog.Any("error", err))
+		return receipt.ScoreRecord{}, domain.ErrInternal
+	}
+	return record, domain.StatusOK
+}
+
+// Set lets the repository be the cold tier of a receipt.TieredRepository. value must be a receipt.ScoreRecord.
+func (r *SQLiteRepository) Set(ctx context.Context, id string, value interface{}) domain.StatusCode {
+	record, ok := value.(receipt.ScoreRecord)
+	if !ok {
+		slog.ErrorContext(ctx, "Failed to write receipt score.", slog.String("id", id), slog.String("type", fmt.Sprintf("%T", value)))
+		return domain.ErrInternal
+	}
+	return r.WriteReceiptScore(ctx, id, record)
+}
+
+// Get lets the repository be the cold tier of a receipt.TieredRepository.
+func (r *SQLiteRepository) Get(ctx context.Context, id string) (interface{}, domain.StatusCode) {
+	record, status := r.ReadReceiptScore(ctx, id)
+	if status > 0 {
+		return nil, status
+	}
+	return record, domain.StatusOK
+}
+
+func (r *SQLiteRepository) readReceiptScore(ctx context.Context, id string) (receipt.ScoreRecord, error) {
+	var record receipt.ScoreRecord
+	rcpt := &record.Receipt
+	var scoredAt string
+	if err := r.db.QueryRowContext(ctx,
+		`SELECT s.points, s.rule_set_version, s.scored_at, s.retailer_id, r.retailer, r.purchase_date, r.purchase_time, r.total, r.subtotal, r.tax, r.tip, r.payment_method, r.user_id
+		FROM scores s JOIN receipts r ON r.id = s.receipt_id WHERE s.receipt_id = ?`, id).
+		Scan(&record.Points, &record.RuleSetVersion, &scoredAt, &record.RetailerID, &rcpt.Retailer, &rcpt.PurchaseDate, &rcpt.PurchaseTime, &rcpt.Total,
+			&rcpt.Subtotal, &rcpt.Tax, &rcpt.Tip, &rcpt.PaymentMethod, &rcpt.UserID); err != nil {
+		return record, err
+	}
+	var err error
+	if record.ScoredAt, err = parseTime(scoredAt); err != nil {
+		return record, fmt.Errorf("read scored at: %w", err)
+	}
+
+	items, err := r.readItems(ctx, id)
+	if err != nil {
+		return record, fmt.Errorf("read items: %w", err)
+	}
+	rcpt.Items = items
+
+	rcpt.Discounts, err = r.readDiscounts(ctx, id)
+	if err != nil {
+		return record, fmt.Errorf("read discounts: %w", err)
+	}
+
+	record.Breakdown, err = r.readRuleScores(ctx, id)
+	if err != nil {
+		return record, fmt.Errorf("read rule scores: %w", err)
+	}
+
+	record.Campaigns, err = r.readCampaigns(ctx, id)
+	if err != nil {
+		return record, fmt.Errorf("read score campaigns: %w", err)
+	}
+
+	record.History, err = r.readHistory(ctx, id)
+	if err != nil {
+		return record, fmt.Errorf("read score history: %w", err)
+	}
+
+	return record, nil
+}
+
+func (r *SQLiteRepository) readItems(ctx context.Context, id string) ([]receipt.Item, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT short_description, price, quantity, unit_price, sku, upc, category FROM items WHERE receipt_id = ? ORDER BY position`, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	items := []receipt.Item{}
+	for rows.Next() {
+		var item receipt.Item
+		if err := rows.Scan(&item.ShortDescription, &item.Price, &item.Quantity, &item.UnitPrice, &item.SKU, &item.UPC, &item.Category); err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+	return items, rows.Err()
+}
+
+// readDiscounts returns nil for a receipt without discounts, so it reads back as it was written.
+func (r *SQLiteRepository) readDiscounts(ctx context.Context, id string) ([]receipt.Discount, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT description, amount, coupon FROM discounts WHERE receipt_id = ? ORDER BY position`, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var discounts []receipt.Discount
+	for rows.Next() {
+		var discount receipt.Discount
+		if err := rows.Scan(&discount.Description, &discount.Amount, &discount.Coupon); err != nil {
+			return nil, err
+		}
+		discounts = append(discounts, discount)
+	}
+	return discounts, rows.Err()
+}
+
+func (r *SQLiteRepository) readRuleScores(ctx context.Context, id string) ([]receipt.RuleScore, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT rule, points, reason, capped FROM rule_scores WHERE receipt_id = ? ORDER BY position`, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var scores []receipt.RuleScore
+	for rows.Next() {
+		var score receipt.RuleScore
+		if err := rows.Scan(&score.Rule, &score.Points, &score.Reason, &score.Capped); err != nil {
+			return nil, err
+		}
+		scores = append(scores, score)
+	}
+	return scores, rows.Err()
+}
+
+// readCampaigns returns nil for a score without campaigns, so it reads back as it was written.
+func (r *SQLiteRepository) readCampaigns(ctx context.Context, id string) ([]string, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT campaign_id FROM score_campaigns WHERE receipt_id = ? ORDER BY position`, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var campaigns []string
+	for rows.Next() {
+		var campaign string
+		if err := rows.Scan(&campaign); err != nil {
+			return nil, err
+		}
+		campaigns = append(campaigns, campaign)
+	}
+	return campaigns, rows.Err()
+}
+
+func (r *SQLiteRepository) readHistory(ctx context.Context, id string) ([]receipt.ScoreRevision, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT points, rule_set_version, scored_at FROM score_history WHERE receipt_id = ? ORDER BY revision`, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var history []receipt.ScoreRevision
+	for rows.Next() {
+		var revision receipt.ScoreRevision
+		var scoredAt string
+		if err := rows.Scan(&revision.Points, &revision.RuleSetVersion, &scoredAt); err != nil {
+			return nil, err
+		}
+		if revision.ScoredAt, err = parseTime(scoredAt); err != nil {
+			return nil, err
+		}
+		history = append(history, revision)
+	}
+	return history, rows.Err()
+}
+
+func (r *SQLiteRepository) ListReceiptIDs(ctx context.Context) ([]string, domain.StatusCode) {
+	ids, err := r.listReceiptIDs(ctx)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to list receipts.", slog.Any("error", err))
+		return nil, domain.ErrInternal
+	}
+	return ids, domain.StatusOK
+}
+
+// Keys lets the repository be the cold tier of a receipt.TieredRepository that receipts are listed from.
+func (r *SQLiteRepository) Keys(ctx context.Context) ([]string, domain.StatusCode) {
+	return r.ListReceiptIDs(ctx)
+}
+
+func (r *SQLiteRepository) listReceiptIDs(ctx context.Context) ([]string, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT receipt_id FROM scores ORDER BY receipt_id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+// Times are stored as RFC 3339 text. Scores written before scored_at existed have an empty value.
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339Nano)
+}
+
+func parseTime(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	return time.Parse(time.RFC3339Nano, value)
+}
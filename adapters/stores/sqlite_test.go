@@ -0,0 +1,108 @@
+package stores_test
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/kevin07696/receipt-processor/adapters/stores"
+	"github.com/kevin07696/receipt-processor/domain"
+	"github.com/kevin07696/receipt-processor/domain/receipt"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSQLiteRepository(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "receipts.db")
+	record := receipt.ScoreRecord{
+		Points:         28,
+		RuleSetVersion: "v1",
+		Breakdown: []receipt.RuleScore{
+			{Rule: receipt.RuleRetailer, Points: 6, Reason: "6 points - retailer name has 6 characters"},
+			{Rule: receipt.RulePurchaseDate, Points: 22, Reason: "22 points - purchase day is odd"},
+		},
+		Receipt: receipt.Receipt{
+			Retailer:     "Target",
+			PurchaseDate: "2022-01-01",
+			PurchaseTime: "13:01",
+			Items: []receipt.Item{
+				{ShortDescription: "Mountain Dew 12PK", Price: "6.49"},
+				{ShortDescription: "Emils Cheese Pizza", Price: "12.25", Quantity: "5", UnitPrice: "2.45", SKU: "PZ-1", UPC: "012345678905", Category: "Frozen"},
+			},
+			Total: "18.74",
+		},
+	}
+
+	repository, err := stores.NewSQLiteRepository(context.TODO(), path)
+	if err != nil {
+		t.Fatalf("Failed to open repository: %v", err)
+	}
+
+	_, status := repository.ReadReceiptScore(context.TODO(), "id")
+	assert.Equal(t, domain.ErrNotFound, status)
+
+	assert.Equal(t, domain.StatusOK, repository.WriteReceiptScore(context.TODO(), "id", record))
+
+	// Rewriting a receipt replaces its items, discounts and breakdown
+	rescored := record
+	rescored.Points = 5
+	rescored.Breakdown = []receipt.RuleScore{
+		record.Breakdown[0],
+		{Rule: receipt.RuleCap, Points: -1, Capped: 1, Reason: "-1 points - receipt capped at 5 points"},
+	}
+	rescored.Receipt.Items = record.Receipt.Items[:1]
+	rescored.Receipt.Subtotal = "6.49"
+	rescored.Receipt.Tax = "0.52"
+	rescored.Receipt.Tip = "1.00"
+	rescored.Receipt.PaymentMethod = receipt.PaymentCredit
+	rescored.Receipt.UserID = "user-1"
+	rescored.Receipt.Discounts = []receipt.Discount{{Description: "Store coupon", Amount: "0.50", Coupon: true}}
+	rescored.RuleSetVersion = "v2"
+	rescored.RetailerID = "target"
+	rescored.Campaigns = []string{"december-weekends", "doritos"}
+	rescored.ScoredAt = time.Date(2024, 3, 20, 14, 33, 0, 0, time.UTC)
+	rescored.History = []receipt.ScoreRevision{
+		{Points: 28, RuleSetVersion: "v1", ScoredAt: time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC)},
+	}
+	assert.Equal(t, domain.StatusOK, repository.WriteReceiptScore(context.TODO(), "rescored", record))
+	assert.Equal(t, domain.StatusOK, repository.WriteReceiptScore(context.TODO(), "rescored", rescored))
+	repository.Close()
+
+	// Migrations must not run twice when the database is reopened
+	repository, err = stores.NewSQLiteRepository(context.TODO(), path)
+	if err != nil {
+		t.Fatalf("Failed to reopen repository: %v", err)
+	}
+	defer repository.Close()
+
+	actual, status := repository.ReadReceiptScore(context.TODO(), "id")
+	assert.Equal(t, domain.StatusOK, status)
+	assert.Equal(t, record, actual)
+
+	actual, status = repository.ReadReceiptScore(context.TODO(), "rescored")
+	assert.Equal(t, domain.StatusOK, status)
+	assert.Equal(t, rescored, actual)
+
+	ids, status := repository.ListReceiptIDs(context.TODO())
+	assert.Equal(t, domain.StatusOK, status)
+	assert.Equal(t, []string{"id", "rescored"}, ids)
+}
+
+func TestSQLiteRepositoryAsColdTier(t *testing.T) {
+	repository, err := stores.NewSQLiteRepository(context.TODO(), filepath.Join(t.TempDir(), "receipts.db"))
+	if err != nil {
+		t.Fatalf("Failed to open repository: %v", err)
+	}
+	defer repository.Close()
+
+	record := receipt.ScoreRecord{Points: 6, Receipt: receipt.Receipt{Retailer: "Target", Total: "1.00"}}
+	assert.Equal(t, domain.StatusOK, repository.Set(context.TODO(), "id", record))
+	assert.Equal(t, domain.ErrInternal, repository.Set(context.TODO(), "other", int64(6)))
+
+	value, status := repository.Get(context.TODO(), "id")
+	assert.Equal(t, domain.StatusOK, status)
+	assert.Equal(t, record.Points, value.(receipt.ScoreRecord).Points)
+
+	_, status = repository.Get(context.TODO(), "other")
+	assert.Equal(t, domain.ErrNotFound, status)
+}
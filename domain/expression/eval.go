@@ -0,0 +1,281 @@
+package expression
+
+import (
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+type node interface {
+	typ() Type
+	eval(s *scope) (Value, error)
+}
+
+// scope holds the variables and, in a predicate, the fields of the current list element.
+type scope struct {
+	env    Env
+	record Record
+}
+
+type literal struct {
+	value Value
+}
+
+func (n *literal) typ() Type { return n.value.Type }
+
+func (n *literal) eval(s *scope) (Value, error) {
+	return n.value, nil
+}
+
+type variable struct {
+	name string
+	t    Type
+}
+
+func (n *variable) typ() Type { return n.t }
+
+func (n *variable) eval(s *scope) (Value, error) {
+	value, ok := s.env[n.name]
+	if !ok || value.Type != n.t {
+		return Value{}, fmt.Errorf("%s has no %s value", n.name, n.t)
+	}
+	return value, nil
+}
+
+type field struct {
+	name string
+	t    Type
+}
+
+func (n *field) typ() Type { return n.t }
+
+func (n *field) eval(s *scope) (Value, error) {
+	value, ok := s.record[n.name]
+	if !ok || value.Type != n.t {
+		return Value{}, fmt.Errorf("field %s has no %s value", n.name, n.t)
+	}
+	return value, nil
+}
+
+type not struct {
+	x node
+}
+
+func (n *not) typ() Type { return Bool }
+
+func (n *not) eval(s *scope) (Value, error) {
+	x, err := n.x.eval(s)
+	if err != nil {
+		return Value{}, err
+	}
+	return NewBool(!x.Bool), nil
+}
+
+type negate struct {
+	x node
+}
+
+func (n *negate) typ() Type { return Number }
+
+func (n *negate) eval(s *scope) (Value, error) {
+	x, err := n.x.eval(s)
+	if err != nil {
+		return Value{}, err
+	}
+	return NewNumber(new(big.Rat).Neg(x.Number)), nil
+}
+
+// logical evaluates its second operand only when the first does not decide the result.
+type logical struct {
+	operator string
+	x, y     node
+}
+
+func (n *logical) typ() Type { return Bool }
+
+func (n *logical) eval(s *scope) (Value, error) {
+	x, err := n.x.eval(s)
+	if err != nil {
+		return Value{}, err
+	}
+	if x.Bool == (n.operator == "||") {
+		return x, nil
+	}
+	return n.y.eval(s)
+}
+
+type arithmetic struct {
+	operator string
+	x, y     node
+	column   int
+}
+
+func (n *arithmetic) typ() Type { return Number }
+
+func (n *arithmetic) eval(s *scope) (Value, error) {
+	x, err := n.x.eval(s)
+	if err != nil {
+		return Value{}, err
+	}
+	y, err := n.y.eval(s)
+	if err != nil {
+		return Value{}, err
+	}
+
+	result := new(big.Rat)
+	switch n.operator {
+	case "+":
+		result.Add(x.Number, y.Number)
+	case "-":
+		result.Sub(x.Number, y.Number)
+	case "*":
+		result.Mul(x.Number, y.Number)
+	case "/", "%":
+		if y.Number.Sign() == 0 {
+			return Value{}, fmt.Errorf("column %d: division by zero", n.column)
+		}
+		result.Quo(x.Number, y.Number)
+		if n.operator == "%" {
+			// The remainder has the sign of the divisor, e.g. -1 % 3 == 2
+			result.Sub(x.Number, new(big.Rat).Mul(y.Number, floor(result)))
+		}
+	}
+	return NewNumber(result), nil
+}
+
+type comparison struct {
+	operator string
+	x, y     node
+}
+
+func (n *comparison) typ() Type { return Bool }
+
+func (n *comparison) eval(s *scope) (Value, error) {
+	x, err := n.x.eval(s)
+	if err != nil {
+		return Value{}, err
+	}
+	y, err := n.y.eval(s)
+	if err != nil {
+		return Value{}, err
+	}
+
+	var order int
+	switch x.Type {
+	case Number:
+		order = x.Number.Cmp(y.Number)
+	case String:
+		order = strings.Compare(x.String, y.String)
+	case Bool:
+		if x.Bool != y.Bool {
+			order = 1
+		}
+	}
+
+	switch n.operator {
+	case "==":
+		return NewBool(order == 0), nil
+	case "!=":
+		return NewBool(order != 0), nil
+	case "<":
+		return NewBool(order < 0), nil
+	case "<=":
+		return NewBool(order <= 0), nil
+	case ">":
+		return NewBool(order > 0), nil
+	}
+	return NewBool(order >= 0), nil
+}
+
+type conditional struct {
+	condition, then, otherwise node
+}
+
+func (n *conditional) typ() Type { return n.then.typ() }
+
+func (n *conditional) eval(s *scope) (Value, error) {
+	condition, err := n.condition.eval(s)
+	if err != nil {
+		return Value{}, err
+	}
+	if condition.Bool {
+		return n.then.eval(s)
+	}
+	return n.otherwise.eval(s)
+}
+
+type call struct {
+	name   string
+	fn     builtin
+	args   []node
+	column int
+}
+
+func (n *call) typ() Type { return n.fn.result }
+
+func (n *call) eval(s *scope) (Value, error) {
+	args := make([]Value, len(n.args))
+	for i, arg := range n.args {
+		value, err := arg.eval(s)
+		if err != nil {
+			return Value{}, err
+		}
+		args[i] = value
+	}
+
+	result, err := n.fn.eval(args)
+	if err != nil {
+		return Value{}, fmt.Errorf("column %d: %s: %w", n.column, n.name, err)
+	}
+	return result, nil
+}
+
+// aggregate evaluates its body for each element of a list.
+type aggregate struct {
+	function string
+	list     string
+	body     node
+}
+
+func (n *aggregate) typ() Type {
+	if n.function == "count" || n.function == "sum" {
+		return Number
+	}
+	return Bool
+}
+
+func (n *aggregate) eval(s *scope) (Value, error) {
+	list, ok := s.env[n.list]
+	if !ok || list.Type != List {
+		return Value{}, fmt.Errorf("%s has no list value", n.list)
+	}
+
+	var count int64
+	sum := new(big.Rat)
+	for _, record := range list.List {
+		value, err := n.body.eval(&scope{env: s.env, record: record})
+		if err != nil {
+			return Value{}, err
+		}
+		switch {
+		case n.function == "sum":
+			sum.Add(sum, value.Number)
+		case value.Bool:
+			count++
+			if n.function == "any" {
+				return NewBool(true), nil
+			}
+		case n.function == "all":
+			return NewBool(false), nil
+		}
+	}
+
+	switch n.function {
+	case "count":
+		return NewInt(count), nil
+	case "sum":
+		return NewNumber(sum), nil
+	}
+	// any found no element, all found no element that breaks it
+	return NewBool(n.function == "all"), nil
+}
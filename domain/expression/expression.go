@@ -0,0 +1,191 @@
+// Package expression compiles the expression language of custom scoring rules.
+//
+// Expressions read the variables of a Schema and can not call anything but the builtin functions,
+// so evaluating one has no side effects. Numbers are exact decimals, so 0.1 + 0.2 == 0.3.
+// Every expression is type checked when it is compiled, so evaluation only fails on values,
+// e.g. a division by zero.
+package expression
+
+import (
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+// Limits of a compiled expression, so a rule from config can not make scoring slow.
+const (
+	MaxLength = 1024
+	MaxDepth  = 32
+)
+
+// Type is the type of a value.
+type Type uint8
+
+const (
+	Invalid Type = iota
+	Number
+	String
+	Bool
+	List
+)
+
+func (t Type) String() string {
+	switch t {
+	case Number:
+		return "number"
+	case String:
+		return "string"
+	case Bool:
+		return "bool"
+	case List:
+		return "list"
+	}
+	return "invalid"
+}
+
+// Schema declares the variables an expression can read.
+type Schema struct {
+	Fields map[string]Type
+	Lists  map[string]ListSchema
+}
+
+// ListSchema declares a list variable. Its elements are read in the predicates of count, sum, any and all.
+type ListSchema struct {
+	// Element names the current element in a predicate, e.g. item in count(items, item.price > 10)
+	Element string
+	Fields  map[string]Type
+}
+
+// Value is a number, string, bool or list of records.
+type Value struct {
+	Type   Type
+	Number *big.Rat
+	String string
+	Bool   bool
+	List   []Record
+}
+
+// Record holds the fields of a list element by name.
+type Record map[string]Value
+
+// Env holds the value of each variable of the schema by name.
+type Env map[string]Value
+
+func NewNumber(number *big.Rat) Value {
+	return Value{Type: Number, Number: number}
+}
+
+func NewInt(number int64) Value {
+	return NewNumber(new(big.Rat).SetInt64(number))
+}
+
+func NewString(s string) Value {
+	return Value{Type: String, String: s}
+}
+
+func NewBool(b bool) Value {
+	return Value{Type: Bool, Bool: b}
+}
+
+func NewList(records []Record) Value {
+	return Value{Type: List, List: records}
+}
+
+// Int64 rounds a number half away from zero. It is false for other types and numbers beyond int64.
+func (v Value) Int64() (int64, bool) {
+	if v.Type != Number {
+		return 0, false
+	}
+	rounded := round(v.Number)
+	if !rounded.IsInt() || !rounded.Num().IsInt64() {
+		return 0, false
+	}
+	return rounded.Num().Int64(), true
+}
+
+// Format writes the value as it would be written in an expression. Numbers show up to 6 decimals.
+func (v Value) Format() string {
+	switch v.Type {
+	case Number:
+		if v.Number.IsInt() {
+			return v.Number.Num().String()
+		}
+		return strings.TrimSuffix(strings.TrimRight(v.Number.FloatString(6), "0"), ".")
+	case String:
+		return fmt.Sprintf("%q", v.String)
+	case Bool:
+		return fmt.Sprint(v.Bool)
+	case List:
+		return fmt.Sprintf("[%d elements]", len(v.List))
+	}
+	return "invalid"
+}
+
+// Error is a compile error at a column of the expression, counted in characters from 1.
+type Error struct {
+	Column  int
+	Message string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("column %d: %s", e.Column, e.Message)
+}
+
+// Program is a compiled expression.
+type Program struct {
+	source string
+	root   node
+}
+
+// Compile parses the source and checks the type of every operation against the schema.
+func Compile(source string, schema Schema) (*Program, error) {
+	if len(source) > MaxLength {
+		return nil, &Error{Column: MaxLength + 1, Message: fmt.Sprintf("expression is longer than %d characters", MaxLength)}
+	}
+	tokens, err := lex(source)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &parser{tokens: tokens, schema: schema}
+	root, err := p.parseExpr()
+	if err != nil {
+		return nil, err
+	}
+	if next := p.peek(); next.kind != tokenEOF {
+		return nil, &Error{Column: next.column, Message: fmt.Sprintf("unexpected %s", next)}
+	}
+	return &Program{source: source, root: root}, nil
+}
+
+func (p *Program) String() string {
+	return p.source
+}
+
+// Type is the type of the values the program evaluates to.
+func (p *Program) Type() Type {
+	return p.root.typ()
+}
+
+// Eval evaluates the program with the variables of env. A variable of the schema that env lacks is an error.
+func (p *Program) Eval(env Env) (Value, error) {
+	return p.root.eval(&scope{env: env})
+}
+
+// round rounds half away from zero.
+func round(r *big.Rat) *big.Rat {
+	half := big.NewRat(1, 2)
+	if r.Sign() < 0 {
+		return new(big.Rat).Neg(floor(new(big.Rat).Add(new(big.Rat).Neg(r), half)))
+	}
+	return floor(new(big.Rat).Add(r, half))
+}
+
+func floor(r *big.Rat) *big.Rat {
+	// Denominators are positive, so Euclidean division rounds toward negative infinity
+	return new(big.Rat).SetInt(new(big.Int).Div(r.Num(), r.Denom()))
+}
+
+func ceil(r *big.Rat) *big.Rat {
+	return new(big.Rat).Neg(floor(new(big.Rat).Neg(r)))
+}
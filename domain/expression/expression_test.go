@@ -0,0 +1,154 @@
+package expression_test
+
+import (
+	"errors"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/kevin07696/receipt-processor/domain/expression"
+	"github.com/stretchr/testify/assert"
+)
+
+var schema = expression.Schema{
+	Fields: map[string]expression.Type{
+		"retailer":     expression.String,
+		"purchaseDate": expression.String,
+		"purchaseTime": expression.String,
+		"total":        expression.Number,
+		"member":       expression.Bool,
+	},
+	Lists: map[string]expression.ListSchema{
+		"items": {Element: "item", Fields: map[string]expression.Type{
+			"shortDescription": expression.String,
+			"price":            expression.Number,
+		}},
+	},
+}
+
+func decimal(s string) expression.Value {
+	number, _ := new(big.Rat).SetString(s)
+	return expression.NewNumber(number)
+}
+
+var env = expression.Env{
+	"retailer":     expression.NewString("M&M Corner Market"),
+	"purchaseDate": expression.NewString("2022-03-20"),
+	"purchaseTime": expression.NewString("14:33"),
+	"total":        decimal("35.35"),
+	"member":       expression.NewBool(true),
+	"items": expression.NewList([]expression.Record{
+		{"shortDescription": expression.NewString("Doritos Nacho Cheese"), "price": decimal("12.25")},
+		{"shortDescription": expression.NewString("Gatorade"), "price": decimal("2.25")},
+		{"shortDescription": expression.NewString("Emils Cheese Pizza"), "price": decimal("10.00")},
+	}),
+}
+
+func TestEval(t *testing.T) {
+	testCases := []struct {
+		title      string
+		expression string
+		expected   string
+	}{
+		{title: "GivenDecimals_ComputeExactly", expression: "0.1 + 0.2 == 0.3", expected: "true"},
+		{title: "GivenPrecedence_MultiplyFirst", expression: "1 + 2 * 3 - 4 / 2", expected: "5"},
+		{title: "GivenParentheses_ComputeThemFirst", expression: "(1 + 2) * 3", expected: "9"},
+		{title: "GivenANegativeModulo_ReturnTheSignOfTheDivisor", expression: "-1 % 3", expected: "2"},
+		{title: "GivenAMoneyModulo_ReturnRemainder", expression: "total % 0.25", expected: "0.1"},
+		{title: "GivenCount_CountMatchingElements", expression: "5 * count(items, item.price > 10)", expected: "5"},
+		{title: "GivenSum_AddEveryElement", expression: "sum(items, item.price)", expected: "24.5"},
+		{title: "GivenAny_ReturnTrueWhenOneMatches", expression: `any(items, contains(lower(item.shortDescription), "doritos"))`, expected: "true"},
+		{title: "GivenAll_ReturnFalseWhenOneDoesNot", expression: "all(items, item.price >= 10)", expected: "false"},
+		{title: "GivenALength_CountRunesOrElements", expression: `len(retailer) + len(items)`, expected: "20"},
+		{title: "GivenAlnum_CountLettersAndDigits", expression: "alnum(retailer)", expected: "14"},
+		{title: "GivenRounding_RoundHalfAwayFromZero", expression: "round(2.5) + round(-2.5) * 10 + ceil(0.1) * 100 + floor(-0.1) * 1000", expected: "-927"},
+		{title: "GivenDateParts_ReadThem", expression: "year(purchaseDate) + month(purchaseDate) + day(purchaseDate) + weekday(purchaseDate)", expected: "2045"},
+		{title: "GivenTimeParts_ReadThem", expression: "hour(purchaseTime) * 60 + minute(purchaseTime)", expected: "873"},
+		{title: "GivenStringComparison_OrderLexically", expression: `purchaseTime >= "14:00" && purchaseTime < "16:00"`, expected: "true"},
+		{title: "GivenIf_ReturnTheBranchOfTheCondition", expression: `if(member && !(total < 10), max(total, 50), min(total, 1))`, expected: "50"},
+		{title: "GivenAShortCircuit_SkipTheDivision", expression: "member || 1 / 0 > 1", expected: "true"},
+		{title: "GivenAString_ReturnIt", expression: `upper(trim("  a\"b  "))`, expected: `"A\"B"`},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.title, func(t *testing.T) {
+			program, err := expression.Compile(tc.expression, schema)
+			assert.NoError(t, err)
+
+			value, err := program.Eval(env)
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, value.Format())
+		})
+	}
+}
+
+func TestCompileErrors(t *testing.T) {
+	testCases := []struct {
+		title      string
+		expression string
+		expected   string
+	}{
+		{title: "GivenAnUnknownVariable_ReturnError", expression: "totl * 2", expected: "column 1: unknown variable totl"},
+		{title: "GivenMismatchedTypes_ReturnError", expression: `total + "1"`, expected: "column 7: + needs number operands, found string"},
+		{title: "GivenAComparisonOfDifferentTypes_ReturnError", expression: `total == "1"`, expected: "column 7: can not compare number with string"},
+		{title: "GivenAnElementOutsideAPredicate_ReturnError", expression: "item.price", expected: "column 1: item can only be read in the predicate of count, sum, any or all"},
+		{title: "GivenAnUnknownField_ReturnError", expression: "count(items, item.cost > 1)", expected: `column 19: item has no field "cost", expected one of price, shortDescription`},
+		{title: "GivenANonListAggregate_ReturnError", expression: "sum(total, 1)", expected: "column 5: argument 1 of sum must be a list, one of items"},
+		{title: "GivenANestedAggregate_ReturnError", expression: "count(items, any(items, true))", expected: "column 14: any can not be used in the predicate of another list function"},
+		{title: "GivenAWrongPredicateType_ReturnError", expression: "count(items, item.price)", expected: "column 1: argument 2 of count must be bool, found number"},
+		{title: "GivenAnUnknownFunction_ReturnError", expression: "exec(retailer)", expected: "column 1: unknown function exec"},
+		{title: "GivenAWrongArgumentCount_ReturnError", expression: "min(total)", expected: "column 1: min takes 2 arguments, found 1"},
+		{title: "GivenAWrongArgumentType_ReturnError", expression: "len(total)", expected: "column 1: argument 1 of len must be string or list, found number"},
+		{title: "GivenMismatchedBranches_ReturnError", expression: `if(member, 1, "1")`, expected: "column 1: if must return the same type from both branches, found number and string"},
+		{title: "GivenAnUnclosedParenthesis_ReturnError", expression: "(total", expected: `column 7: expected ")", found end of expression`},
+		{title: "GivenATrailingToken_ReturnError", expression: "total total", expected: `column 7: unexpected "total"`},
+		{title: "GivenAnUnclosedString_ReturnError", expression: `retailer == "Target`, expected: "column 13: string is not closed"},
+		{title: "GivenAnUnknownCharacter_ReturnError", expression: "total ; 1", expected: `column 7: unexpected character ';'`},
+		{title: "GivenDeepNesting_ReturnError", expression: strings.Repeat("-", 40) + "1", expected: "column 33: expression is nested deeper than 32"},
+		{title: "GivenALongExpression_ReturnError", expression: strings.Repeat("1+", 600) + "1", expected: "column 1025: expression is longer than 1024 characters"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.title, func(t *testing.T) {
+			_, err := expression.Compile(tc.expression, schema)
+			var compileErr *expression.Error
+			assert.True(t, errors.As(err, &compileErr))
+			assert.EqualError(t, err, tc.expected)
+		})
+	}
+}
+
+func TestEvalErrors(t *testing.T) {
+	testCases := []struct {
+		title      string
+		expression string
+		env        expression.Env
+		expected   string
+	}{
+		{title: "GivenADivisionByZero_ReturnError", expression: "total / (total - total)", env: env, expected: "column 7: division by zero"},
+		{title: "GivenAnInvalidDate_ReturnError", expression: "day(retailer)", env: env, expected: `column 1: day: "M&M Corner Market" does not match 2006-01-02`},
+		{title: "GivenAMissingVariable_ReturnError", expression: "total", env: expression.Env{}, expected: "total has no number value"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.title, func(t *testing.T) {
+			program, err := expression.Compile(tc.expression, schema)
+			assert.NoError(t, err)
+
+			_, err = program.Eval(tc.env)
+			assert.EqualError(t, err, tc.expected)
+		})
+	}
+}
+
+func TestValueInt64(t *testing.T) {
+	points, ok := decimal("2.5").Int64()
+	assert.True(t, ok)
+	assert.Equal(t, int64(3), points)
+
+	_, ok = decimal("9223372036854775808").Int64()
+	assert.False(t, ok)
+
+	_, ok = expression.NewBool(true).Int64()
+	assert.False(t, ok)
+}
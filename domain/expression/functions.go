@@ -0,0 +1,96 @@
+package expression
+
+import (
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// builtin is a function whose arguments are all evaluated before it is called.
+type builtin struct {
+	// params lists the types each parameter accepts
+	params [][]Type
+	result Type
+	eval   func(args []Value) (Value, error)
+}
+
+// builtins are the functions besides if, count, sum, any and all, which the parser handles itself.
+var builtins = map[string]builtin{
+	"len": {params: [][]Type{{String, List}}, result: Number, eval: func(args []Value) (Value, error) {
+		if args[0].Type == List {
+			return NewInt(int64(len(args[0].List))), nil
+		}
+		return NewInt(int64(len([]rune(args[0].String)))), nil
+	}},
+	"alnum": {params: [][]Type{{String}}, result: Number, eval: func(args []Value) (Value, error) {
+		var count int64
+		for _, r := range args[0].String {
+			if unicode.IsLetter(r) || unicode.IsDigit(r) {
+				count++
+			}
+		}
+		return NewInt(count), nil
+	}},
+	"lower":      stringFunction(strings.ToLower),
+	"upper":      stringFunction(strings.ToUpper),
+	"trim":       stringFunction(strings.TrimSpace),
+	"contains":   predicate(strings.Contains),
+	"startsWith": predicate(strings.HasPrefix),
+	"endsWith":   predicate(strings.HasSuffix),
+	"floor":      numberFunction(floor),
+	"ceil":       numberFunction(ceil),
+	"round":      numberFunction(round),
+	"abs": numberFunction(func(r *big.Rat) *big.Rat {
+		return new(big.Rat).Abs(r)
+	}),
+	"min": {params: [][]Type{{Number}, {Number}}, result: Number, eval: func(args []Value) (Value, error) {
+		if args[0].Number.Cmp(args[1].Number) <= 0 {
+			return args[0], nil
+		}
+		return args[1], nil
+	}},
+	"max": {params: [][]Type{{Number}, {Number}}, result: Number, eval: func(args []Value) (Value, error) {
+		if args[0].Number.Cmp(args[1].Number) >= 0 {
+			return args[0], nil
+		}
+		return args[1], nil
+	}},
+	"year":  dateFunction(time.DateOnly, func(t time.Time) int { return t.Year() }),
+	"month": dateFunction(time.DateOnly, func(t time.Time) int { return int(t.Month()) }),
+	"day":   dateFunction(time.DateOnly, func(t time.Time) int { return t.Day() }),
+	// weekday counts from 0 on Sunday
+	"weekday": dateFunction(time.DateOnly, func(t time.Time) int { return int(t.Weekday()) }),
+	"hour":    dateFunction("15:04", func(t time.Time) int { return t.Hour() }),
+	"minute":  dateFunction("15:04", func(t time.Time) int { return t.Minute() }),
+}
+
+func stringFunction(fn func(string) string) builtin {
+	return builtin{params: [][]Type{{String}}, result: String, eval: func(args []Value) (Value, error) {
+		return NewString(fn(args[0].String)), nil
+	}}
+}
+
+func predicate(fn func(s, substr string) bool) builtin {
+	return builtin{params: [][]Type{{String}, {String}}, result: Bool, eval: func(args []Value) (Value, error) {
+		return NewBool(fn(args[0].String, args[1].String)), nil
+	}}
+}
+
+func numberFunction(fn func(*big.Rat) *big.Rat) builtin {
+	return builtin{params: [][]Type{{Number}}, result: Number, eval: func(args []Value) (Value, error) {
+		return NewNumber(fn(args[0].Number)), nil
+	}}
+}
+
+// dateFunction reads a part of a date or time formatted with layout.
+func dateFunction(layout string, part func(time.Time) int) builtin {
+	return builtin{params: [][]Type{{String}}, result: Number, eval: func(args []Value) (Value, error) {
+		t, err := time.Parse(layout, args[0].String)
+		if err != nil {
+			return Value{}, fmt.Errorf("%q does not match %s", args[0].String, layout)
+		}
+		return NewInt(int64(part(t))), nil
+	}}
+}
@@ -0,0 +1,109 @@
+package expression
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+type tokenKind uint8
+
+const (
+	tokenEOF tokenKind = iota
+	tokenNumber
+	tokenString
+	tokenIdent
+	tokenOperator
+)
+
+type token struct {
+	kind   tokenKind
+	text   string
+	column int
+}
+
+func (t token) String() string {
+	switch t.kind {
+	case tokenEOF:
+		return "end of expression"
+	case tokenString:
+		return fmt.Sprintf("string %q", t.text)
+	}
+	return fmt.Sprintf("%q", t.text)
+}
+
+// operators are ordered so that two character operators are matched first
+var operators = []string{"==", "!=", "<=", ">=", "&&", "||", "+", "-", "*", "/", "%", "<", ">", "!", "(", ")", ",", "."}
+
+func lex(source string) ([]token, error) {
+	var tokens []token
+	runes := []rune(source)
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		column := i + 1
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case isDigit(r):
+			start := i
+			for i < len(runes) && isDigit(runes[i]) {
+				i++
+			}
+			if i < len(runes) && runes[i] == '.' {
+				i++
+				if i == len(runes) || !isDigit(runes[i]) {
+					return nil, &Error{Column: i + 1, Message: "expected a digit after the decimal point"}
+				}
+				for i < len(runes) && isDigit(runes[i]) {
+					i++
+				}
+			}
+			tokens = append(tokens, token{kind: tokenNumber, text: string(runes[start:i]), column: column})
+		case r == '"':
+			var text strings.Builder
+			i++
+			for {
+				if i == len(runes) {
+					return nil, &Error{Column: column, Message: "string is not closed"}
+				}
+				if runes[i] == '"' {
+					i++
+					break
+				}
+				if runes[i] == '\\' {
+					if i+1 == len(runes) || (runes[i+1] != '"' && runes[i+1] != '\\') {
+						return nil, &Error{Column: i + 1, Message: `only \" and \\ can be escaped`}
+					}
+					i++
+				}
+				text.WriteRune(runes[i])
+				i++
+			}
+			tokens = append(tokens, token{kind: tokenString, text: text.String(), column: column})
+		case unicode.IsLetter(r) || r == '_':
+			start := i
+			for i < len(runes) && (unicode.IsLetter(runes[i]) || isDigit(runes[i]) || runes[i] == '_') {
+				i++
+			}
+			tokens = append(tokens, token{kind: tokenIdent, text: string(runes[start:i]), column: column})
+		default:
+			operator := ""
+			for _, candidate := range operators {
+				if strings.HasPrefix(string(runes[i:min(i+2, len(runes))]), candidate) {
+					operator = candidate
+					break
+				}
+			}
+			if operator == "" {
+				return nil, &Error{Column: column, Message: fmt.Sprintf("unexpected character %q", r)}
+			}
+			tokens = append(tokens, token{kind: tokenOperator, text: operator, column: column})
+			i += len(operator)
+		}
+	}
+	return append(tokens, token{kind: tokenEOF, column: len(runes) + 1}), nil
+}
+
+func isDigit(r rune) bool {
+	return r >= '0' && r <= '9'
+}
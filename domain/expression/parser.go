@@ -0,0 +1,342 @@
+package expression
+
+import (
+	"fmt"
+	"math/big"
+	"slices"
+	"strings"
+)
+
+// parser builds typed nodes by recursive descent. From lowest to highest precedence the operators are
+// ||, &&, comparisons, + and -, * / and %, then the unary ! and -.
+type parser struct {
+	tokens []token
+	pos    int
+	schema Schema
+	depth  int
+	// list is the list whose elements the current predicate reads. It is nil outside predicates.
+	list *ListSchema
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) next() token {
+	t := p.tokens[p.pos]
+	if t.kind != tokenEOF {
+		p.pos++
+	}
+	return t
+}
+
+// accept consumes the next token when it is one of the operators.
+func (p *parser) accept(operators ...string) (token, bool) {
+	t := p.peek()
+	if t.kind == tokenOperator && slices.Contains(operators, t.text) {
+		p.pos++
+		return t, true
+	}
+	return t, false
+}
+
+func (p *parser) expect(operator string) error {
+	if t, ok := p.accept(operator); !ok {
+		return &Error{Column: t.column, Message: fmt.Sprintf("expected %q, found %s", operator, t)}
+	}
+	return nil
+}
+
+func (p *parser) parseExpr() (node, error) {
+	return p.parseOr()
+}
+
+func (p *parser) parseOr() (node, error) {
+	return p.parseLogical("||", p.parseAnd)
+}
+
+func (p *parser) parseAnd() (node, error) {
+	return p.parseLogical("&&", p.parseComparison)
+}
+
+func (p *parser) parseLogical(operator string, operand func() (node, error)) (node, error) {
+	x, err := operand()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		t, ok := p.accept(operator)
+		if !ok {
+			return x, nil
+		}
+		y, err := operand()
+		if err != nil {
+			return nil, err
+		}
+		if err := operands(t, Bool, x, y); err != nil {
+			return nil, err
+		}
+		x = &logical{operator: operator, x: x, y: y}
+	}
+}
+
+func (p *parser) parseComparison() (node, error) {
+	x, err := p.parseAdditive()
+	if err != nil {
+		return nil, err
+	}
+	t, ok := p.accept("==", "!=", "<", "<=", ">", ">=")
+	if !ok {
+		return x, nil
+	}
+	y, err := p.parseAdditive()
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case x.typ() != y.typ():
+		return nil, &Error{Column: t.column, Message: fmt.Sprintf("can not compare %s with %s", x.typ(), y.typ())}
+	case x.typ() == List:
+		return nil, &Error{Column: t.column, Message: "can not compare lists"}
+	case x.typ() == Bool && t.text != "==" && t.text != "!=":
+		return nil, &Error{Column: t.column, Message: fmt.Sprintf("can not order bools with %s", t.text)}
+	}
+	return &comparison{operator: t.text, x: x, y: y}, nil
+}
+
+func (p *parser) parseAdditive() (node, error) {
+	return p.parseArithmetic([]string{"+", "-"}, p.parseMultiplicative)
+}
+
+func (p *parser) parseMultiplicative() (node, error) {
+	return p.parseArithmetic([]string{"*", "/", "%"}, p.parseUnary)
+}
+
+func (p *parser) parseArithmetic(operators []string, operand func() (node, error)) (node, error) {
+	x, err := operand()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		t, ok := p.accept(operators...)
+		if !ok {
+			return x, nil
+		}
+		y, err := operand()
+		if err != nil {
+			return nil, err
+		}
+		if err := operands(t, Number, x, y); err != nil {
+			return nil, err
+		}
+		x = &arithmetic{operator: t.text, x: x, y: y, column: t.column}
+	}
+}
+
+func (p *parser) parseUnary() (node, error) {
+	p.depth++
+	defer func() { p.depth-- }()
+	if p.depth > MaxDepth {
+		return nil, &Error{Column: p.peek().column, Message: fmt.Sprintf("expression is nested deeper than %d", MaxDepth)}
+	}
+
+	t, ok := p.accept("!", "-")
+	if !ok {
+		return p.parsePrimary()
+	}
+	x, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	if t.text == "!" {
+		if err := operands(t, Bool, x); err != nil {
+			return nil, err
+		}
+		return &not{x: x}, nil
+	}
+	if err := operands(t, Number, x); err != nil {
+		return nil, err
+	}
+	return &negate{x: x}, nil
+}
+
+func (p *parser) parsePrimary() (node, error) {
+	t := p.next()
+	switch t.kind {
+	case tokenNumber:
+		number, ok := new(big.Rat).SetString(t.text)
+		if !ok {
+			return nil, &Error{Column: t.column, Message: fmt.Sprintf("invalid number %s", t.text)}
+		}
+		return &literal{value: NewNumber(number)}, nil
+	case tokenString:
+		return &literal{value: NewString(t.text)}, nil
+	case tokenIdent:
+		return p.parseIdent(t)
+	case tokenOperator:
+		if t.text == "(" {
+			x, err := p.parseExpr()
+			if err != nil {
+				return nil, err
+			}
+			return x, p.expect(")")
+		}
+	}
+	return nil, &Error{Column: t.column, Message: fmt.Sprintf("unexpected %s", t)}
+}
+
+func (p *parser) parseIdent(t token) (node, error) {
+	switch t.text {
+	case "true":
+		return &literal{value: NewBool(true)}, nil
+	case "false":
+		return &literal{value: NewBool(false)}, nil
+	}
+	if _, ok := p.accept("("); ok {
+		return p.parseCall(t)
+	}
+
+	if p.list != nil && t.text == p.list.Element {
+		if err := p.expect("."); err != nil {
+			return nil, err
+		}
+		name := p.next()
+		typ, ok := p.list.Fields[name.text]
+		if name.kind != tokenIdent || !ok {
+			return nil, &Error{Column: name.column, Message: fmt.Sprintf("%s has no field %s, expected one of %s", t.text, name, names(p.list.Fields))}
+		}
+		return &field{name: name.text, t: typ}, nil
+	}
+
+	if typ, ok := p.schema.Fields[t.text]; ok {
+		return &variable{name: t.text, t: typ}, nil
+	}
+	if _, ok := p.schema.Lists[t.text]; ok {
+		return &variable{name: t.text, t: List}, nil
+	}
+	for _, list := range p.schema.Lists {
+		if t.text == list.Element {
+			return nil, &Error{Column: t.column, Message: fmt.Sprintf("%s can only be read in the predicate of count, sum, any or all", t.text)}
+		}
+	}
+	return nil, &Error{Column: t.column, Message: fmt.Sprintf("unknown variable %s", t.text)}
+}
+
+func (p *parser) parseCall(name token) (node, error) {
+	switch name.text {
+	case "count", "sum", "any", "all":
+		return p.parseAggregate(name)
+	}
+
+	args, err := p.parseArgs()
+	if err != nil {
+		return nil, err
+	}
+
+	if name.text == "if" {
+		if len(args) != 3 {
+			return nil, &Error{Column: name.column, Message: fmt.Sprintf("if takes 3 arguments, found %d", len(args))}
+		}
+		if err := operands(name, Bool, args[0]); err != nil {
+			return nil, err
+		}
+		if args[1].typ() != args[2].typ() || args[1].typ() == List {
+			return nil, &Error{Column: name.column, Message: fmt.Sprintf("if must return the same type from both branches, found %s and %s", args[1].typ(), args[2].typ())}
+		}
+		return &conditional{condition: args[0], then: args[1], otherwise: args[2]}, nil
+	}
+
+	fn, ok := builtins[name.text]
+	if !ok {
+		return nil, &Error{Column: name.column, Message: fmt.Sprintf("unknown function %s", name.text)}
+	}
+	if len(args) != len(fn.params) {
+		return nil, &Error{Column: name.column, Message: fmt.Sprintf("%s takes %d arguments, found %d", name.text, len(fn.params), len(args))}
+	}
+	for i, arg := range args {
+		if !slices.Contains(fn.params[i], arg.typ()) {
+			return nil, &Error{Column: name.column, Message: fmt.Sprintf("argument %d of %s must be %s, found %s", i+1, name.text, typeNames(fn.params[i]), arg.typ())}
+		}
+	}
+	return &call{name: name.text, fn: fn, args: args, column: name.column}, nil
+}
+
+// parseAggregate parses count, sum, any and all, whose first argument is a list variable and whose second is
+// a predicate evaluated for each element.
+func (p *parser) parseAggregate(name token) (node, error) {
+	if p.list != nil {
+		return nil, &Error{Column: name.column, Message: fmt.Sprintf("%s can not be used in the predicate of another list function", name.text)}
+	}
+	t := p.next()
+	list, ok := p.schema.Lists[t.text]
+	if t.kind != tokenIdent || !ok {
+		return nil, &Error{Column: t.column, Message: fmt.Sprintf("argument 1 of %s must be a list, one of %s", name.text, names(p.schema.Lists))}
+	}
+	if err := p.expect(","); err != nil {
+		return nil, err
+	}
+
+	p.list = &list
+	body, err := p.parseExpr()
+	p.list = nil
+	if err != nil {
+		return nil, err
+	}
+	if err := p.expect(")"); err != nil {
+		return nil, err
+	}
+
+	want := Bool
+	if name.text == "sum" {
+		want = Number
+	}
+	if body.typ() != want {
+		return nil, &Error{Column: name.column, Message: fmt.Sprintf("argument 2 of %s must be %s, found %s", name.text, want, body.typ())}
+	}
+	return &aggregate{function: name.text, list: t.text, body: body}, nil
+}
+
+func (p *parser) parseArgs() ([]node, error) {
+	if _, ok := p.accept(")"); ok {
+		return nil, nil
+	}
+	var args []node
+	for {
+		arg, err := p.parseExpr()
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, arg)
+		if _, ok := p.accept(","); !ok {
+			return args, p.expect(")")
+		}
+	}
+}
+
+// operands checks that every operand of the operator t has type want.
+func operands(t token, want Type, xs ...node) error {
+	for _, x := range xs {
+		if x.typ() != want {
+			return &Error{Column: t.column, Message: fmt.Sprintf("%s needs %s operands, found %s", t.text, want, x.typ())}
+		}
+	}
+	return nil
+}
+
+func names[V any](m map[string]V) string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+	return strings.Join(keys, ", ")
+}
+
+func typeNames(types []Type) string {
+	var s []string
+	for _, t := range types {
+		s = append(s, t.String())
+	}
+	return strings.Join(s, " or ")
+}
@@ -0,0 +1,283 @@
+package receipt
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/kevin07696/receipt-processor/domain"
+)
+
+// RuleCampaign names the breakdown entries of campaigns.
+const RuleCampaign = "campaign"
+
+// Campaign adds points to the receipts it matches that were purchased between Start and End, both included.
+type Campaign struct {
+	ID    string
+	Name  string
+	Start string
+	End   string
+	Match CampaignMatch
+	Bonus int64
+	// Factor multiplies the points the receipt scored before campaigns. Zero keeps them.
+	Factor float64
+}
+
+// CampaignMatch holds the predicates a receipt must all satisfy. Empty predicates match every receipt.
+type CampaignMatch struct {
+	// Retailers match like those of a RetailerOverride.
+	Retailers []string
+	// Items match receipts with an item description that contains one of them, ignoring case.
+	Items    []string
+	MinTotal string
+	Weekdays []string
+	// StartTime and EndTime bound the purchase time, both included.
+	StartTime string
+	EndTime   string
+}
+
+// ICampaignStore persists the campaigns of a CampaignRegistry after each change.
+type ICampaignStore interface {
+	SaveCampaigns(campaigns []Campaign) error
+}
+
+var weekdays = []string{"sunday", "monday", "tuesday", "wednesday", "thursday", "friday", "saturday"}
+
+// Matches reports whether the campaign applies to a valid receipt of the retailer resolved to retailerID.
+func (c Campaign) Matches(receipt Receipt, retailerID string) bool {
+	if receipt.PurchaseDate < c.Start || receipt.PurchaseDate > c.End {
+		return false
+	}
+	match := c.Match
+
+	if len(match.Retailers) > 0 {
+		name := NormalizeRetailer(receipt.Retailer)
+		if !slices.ContainsFunc(match.Retailers, func(retailer string) bool {
+			return retailer == retailerID || NormalizeRetailer(retailer) == name
+		}) {
+			return false
+		}
+	}
+
+	if len(match.Items) > 0 {
+		if !slices.ContainsFunc(receipt.Items, func(item Item) bool {
+			description := strings.ToLower(item.ShortDescription)
+			return slices.ContainsFunc(match.Items, func(term string) bool {
+				return strings.Contains(description, strings.ToLower(term))
+			})
+		}) {
+			return false
+		}
+	}
+
+	if match.MinTotal != "" && receipt.TotalAmount() < mustParseMoney(match.MinTotal) {
+		return false
+	}
+
+	if len(match.Weekdays) > 0 {
+		date, err := time.Parse(time.DateOnly, receipt.PurchaseDate)
+		if err != nil || !slices.Contains(match.Weekdays, weekdays[date.Weekday()]) {
+			return false
+		}
+	}
+
+	if match.StartTime != "" && receipt.PurchaseTime < match.StartTime {
+		return false
+	}
+	if match.EndTime != "" && receipt.PurchaseTime > match.EndTime {
+		return false
+	}
+	return true
+}
+
+// apply returns the breakdown entry of the campaign for a receipt that scored points before campaigns.
+func (c Campaign) apply(points int64) RuleScore {
+	var extra int64
+	var reasons []string
+	if c.Factor != 0 && c.Factor != 1 {
+		extra = scale(points, c.Factor) - points
+		reasons = append(reasons, fmt.Sprintf("%d points from %.2fx points", extra, c.Factor))
+	}
+	if c.Bonus != 0 {
+		reasons = append(reasons, fmt.Sprintf("%d points bonus", c.Bonus))
+	}
+
+	extra += c.Bonus
+	return RuleScore{
+		Rule:   RuleCampaign,
+		Points: extra,
+		Reason: fmt.Sprintf("%d points - campaign %s: %s", extra, c.Name, strings.Join(reasons, ", ")),
+	}
+}
+
+// CampaignRegistry holds the campaigns that ProcessReceipt evaluates after the rules. It is safe for concurrent use.
+type CampaignRegistry struct {
+	mu        sync.RWMutex
+	campaigns map[string]Campaign
+	store     ICampaignStore
+}
+
+// NewCampaignRegistry holds the campaigns and saves changes to store. A nil store keeps changes in memory.
+func NewCampaignRegistry(store ICampaignStore, campaigns ...Campaign) (*CampaignRegistry, error) {
+	r := &CampaignRegistry{campaigns: map[string]Campaign{}, store: store}
+	for i, campaign := range campaigns {
+		if violations := campaign.Validate(); len(violations) > 0 {
+			return nil, fmt.Errorf("campaign %d: %s %s %s", i, violations[0].Path, violations[0].Rule, violations[0].Value)
+		}
+		r.campaigns[campaign.ID] = campaign
+	}
+	return r, nil
+}
+
+// Active returns the campaigns that match the receipt ordered by ID. A nil registry has no campaigns.
+func (r *CampaignRegistry) Active(receipt Receipt, retailerID string) []Campaign {
+	if r == nil {
+		return nil
+	}
+	var active []Campaign
+	for _, campaign := range r.List() {
+		if campaign.Matches(receipt, retailerID) {
+			active = append(active, campaign)
+		}
+	}
+	return active
+}
+
+// List returns every campaign ordered by ID.
+func (r *CampaignRegistry) List() []Campaign {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.list()
+}
+
+func (r *CampaignRegistry) Get(id string) (Campaign, domain.StatusCode) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	campaign, ok := r.campaigns[id]
+	if !ok {
+		return Campaign{}, domain.ErrNotFound
+	}
+	return campaign, domain.StatusOK
+}
+
+// Put adds the campaign or replaces the campaign with its ID.
+func (r *CampaignRegistry) Put(ctx context.Context, campaign Campaign) ([]domain.Violation, domain.StatusCode) {
+	if violations := campaign.Validate(); len(violations) > 0 {
+		slog.DebugContext(ctx, "Campaign failed validation", slog.Any("CampaignInvalidMsgs", violations))
+		return violations, domain.ErrBadRequest
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	previous, existed := r.campaigns[campaign.ID]
+	r.campaigns[campaign.ID] = campaign
+	if status := r.save(ctx); status > 0 {
+		delete(r.campaigns, campaign.ID)
+		if existed {
+			r.campaigns[campaign.ID] = previous
+		}
+		return nil, status
+	}
+	return nil, domain.StatusOK
+}
+
+func (r *CampaignRegistry) Delete(ctx context.Context, id string) domain.StatusCode {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	previous, ok := r.campaigns[id]
+	if !ok {
+		return domain.ErrNotFound
+	}
+	delete(r.campaigns, id)
+
+	if status := r.save(ctx); status > 0 {
+		r.campaigns[id] = previous
+		return status
+	}
+	return domain.StatusOK
+}
+
+func (r *CampaignRegistry) list() []Campaign {
+	campaigns := make([]Campaign, 0, len(r.campaigns))
+	for _, campaign := range r.campaigns {
+		campaigns = append(campaigns, campaign)
+	}
+	slices.SortFunc(campaigns, func(a, b Campaign) int {
+		return strings.Compare(a.ID, b.ID)
+	})
+	return campaigns
+}
+
+func (r *CampaignRegistry) save(ctx context.Context) domain.StatusCode {
+	if r.store == nil {
+		return domain.StatusOK
+	}
+	if err := r.store.SaveCampaigns(r.list()); err != nil {
+		slog.ErrorContext(ctx, "Failed to save campaigns.", slog.Any("error", err))
+		return domain.ErrInternal
+	}
+	return domain.StatusOK
+}
+
+// Validate returns every field of the campaign that is invalid.
+func (c Campaign) Validate() []domain.Violation {
+	var violations []domain.Violation
+	check := func(pattern *regexp.Regexp, path, value string) {
+		if value == "" {
+			violations = append(violations, domain.Violation{Path: path, Rule: domain.RuleRequired, Value: value})
+		} else if !match(pattern, value) {
+			violations = append(violations, domain.Violation{Path: path, Rule: domain.RulePattern, Value: value})
+		}
+	}
+	optional := func(pattern *regexp.Regexp, path, value string) {
+		if value != "" {
+			check(pattern, path, value)
+		}
+	}
+
+	check(retailerIDPattern, "/ID", c.ID)
+	check(descriptionPattern, "/Name", c.Name)
+	check(datePattern, "/Start", c.Start)
+	check(datePattern, "/End", c.End)
+	if match(datePattern, c.Start) && match(datePattern, c.End) && c.End < c.Start {
+		violations = append(violations, domain.Violation{Path: "/End", Rule: domain.RuleMin, Value: c.End})
+	}
+
+	for i, retailer := range c.Match.Retailers {
+		check(retailerPattern, fmt.Sprintf("/Match/Retailers/%d", i), retailer)
+	}
+	for i, item := range c.Match.Items {
+		check(descriptionPattern, fmt.Sprintf("/Match/Items/%d", i), item)
+	}
+	optional(currencyPattern, "/Match/MinTotal", c.Match.MinTotal)
+	if _, err := ParseMoney(c.Match.MinTotal); match(currencyPattern, c.Match.MinTotal) && err != nil {
+		violations = append(violations, domain.Violation{Path: "/Match/MinTotal", Rule: domain.RuleMax, Value: c.Match.MinTotal})
+	}
+	for i, weekday := range c.Match.Weekdays {
+		if !slices.Contains(weekdays, weekday) {
+			violations = append(violations, domain.Violation{Path: fmt.Sprintf("/Match/Weekdays/%d", i), Rule: domain.RuleOneOf, Value: weekday})
+		}
+	}
+	optional(timePattern, "/Match/StartTime", c.Match.StartTime)
+	optional(timePattern, "/Match/EndTime", c.Match.EndTime)
+
+	if c.Factor < 0 {
+		violations = append(violations, domain.Violation{Path: "/Factor", Rule: domain.RuleMin, Value: fmt.Sprint(c.Factor)})
+	}
+	if c.Factor > MaxFactor {
+		violations = append(violations, domain.Violation{Path: "/Factor", Rule: domain.RuleMax, Value: fmt.Sprint(c.Factor)})
+	}
+	// A campaign without a bonus or a factor would not change any score
+	if c.Bonus == 0 && (c.Factor == 0 || c.Factor == 1) {
+		violations = append(violations, domain.Violation{Path: "/Bonus", Rule: domain.RuleRequired, Value: "0"})
+	}
+	return violations
+}
@@ -0,0 +1,227 @@
+package receipt_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/kevin07696/receipt-processor/domain"
+	"github.com/kevin07696/receipt-processor/domain/receipt"
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	decemberWeekends = receipt.Campaign{
+		ID: "december-weekends", Name: "December weekends", Start: "2022-12-01", End: "2022-12-31",
+		Match: receipt.CampaignMatch{Weekdays: []string{"saturday", "sunday"}}, Factor: 2,
+	}
+	doritos = receipt.Campaign{
+		ID: "doritos", Name: "Doritos", Start: "2022-01-01", End: "2022-12-31",
+		Match: receipt.CampaignMatch{Items: []string{"doritos"}}, Bonus: 100,
+	}
+)
+
+func TestCampaignMatches(t *testing.T) {
+	campaign := receipt.Campaign{
+		Start: "2022-12-01",
+		End:   "2022-12-31",
+		Match: receipt.CampaignMatch{
+			Retailers: []string{"target", "M&M Corner Market"},
+			Items:     []string{"doritos"},
+			MinTotal:  "10.00",
+			Weekdays:  []string{"saturday"},
+			StartTime: "09:00",
+			EndTime:   "17:00",
+		},
+	}
+	// 2022-12-03 is a Saturday
+	matching := receipt.Receipt{
+		Retailer: "Target", PurchaseDate: "2022-12-03", PurchaseTime: "17:00", Total: "10.00",
+		Items: []receipt.Item{{ShortDescription: "Pepsi", Price: "2.00"}, {ShortDescription: "DORITOS Nacho", Price: "8.00"}},
+	}
+
+	testCases := []struct {
+		title      string
+		update     func(rcpt *receipt.Receipt)
+		retailerID string
+		expected   bool
+	}{
+		{title: "GivenEveryPredicate_ReturnTrue", update: func(rcpt *receipt.Receipt) {}, retailerID: "target", expected: true},
+		{title: "GivenARetailerNameIgnoringPunctuation_ReturnTrue", update: func(rcpt *receipt.Receipt) { rcpt.Retailer = "M M Corner Market" }, expected: true},
+		{title: "GivenAnotherRetailer_ReturnFalse", update: func(rcpt *receipt.Receipt) { rcpt.Retailer = "Walmart" }, retailerID: "walmart", expected: false},
+		{title: "GivenNoMatchingItem_ReturnFalse", update: func(rcpt *receipt.Receipt) { rcpt.Items = rcpt.Items[:1] }, retailerID: "target", expected: false},
+		{title: "GivenASmallerTotal_ReturnFalse", update: func(rcpt *receipt.Receipt) { rcpt.Total = "9.99" }, retailerID: "target", expected: false},
+		{title: "GivenAnotherWeekday_ReturnFalse", update: func(rcpt *receipt.Receipt) { rcpt.PurchaseDate = "2022-12-04" }, retailerID: "target", expected: false},
+		{title: "GivenALaterTime_ReturnFalse", update: func(rcpt *receipt.Receipt) { rcpt.PurchaseTime = "17:01" }, retailerID: "target", expected: false},
+		{title: "GivenADateAfterTheEnd_ReturnFalse", update: func(rcpt *receipt.Receipt) { rcpt.PurchaseDate = "2023-01-07" }, retailerID: "target", expected: false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.title, func(t *testing.T) {
+			rcpt := matching
+			tc.update(&rcpt)
+			assert.Equal(t, tc.expected, campaign.Matches(rcpt, tc.retailerID))
+		})
+	}
+}
+
+func TestCampaignRegistryPut(t *testing.T) {
+	testCases := []struct {
+		title              string
+		campaign           receipt.Campaign
+		fail               bool
+		expectedStatus     domain.StatusCode
+		expectedViolations []domain.Violation
+		expectedIDs        []string
+	}{
+		{
+			title:          "GivenANewCampaign_AddIt",
+			campaign:       decemberWeekends,
+			expectedStatus: domain.StatusOK,
+			expectedIDs:    []string{"december-weekends", "doritos"},
+		},
+		{
+			title: "GivenAnInvalidCampaign_ReturnViolations",
+			campaign: receipt.Campaign{
+				ID: "Spring Sale", Start: "2022-03-01", End: "2022-02-01",
+				Match:  receipt.CampaignMatch{MinTotal: "10", Weekdays: []string{"caturday"}, EndTime: "25:00"},
+				Factor: 1,
+			},
+			expectedStatus: domain.ErrBadRequest,
+			expectedViolations: []domain.Violation{
+				{Path: "/ID", Rule: domain.RulePattern, Value: "Spring Sale"},
+				{Path: "/Name", Rule: domain.RuleRequired, Value: ""},
+				{Path: "/End", Rule: domain.RuleMin, Value: "2022-02-01"},
+				{Path: "/Match/MinTotal", Rule: domain.RulePattern, Value: "10"},
+				{Path: "/Match/Weekdays/0", Rule: domain.RuleOneOf, Value: "caturday"},
+				{Path: "/Match/EndTime", Rule: domain.RulePattern, Value: "25:00"},
+				{Path: "/Bonus", Rule: domain.RuleRequired, Value: "0"},
+			},
+			expectedIDs: []string{"doritos"},
+		},
+		{
+			title:              "GivenAFactorOverTheMax_ReturnViolation",
+			campaign:           receipt.Campaign{ID: "boost", Name: "Boost", Start: "2022-01-01", End: "2022-12-31", Factor: 1e300},
+			expectedStatus:     domain.ErrBadRequest,
+			expectedViolations: []domain.Violation{{Path: "/Factor", Rule: domain.RuleMax, Value: "1e+300"}},
+			expectedIDs:        []string{"doritos"},
+		},
+		{
+			title:          "GivenAFailedSave_KeepThePreviousCampaigns",
+			campaign:       decemberWeekends,
+			fail:           true,
+			expectedStatus: domain.ErrInternal,
+			expectedIDs:    []string{"doritos"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.title, func(t *testing.T) {
+			store := &MockCampaignStore{Fail: tc.fail}
+			registry, err := receipt.NewCampaignRegistry(store, doritos)
+			assert.NoError(t, err)
+
+			violations, status := registry.Put(context.TODO(), tc.campaign)
+			assert.Equal(t, tc.expectedStatus, status)
+			assert.Equal(t, tc.expectedViolations, violations)
+
+			var ids []string
+			for _, campaign := range registry.List() {
+				ids = append(ids, campaign.ID)
+			}
+			assert.Equal(t, tc.expectedIDs, ids)
+			if status == domain.StatusOK {
+				assert.Equal(t, [][]receipt.Campaign{registry.List()}, store.Saved)
+			}
+		})
+	}
+}
+
+func TestCampaignRegistryDelete(t *testing.T) {
+	store := &MockCampaignStore{}
+	registry, err := receipt.NewCampaignRegistry(store, doritos)
+	assert.NoError(t, err)
+
+	store.Fail = true
+	assert.Equal(t, domain.ErrInternal, registry.Delete(context.TODO(), "doritos"))
+	_, status := registry.Get("doritos")
+	assert.Equal(t, domain.StatusOK, status)
+
+	store.Fail = false
+	assert.Equal(t, domain.StatusOK, registry.Delete(context.TODO(), "doritos"))
+	assert.Equal(t, domain.ErrNotFound, registry.Delete(context.TODO(), "doritos"))
+	assert.Equal(t, [][]receipt.Campaign{{}}, store.Saved)
+}
+
+func TestProcessReceiptWithCampaigns(t *testing.T) {
+	registry, err := receipt.NewCampaignRegistry(nil, decemberWeekends, doritos)
+	assert.NoError(t, err)
+
+	opts := receipt.Options{Rules: []string{receipt.RuleRetailer, receipt.RuleRoundTotal}, Campaigns: registry}
+	mults := receipt.Multipliers{Retailer: 1, RoundTotal: 50}
+
+	testCases := []struct {
+		title             string
+		purchaseDate      string
+		description       string
+		expectedPoints    int64
+		expectedCampaigns []string
+		expectedScores    []receipt.RuleScore
+	}{
+		{
+			title:          "GivenNoActiveCampaign_ReturnRulePoints",
+			purchaseDate:   "2022-12-05",
+			description:    "Pepsi",
+			expectedPoints: 56,
+		},
+		{
+			title:             "GivenAFactorCampaign_ScaleRulePoints",
+			purchaseDate:      "2022-12-03",
+			description:       "Pepsi",
+			expectedPoints:    112,
+			expectedCampaigns: []string{"december-weekends"},
+			expectedScores: []receipt.RuleScore{
+				{Rule: receipt.RuleCampaign, Points: 56, Reason: "56 points - campaign December weekends: 56 points from 2.00x points"},
+			},
+		},
+		{
+			title:             "GivenTwoActiveCampaigns_ApplyBothToTheRulePoints",
+			purchaseDate:      "2022-12-03",
+			description:       "Doritos Nacho",
+			expectedPoints:    212,
+			expectedCampaigns: []string{"december-weekends", "doritos"},
+			expectedScores: []receipt.RuleScore{
+				{Rule: receipt.RuleCampaign, Points: 56, Reason: "56 points - campaign December weekends: 56 points from 2.00x points"},
+				{Rule: receipt.RuleCampaign, Points: 100, Reason: "100 points - campaign Doritos: 100 points bonus"},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.title, func(t *testing.T) {
+			services := receipt.NewReceiptProcessorService(NewMapReceiptRepository(), opts, mults)
+
+			rcpt := validReceipt("1.00", "1.00")
+			rcpt.PurchaseDate = tc.purchaseDate
+			rcpt.Items[0].ShortDescription = tc.description
+			_, status := services.ProcessReceipt(context.TODO(), receipt.ReceiptProcessorRequest{ID: "id", Receipt: rcpt})
+			assert.Equal(t, domain.StatusOK, status)
+
+			breakdown, _ := services.GetReceiptBreakdown(context.TODO(), receipt.ReceiptBreakdownRequest{ID: "id"})
+			assert.Equal(t, tc.expectedPoints, breakdown.Points)
+			assert.Equal(t, tc.expectedCampaigns, breakdown.Campaigns)
+
+			var scores []receipt.RuleScore
+			for _, score := range breakdown.Breakdown {
+				if score.Rule == receipt.RuleCampaign {
+					scores = append(scores, score)
+				}
+			}
+			assert.Equal(t, tc.expectedScores, scores)
+		})
+	}
+}
+
+func TestNewCampaignRegistry(t *testing.T) {
+	_, err := receipt.NewCampaignRegistry(nil, receipt.Campaign{ID: "doritos", Name: "Doritos", Start: "2022-01-01", End: "2022-12-31"})
+	assert.Error(t, err)
+}
@@ -0,0 +1,225 @@
+package receipt
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"maps"
+	"slices"
+	"sync"
+
+	"github.com/kevin07696/receipt-processor/domain"
+)
+
+// Names of the breakdown entries that caps and the floor add.
+const (
+	RuleCap     = "cap"
+	RuleFloor   = "floor"
+	RuleUserCap = "user_cap"
+)
+
+// Caps bound the points of a receipt. Zero values leave points unbounded.
+type Caps struct {
+	// Rules caps the points of breakdown entries by rule name, including campaign and retailer_override entries.
+	Rules map[string]int64
+	// Receipt caps the points of a receipt after its rules, override and campaigns.
+	Receipt int64
+	// Floor raises the points of a receipt once Receipt capped them.
+	Floor int64
+	// UserDaily caps the points of the receipts a user purchased on the same day. Receipts without a user are not capped.
+	UserDaily int64
+	// UserRetailer caps the points of the receipts a user purchased from the same retailer on the same day.
+	UserRetailer int64
+}
+
+// Validate returns every cap that is negative or names no rule, and a floor above the receipt cap. Paths are relative to the options.
+func (c Caps) Validate() []domain.Violation {
+	var violations []domain.Violation
+	for _, name := range slices.Sorted(maps.Keys(c.Rules)) {
+		path := "/Caps/Rules/" + name
+		if !DefaultRules.Has(name) && name != RuleCampaign && name != RuleRetailerOverride {
+			violations = append(violations, domain.Violation{Path: path, Rule: domain.RuleOneOf, Value: name})
+		} else if c.Rules[name] < 0 {
+			violations = append(violations, domain.Violation{Path: path, Rule: domain.RuleMin, Value: fmt.Sprint(c.Rules[name])})
+		}
+	}
+	positive := func(path string, value int64) {
+		if value < 0 {
+			violations = append(violations, domain.Violation{Path: path, Rule: domain.RuleMin, Value: fmt.Sprint(value)})
+		}
+	}
+	positive("/Caps/Receipt", c.Receipt)
+	positive("/Caps/Floor", c.Floor)
+	positive("/Caps/UserDaily", c.UserDaily)
+	positive("/Caps/UserRetailer", c.UserRetailer)
+	if c.Receipt > 0 && c.Floor > c.Receipt {
+		violations = append(violations, domain.Violation{Path: "/Caps/Floor", Rule: domain.RuleMax, Value: fmt.Sprint(c.Floor)})
+	}
+	return violations
+}
+
+// capRule caps the points of a rule, keeping the points it took off in Capped.
+func (c Caps) capRule(score RuleScore) RuleScore {
+	limit, ok := c.Rules[score.Rule]
+	if !ok || score.Points <= limit {
+		return score
+	}
+	score.Capped = score.Points - limit
+	score.Points = limit
+	score.Reason = fmt.Sprintf("%s, capped at %d points", score.Reason, limit)
+	return score
+}
+
+// bound caps the points of the receipt, then raises them to the floor. Each adds an entry to the breakdown,
+// so the points stay the sum of the breakdown.
+func (c Caps) bound(points int64) []RuleScore {
+	var scores []RuleScore
+	if c.Receipt > 0 && points > c.Receipt {
+		over := points - c.Receipt
+		scores = append(scores, RuleScore{Rule: RuleCap, Points: -over, Capped: over, Reason: fmt.Sprintf("%d points - receipt capped at %d points", -over, c.Receipt)})
+		points = c.Receipt
+	}
+	if c.Floor > 0 && points < c.Floor {
+		scores = append(scores, RuleScore{Rule: RuleFloor, Points: c.Floor - points, Reason: fmt.Sprintf("%d points - receipt raised to the floor of %d points", c.Floor-points, c.Floor)})
+	}
+	return scores
+}
+
+// PointsLedger sums the points awarded to each user per purchase day and per retailer, so the user caps
+// hold across receipts. Receipts without a user are not in it.
+type PointsLedger struct {
+	mu       sync.Mutex
+	entries  map[string]ledgerEntry
+	daily    map[string]int64
+	retailer map[string]int64
+}
+
+type ledgerEntry struct {
+	user, date, retailer string
+	points               int64
+}
+
+func (e ledgerEntry) dailyKey() string {
+	return e.user + "/" + e.date
+}
+
+func (e ledgerEntry) retailerKey() string {
+	return e.user + "/" + e.date + "/" + e.retailer
+}
+
+func NewPointsLedger() *PointsLedger {
+	return &PointsLedger{
+		entries:  make(map[string]ledgerEntry),
+		daily:    make(map[string]int64),
+		retailer: make(map[string]int64),
+	}
+}
+
+// newLedgerEntry keys the retailer of a receipt by its canonical retailer, so aliases share a cap.
+func newLedgerEntry(record ScoreRecord) ledgerEntry {
+	retailer := record.RetailerID
+	if retailer == "" {
+		retailer = NormalizeRetailer(record.Receipt.Retailer)
+	}
+	return ledgerEntry{user: record.Receipt.UserID, date: record.Receipt.PurchaseDate, retailer: retailer, points: record.Points}
+}
+
+// Award replaces the points a receipt contributes with the points of record, lowered to what the user caps leave.
+// It returns the points awarded and, when a cap lowered them, the reason. A nil ledger awards every point.
+func (l *PointsLedger) Award(id string, record ScoreRecord, caps Caps) (int64, string) {
+	if l == nil || record.Receipt.UserID == "" {
+		return record.Points, ""
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.remove(id)
+	entry := newLedgerEntry(record)
+	var reason string
+	if left := caps.UserDaily - l.daily[entry.dailyKey()]; caps.UserDaily > 0 && entry.points > max(left, 0) {
+		entry.points = max(left, 0)
+		reason = fmt.Sprintf("user %s capped at %d points on %s", entry.user, caps.UserDaily, entry.date)
+	}
+	if left := caps.UserRetailer - l.retailer[entry.retailerKey()]; caps.UserRetailer > 0 && entry.points > max(left, 0) {
+		entry.points = max(left, 0)
+		reason = fmt.Sprintf("user %s capped at %d points at %s on %s", entry.user, caps.UserRetailer, entry.retailer, entry.date)
+	}
+	l.add(id, entry)
+	return entry.points, reason
+}
+
+// Record adds the points of a stored record without capping them. A nil ledger ignores it.
+func (l *PointsLedger) Record(id string, record ScoreRecord) {
+	if l == nil || record.Receipt.UserID == "" {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.remove(id)
+	l.add(id, newLedgerEntry(record))
+}
+
+// Remove takes the points of a receipt out of the sums, e.g. when storing its score failed.
+func (l *PointsLedger) Remove(id string) {
+	if l == nil {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.remove(id)
+}
+
+func (l *PointsLedger) add(id string, entry ledgerEntry) {
+	l.entries[id] = entry
+	l.daily[entry.dailyKey()] += entry.points
+	l.retailer[entry.retailerKey()] += entry.points
+}
+
+func (l *PointsLedger) remove(id string) {
+	entry, ok := l.entries[id]
+	if !ok {
+		return
+	}
+	delete(l.entries, id)
+	if l.daily[entry.dailyKey()] -= entry.points; l.daily[entry.dailyKey()] == 0 {
+		delete(l.daily, entry.dailyKey())
+	}
+	if l.retailer[entry.retailerKey()] -= entry.points; l.retailer[entry.retailerKey()] == 0 {
+		delete(l.retailer, entry.retailerKey())
+	}
+}
+
+// RestoreLedger records the points of every stored receipt in the ledger of the options, so the user caps
+// count receipts scored before a restart. A receipt that can not be read, e.g. one evicted since it was listed,
+// is skipped, so only listing fails the restore.
+func (rps ReceiptProcessorService) RestoreLedger(ctx context.Context) domain.StatusCode {
+	if rps.opts.Ledger == nil {
+		return domain.StatusOK
+	}
+	ids, status := rps.repository.ListReceiptIDs(ctx)
+	if status > 0 {
+		return status
+	}
+	for _, id := range ids {
+		record, status := rps.repository.ReadReceiptScore(ctx, id)
+		if status > 0 {
+			slog.WarnContext(ctx, "Skipped a receipt the ledger could not read.", slog.String("id", id), slog.Int("status", int(status)))
+			continue
+		}
+		rps.opts.Ledger.Record(id, record)
+	}
+	return domain.StatusOK
+}
+
+// awardUser lowers the points of the record to what the user caps leave, adding a user_cap entry to the breakdown.
+func (rps ReceiptProcessorService) awardUser(id string, record *ScoreRecord, caps Caps) {
+	points, reason := rps.opts.Ledger.Award(id, *record, caps)
+	if points >= record.Points {
+		return
+	}
+	over := record.Points - points
+	record.Points = points
+	record.Breakdown = append(record.Breakdown, RuleScore{Rule: RuleUserCap, Points: -over, Capped: over, Reason: fmt.Sprintf("%d points - %s", -over, reason)})
+}
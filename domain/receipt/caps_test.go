@@ -0,0 +1,231 @@
+package receipt_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/kevin07696/receipt-processor/domain"
+	"github.com/kevin07696/receipt-processor/domain/receipt"
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	capsOpts  = receipt.Options{Rules: []string{receipt.RuleRetailer, receipt.RuleRoundTotal}}
+	capsMults = receipt.Multipliers{Retailer: 1, RoundTotal: 50}
+)
+
+func newCapsService(t *testing.T, repository receipt.IReceiptProcessorRepository, caps receipt.Caps, overrides ...receipt.RetailerOverride) receipt.ReceiptProcessorService {
+	opts := capsOpts
+	opts.Caps = caps
+	opts.Ledger = receipt.NewPointsLedger()
+	ruleSets, err := receipt.NewRuleSets(receipt.SelectByPurchaseDate,
+		receipt.RuleSet{Version: "v1", Options: opts, Multipliers: capsMults, Overrides: overrides},
+	)
+	assert.NoError(t, err)
+	return receipt.NewReceiptProcessorServiceWithRuleSets(repository, opts, ruleSets)
+}
+
+func sumBreakdown(breakdown []receipt.RuleScore) int64 {
+	var points int64
+	for _, score := range breakdown {
+		points += score.Points
+	}
+	return points
+}
+
+func TestProcessReceiptWithCaps(t *testing.T) {
+	overrideOpts := capsOpts
+	overrideOpts.Caps = receipt.Caps{Receipt: 10}
+
+	testCases := []struct {
+		title          string
+		caps           receipt.Caps
+		overrides      []receipt.RetailerOverride
+		expectedPoints int64
+		expectedLast   receipt.RuleScore
+	}{
+		{
+			title:          "GivenNoCaps_ReturnRulePoints",
+			expectedPoints: 56,
+			expectedLast:   receipt.RuleScore{Rule: receipt.RuleRoundTotal, Points: 50, Reason: "50 points - total is a round dollar amount"},
+		},
+		{
+			title:          "GivenARuleCap_CapTheRuleEntry",
+			caps:           receipt.Caps{Rules: map[string]int64{receipt.RuleRoundTotal: 20}},
+			expectedPoints: 26,
+			expectedLast:   receipt.RuleScore{Rule: receipt.RuleRoundTotal, Points: 20, Capped: 30, Reason: "50 points - total is a round dollar amount, capped at 20 points"},
+		},
+		{
+			title:          "GivenAReceiptCap_AddACapEntry",
+			caps:           receipt.Caps{Receipt: 40},
+			expectedPoints: 40,
+			expectedLast:   receipt.RuleScore{Rule: receipt.RuleCap, Points: -16, Capped: 16, Reason: "-16 points - receipt capped at 40 points"},
+		},
+		{
+			title:          "GivenAFloor_AddAFloorEntry",
+			caps:           receipt.Caps{Floor: 100},
+			expectedPoints: 100,
+			expectedLast:   receipt.RuleScore{Rule: receipt.RuleFloor, Points: 44, Reason: "44 points - receipt raised to the floor of 100 points"},
+		},
+		{
+			title:          "GivenAnOverrideWithCaps_UseTheOverrideCaps",
+			caps:           receipt.Caps{Receipt: 40},
+			overrides:      []receipt.RetailerOverride{{Name: "partner", Retailers: []string{"Target"}, Options: overrideOpts, Multipliers: capsMults, Factor: 1}},
+			expectedPoints: 10,
+			expectedLast:   receipt.RuleScore{Rule: receipt.RuleCap, Points: -46, Capped: 46, Reason: "-46 points - receipt capped at 10 points"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.title, func(t *testing.T) {
+			services := newCapsService(t, NewMapReceiptRepository(), tc.caps, tc.overrides...)
+
+			request := receipt.ReceiptProcessorRequest{ID: "id", Receipt: receipt.Receipt{Retailer: "Target", PurchaseDate: "2022-01-01", Total: "1.00"}}
+			_, status := services.ProcessReceipt(context.TODO(), request)
+			assert.Equal(t, domain.StatusOK, status)
+
+			breakdown, _ := services.GetReceiptBreakdown(context.TODO(), receipt.ReceiptBreakdownRequest{ID: "id"})
+			assert.Equal(t, tc.expectedPoints, breakdown.Points)
+			assert.Equal(t, tc.expectedPoints, sumBreakdown(breakdown.Breakdown))
+			assert.Equal(t, tc.expectedLast, breakdown.Breakdown[len(breakdown.Breakdown)-1])
+		})
+	}
+}
+
+func TestProcessReceiptWithUserCaps(t *testing.T) {
+	repository := NewMapReceiptRepository()
+	services := newCapsService(t, repository, receipt.Caps{UserDaily: 100, UserRetailer: 70})
+
+	// Target scores 56 points and Walmart 57, each processed in order
+	testCases := []struct {
+		title          string
+		id             string
+		retailer       string
+		user           string
+		purchaseDate   string
+		expectedPoints int64
+		expectedReason string
+	}{
+		{title: "GivenTheFirstReceipt_ReturnItsPoints", id: "1", retailer: "Target", user: "alice", purchaseDate: "2022-01-01", expectedPoints: 56},
+		{title: "GivenTheSameRetailer_CapAtTheRetailerCap", id: "2", retailer: "Target", user: "alice", purchaseDate: "2022-01-01", expectedPoints: 14,
+			expectedReason: "-42 points - user alice capped at 70 points at target on 2022-01-01"},
+		{title: "GivenAnotherRetailer_CapAtTheDailyCap", id: "3", retailer: "Walmart", user: "alice", purchaseDate: "2022-01-01", expectedPoints: 30,
+			expectedReason: "-27 points - user alice capped at 100 points on 2022-01-01"},
+		{title: "GivenTheDailyCapIsReached_ReturnZero", id: "4", retailer: "Walmart", user: "alice", purchaseDate: "2022-01-01", expectedPoints: 0,
+			expectedReason: "-57 points - user alice capped at 100 points on 2022-01-01"},
+		{title: "GivenAnotherDay_ReturnItsPoints", id: "5", retailer: "Target", user: "alice", purchaseDate: "2022-01-02", expectedPoints: 56},
+		{title: "GivenAnotherUser_ReturnItsPoints", id: "6", retailer: "Target", user: "bob", purchaseDate: "2022-01-01", expectedPoints: 56},
+		{title: "GivenNoUser_ReturnItsPoints", id: "7", retailer: "Target", purchaseDate: "2022-01-01", expectedPoints: 56},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.title, func(t *testing.T) {
+			request := receipt.ReceiptProcessorRequest{ID: tc.id, Receipt: receipt.Receipt{Retailer: tc.retailer, PurchaseDate: tc.purchaseDate, Total: "1.00", UserID: tc.user}}
+			_, status := services.ProcessReceipt(context.TODO(), request)
+			assert.Equal(t, domain.StatusOK, status)
+
+			breakdown, _ := services.GetReceiptBreakdown(context.TODO(), receipt.ReceiptBreakdownRequest{ID: tc.id})
+			assert.Equal(t, tc.expectedPoints, breakdown.Points)
+			assert.Equal(t, tc.expectedPoints, sumBreakdown(breakdown.Breakdown))
+
+			last := breakdown.Breakdown[len(breakdown.Breakdown)-1]
+			if tc.expectedReason == "" {
+				assert.NotEqual(t, receipt.RuleUserCap, last.Rule)
+				return
+			}
+			assert.Equal(t, receipt.RuleUserCap, last.Rule)
+			assert.Equal(t, tc.expectedReason, last.Reason)
+		})
+	}
+
+	t.Run("GivenARescore_ReplaceTheReceiptPoints", func(t *testing.T) {
+		assert.Equal(t, domain.StatusOK, services.RescoreReceipt(context.TODO(), "2", "v1"))
+
+		breakdown, _ := services.GetReceiptBreakdown(context.TODO(), receipt.ReceiptBreakdownRequest{ID: "2"})
+		assert.Equal(t, int64(14), breakdown.Points)
+	})
+}
+
+func TestProcessReceiptWithUserCapsGivenAFailedWrite_ReleaseThePoints(t *testing.T) {
+	repository := NewMapReceiptRepository()
+	write := repository.WriteReceiptScoreMock
+	repository.WriteReceiptScoreMock = func(ctx context.Context, id string, record receipt.ScoreRecord, scores map[string]receipt.ScoreRecord) domain.StatusCode {
+		if id == "failed" {
+			return domain.ErrInternal
+		}
+		return write(ctx, id, record, scores)
+	}
+	services := newCapsService(t, repository, receipt.Caps{UserDaily: 60})
+
+	request := receipt.ReceiptProcessorRequest{ID: "failed", Receipt: receipt.Receipt{Retailer: "Target", PurchaseDate: "2022-01-01", Total: "1.00", UserID: "alice"}}
+	_, status := services.ProcessReceipt(context.TODO(), request)
+	assert.Equal(t, domain.ErrInternal, status)
+
+	request.ID = "stored"
+	_, status = services.ProcessReceipt(context.TODO(), request)
+	assert.Equal(t, domain.StatusOK, status)
+
+	breakdown, _ := services.GetReceiptBreakdown(context.TODO(), receipt.ReceiptBreakdownRequest{ID: "stored"})
+	assert.Equal(t, int64(56), breakdown.Points)
+}
+
+func TestRestoreLedger(t *testing.T) {
+	repository := NewMapReceiptRepository()
+	repository.Scores["stored"] = receipt.ScoreRecord{Points: 50, Receipt: receipt.Receipt{Retailer: "Walmart", PurchaseDate: "2022-01-01", UserID: "alice"}}
+	// Listed before the stored receipt, but evicted before it is read
+	repository.Scores["evicted"] = receipt.ScoreRecord{}
+	read := repository.ReadReceiptScoreMock
+	repository.ReadReceiptScoreMock = func(ctx context.Context, id string, scores map[string]receipt.ScoreRecord) (receipt.ScoreRecord, domain.StatusCode) {
+		if id == "evicted" {
+			return receipt.ScoreRecord{}, domain.ErrNotFound
+		}
+		return read(ctx, id, scores)
+	}
+	services := newCapsService(t, repository, receipt.Caps{UserDaily: 60})
+
+	assert.Equal(t, domain.StatusOK, services.RestoreLedger(context.TODO()))
+
+	request := receipt.ReceiptProcessorRequest{ID: "id", Receipt: receipt.Receipt{Retailer: "Target", PurchaseDate: "2022-01-01", Total: "1.00", UserID: "alice"}}
+	_, status := services.ProcessReceipt(context.TODO(), request)
+	assert.Equal(t, domain.StatusOK, status)
+
+	breakdown, _ := services.GetReceiptBreakdown(context.TODO(), receipt.ReceiptBreakdownRequest{ID: "id"})
+	assert.Equal(t, int64(10), breakdown.Points)
+}
+
+func TestPointsLedger(t *testing.T) {
+	caps := receipt.Caps{UserDaily: 100}
+	record := receipt.ScoreRecord{Points: 80, Receipt: receipt.Receipt{Retailer: "Target", PurchaseDate: "2022-01-01", UserID: "alice"}}
+
+	t.Run("GivenANilLedger_AwardEveryPoint", func(t *testing.T) {
+		var ledger *receipt.PointsLedger
+		points, reason := ledger.Award("id", record, caps)
+		assert.Equal(t, int64(80), points)
+		assert.Empty(t, reason)
+		ledger.Remove("id")
+	})
+
+	t.Run("GivenARemovedReceipt_ReleaseItsPoints", func(t *testing.T) {
+		ledger := receipt.NewPointsLedger()
+		points, _ := ledger.Award("first", record, caps)
+		assert.Equal(t, int64(80), points)
+
+		points, _ = ledger.Award("second", record, caps)
+		assert.Equal(t, int64(20), points)
+
+		ledger.Remove("first")
+		points, _ = ledger.Award("second", record, caps)
+		assert.Equal(t, int64(80), points)
+	})
+
+	t.Run("GivenNegativePoints_KeepThem", func(t *testing.T) {
+		ledger := receipt.NewPointsLedger()
+		ledger.Record("over", receipt.ScoreRecord{Points: 150, Receipt: record.Receipt})
+
+		negative := record
+		negative.Points = -5
+		points, reason := ledger.Award("negative", negative, caps)
+		assert.Equal(t, int64(-5), points)
+		assert.Empty(t, reason)
+	})
+}
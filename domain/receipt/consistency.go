@@ -0,0 +1,129 @@
+package receipt
+
+import (
+	"context"
+	"log/slog"
+	"math"
+	"time"
+
+	"github.com/kevin07696/receipt-processor/domain"
+)
+
+// ConsistencyMode decides what happens to a receipt whose fields contradict each other.
+type ConsistencyMode string
+
+const (
+	// ConsistencyOff skips the consistency checks.
+	ConsistencyOff ConsistencyMode = "off"
+	// ConsistencyLenient scores an inconsistent receipt and reports the inconsistencies as warnings.
+	ConsistencyLenient ConsistencyMode = "lenient"
+	// ConsistencyStrict rejects an inconsistent receipt with the inconsistencies as violations.
+	ConsistencyStrict ConsistencyMode = "strict"
+)
+
+type ConsistencyOptions struct {
+	Mode ConsistencyMode
+	// Tolerance is how far the subtotal and the total may be from the amounts they add up, e.g. for unlisted tax or discounts.
+	Tolerance Money
+	// Now is the time purchase dates must not be after. Defaults to time.Now.
+	Now func() time.Time
+}
+
+// CheckConsistency returns the fields of a valid receipt that contradict each other or the calendar.
+// A purchase date may be one day ahead of UTC, because receipts carry no time zone.
+// The subtotal, or the item prices without one, less the discounts plus tax and tip must make the total.
+func (r Receipt) CheckConsistency(opts ConsistencyOptions) []domain.Violation {
+	var violations []domain.Violation
+
+	purchaseDate, err := time.Parse(time.DateOnly, r.PurchaseDate)
+	if err != nil {
+		violations = append(violations, domain.Violation{Path: "/purchaseDate", Rule: domain.RuleDate, Value: r.PurchaseDate})
+	} else {
+		now := time.Now
+		if opts.Now != nil {
+			now = opts.Now
+		}
+		latest := now().UTC().Truncate(24*time.Hour).AddDate(0, 0, 1)
+		if purchaseDate.After(latest) {
+			violations = append(violations, domain.Violation{Path: "/purchaseDate", Rule: domain.RuleFuture, Value: r.PurchaseDate})
+		}
+	}
+
+	sum, ok := r.ItemsSum()
+	if r.Subtotal != "" {
+		if !ok || !within(sum, r.SubtotalAmount(), opts.Tolerance) {
+			violations = append(violations, domain.Violation{Path: "/subtotal", Rule: domain.RuleSum, Value: r.Subtotal})
+		}
+		sum, ok = r.SubtotalAmount(), true
+	}
+
+	charges, chargesOk := addMoney(sum, r.TaxAmount(), r.TipAmount())
+	discounts, discountsOk := r.DiscountsSum()
+	if !ok || !chargesOk || !discountsOk || discounts > charges || !within(charges-discounts, r.TotalAmount(), opts.Tolerance) {
+		violations = append(violations, domain.Violation{Path: "/total", Rule: domain.RuleSum, Value: r.Total})
+	}
+
+	return violations
+}
+
+func within(amount, expected, tolerance Money) bool {
+	difference := amount - expected
+	if difference < 0 {
+		difference = -difference
+	}
+	return difference <= tolerance
+}
+
+// addMoney adds up amounts that are not negative. It is not ok when the sum does not fit in Money.
+func addMoney(amounts ...Money) (Money, bool) {
+	var sum Money
+	for _, amount := range amounts {
+		if sum > math.MaxInt64-amount {
+			return 0, false
+		}
+		sum += amount
+	}
+	return sum, true
+}
+
+// ItemsSum adds up the item prices. It is not ok when the sum does not fit in Money.
+func (r Receipt) ItemsSum() (Money, bool) {
+	prices := make([]Money, len(r.Items))
+	for i := range r.Items {
+		prices[i] = r.PriceAmount(i)
+	}
+	return addMoney(prices...)
+}
+
+// DiscountsSum adds up the discount amounts. It is not ok when the sum does not fit in Money.
+func (r Receipt) DiscountsSum() (Money, bool) {
+	amounts := make([]Money, len(r.Discounts))
+	for i := range r.Discounts {
+		amounts[i] = r.DiscountAmount(i)
+	}
+	return addMoney(amounts...)
+}
+
+// ValidateReceipt validates the receipt and checks its consistency.
+// Inconsistencies are violations in strict mode and warnings in lenient mode.
+func (rps ReceiptProcessorService) ValidateReceipt(ctx context.Context, receipt *Receipt) (violations []domain.Violation, warnings []domain.Violation) {
+	if violations := receipt.Validate(ctx); len(violations) > 0 {
+		return violations, nil
+	}
+
+	opts := rps.opts.Consistency
+	if opts.Mode == "" || opts.Mode == ConsistencyOff {
+		return nil, nil
+	}
+
+	inconsistencies := receipt.CheckConsistency(opts)
+	if len(inconsistencies) == 0 {
+		return nil, nil
+	}
+	slog.DebugContext(ctx, "Receipt failed consistency checks", slog.String("mode", string(opts.Mode)), slog.Any("ReceiptInvalidMsgs", inconsistencies))
+
+	if opts.Mode == ConsistencyStrict {
+		return inconsistencies, nil
+	}
+	return nil, inconsistencies
+}
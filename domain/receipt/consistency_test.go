@@ -0,0 +1,209 @@
+package receipt_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/kevin07696/receipt-processor/domain"
+	"github.com/kevin07696/receipt-processor/domain/receipt"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckConsistency(t *testing.T) {
+	now := func() time.Time { return time.Date(2024, 3, 20, 23, 0, 0, 0, time.UTC) }
+
+	testCases := []struct {
+		title              string
+		purchaseDate       string
+		prices             []string
+		total              string
+		tolerance          receipt.Money
+		extra              func(*receipt.Receipt)
+		expectedViolations []domain.Violation
+	}{
+		{
+			title:        "GivenAConsistentReceipt_ReturnNoViolations",
+			purchaseDate: "2024-03-20",
+			prices:       []string{"6.49", "3.51"},
+			total:        "10.00",
+		},
+		{
+			title:        "GivenATotalAboveTheItemSum_ReturnSumViolation",
+			purchaseDate: "2024-03-20",
+			prices:       []string{"6.49", "3.51"},
+			total:        "9999.00",
+			expectedViolations: []domain.Violation{
+				{Path: "/total", Rule: domain.RuleSum, Value: "9999.00"},
+			},
+		},
+		{
+			title:        "GivenATotalWithinTolerance_ReturnNoViolations",
+			purchaseDate: "2024-03-20",
+			prices:       []string{"6.49", "3.51"},
+			total:        "10.80",
+			tolerance:    80,
+		},
+		{
+			title:        "GivenATotalBelowTheItemSumBeyondTolerance_ReturnSumViolation",
+			purchaseDate: "2024-03-20",
+			prices:       []string{"6.49", "3.51"},
+			total:        "9.19",
+			tolerance:    80,
+			expectedViolations: []domain.Violation{
+				{Path: "/total", Rule: domain.RuleSum, Value: "9.19"},
+			},
+		},
+		{
+			title:        "GivenItemsThatOverflowTheirSum_ReturnSumViolation",
+			purchaseDate: "2024-03-20",
+			prices:       []string{"92233720368547758.07", "0.01"},
+			total:        "92233720368547758.07",
+			expectedViolations: []domain.Violation{
+				{Path: "/total", Rule: domain.RuleSum, Value: "92233720368547758.07"},
+			},
+		},
+		{
+			title:        "GivenTaxTipAndDiscountsThatMakeTheTotal_ReturnNoViolations",
+			purchaseDate: "2024-03-20",
+			prices:       []string{"6.49", "3.51"},
+			total:        "10.30",
+			extra: func(r *receipt.Receipt) {
+				r.Subtotal, r.Tax, r.Tip = "10.00", "0.80", "1.00"
+				r.Discounts = []receipt.Discount{{Description: "Coupon", Amount: "1.00", Coupon: true}, {Description: "Member", Amount: "0.50"}}
+			},
+		},
+		{
+			title:        "GivenTaxWithoutASubtotal_AddItToTheItemSum",
+			purchaseDate: "2024-03-20",
+			prices:       []string{"6.49", "3.51"},
+			total:        "10.80",
+			extra:        func(r *receipt.Receipt) { r.Tax = "0.80" },
+		},
+		{
+			title:        "GivenASubtotalOtherThanTheItemSum_ReturnSubtotalViolation",
+			purchaseDate: "2024-03-20",
+			prices:       []string{"6.49", "3.51"},
+			total:        "12.00",
+			extra:        func(r *receipt.Receipt) { r.Subtotal = "12.00" },
+			expectedViolations: []domain.Violation{
+				{Path: "/subtotal", Rule: domain.RuleSum, Value: "12.00"},
+			},
+		},
+		{
+			title:        "GivenUnlistedTax_ReturnSumViolation",
+			purchaseDate: "2024-03-20",
+			prices:       []string{"6.49", "3.51"},
+			total:        "10.80",
+			extra:        func(r *receipt.Receipt) { r.Subtotal = "10.00" },
+			expectedViolations: []domain.Violation{
+				{Path: "/total", Rule: domain.RuleSum, Value: "10.80"},
+			},
+		},
+		{
+			title:        "GivenDiscountsAboveTheSubtotal_ReturnSumViolation",
+			purchaseDate: "2024-03-20",
+			prices:       []string{"1.00"},
+			total:        "0.00",
+			extra: func(r *receipt.Receipt) {
+				r.Discounts = []receipt.Discount{{Description: "Coupon", Amount: "92233720368547758.07"}}
+			},
+			expectedViolations: []domain.Violation{
+				{Path: "/total", Rule: domain.RuleSum, Value: "0.00"},
+			},
+		},
+		{
+			title:        "GivenADayMissingFromTheMonth_ReturnDateViolation",
+			purchaseDate: "2023-02-31",
+			prices:       []string{"1.00"},
+			total:        "1.00",
+			expectedViolations: []domain.Violation{
+				{Path: "/purchaseDate", Rule: domain.RuleDate, Value: "2023-02-31"},
+			},
+		},
+		{
+			title:        "GivenTomorrow_ReturnNoViolations",
+			purchaseDate: "2024-03-21",
+			prices:       []string{"1.00"},
+			total:        "1.00",
+		},
+		{
+			title:        "GivenAFutureDate_ReturnFutureViolation",
+			purchaseDate: "2024-03-22",
+			prices:       []string{"1.00"},
+			total:        "2.00",
+			expectedViolations: []domain.Violation{
+				{Path: "/purchaseDate", Rule: domain.RuleFuture, Value: "2024-03-22"},
+				{Path: "/total", Rule: domain.RuleSum, Value: "2.00"},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.title, func(t *testing.T) {
+			rcpt := validReceipt(tc.total, tc.prices...)
+			rcpt.PurchaseDate = tc.purchaseDate
+			if tc.extra != nil {
+				tc.extra(&rcpt)
+			}
+			assert.Empty(t, rcpt.Validate(context.TODO()))
+
+			violations := rcpt.CheckConsistency(receipt.ConsistencyOptions{Tolerance: tc.tolerance, Now: now})
+			assert.Equal(t, tc.expectedViolations, violations)
+		})
+	}
+}
+
+func TestValidateReceipt(t *testing.T) {
+	sumViolation := []domain.Violation{{Path: "/total", Rule: domain.RuleSum, Value: "9999.00"}}
+	patternViolation := []domain.Violation{{Path: "/total", Rule: domain.RulePattern, Value: "9999"}}
+
+	testCases := []struct {
+		title              string
+		mode               receipt.ConsistencyMode
+		total              string
+		expectedViolations []domain.Violation
+		expectedWarnings   []domain.Violation
+	}{
+		{
+			title:              "GivenStrictMode_ReturnInconsistenciesAsViolations",
+			mode:               receipt.ConsistencyStrict,
+			total:              "9999.00",
+			expectedViolations: sumViolation,
+		},
+		{
+			title:            "GivenLenientMode_ReturnInconsistenciesAsWarnings",
+			mode:             receipt.ConsistencyLenient,
+			total:            "9999.00",
+			expectedWarnings: sumViolation,
+		},
+		{
+			title: "GivenOffMode_SkipConsistencyChecks",
+			mode:  receipt.ConsistencyOff,
+			total: "9999.00",
+		},
+		{
+			title:              "GivenAnInvalidReceipt_ReturnOnlyValidationViolations",
+			mode:               receipt.ConsistencyStrict,
+			total:              "9999",
+			expectedViolations: patternViolation,
+		},
+		{
+			title: "GivenAConsistentReceipt_ReturnNothing",
+			mode:  receipt.ConsistencyStrict,
+			total: "1.00",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.title, func(t *testing.T) {
+			opts := receipt.Options{Consistency: receipt.ConsistencyOptions{Mode: tc.mode}}
+			services := receipt.NewReceiptProcessorService(NewMapReceiptRepository(), opts, receipt.Multipliers{})
+
+			rcpt := validReceipt(tc.total, "1.00")
+			violations, warnings := services.ValidateReceipt(context.TODO(), &rcpt)
+			assert.Equal(t, tc.expectedViolations, violations)
+			assert.Equal(t, tc.expectedWarnings, warnings)
+		})
+	}
+}
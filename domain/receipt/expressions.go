@@ -0,0 +1,149 @@
+package receipt
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"math/big"
+	"regexp"
+
+	"github.com/kevin07696/receipt-processor/domain/expression"
+)
+
+// CustomRule is a scoring rule whose points are an expression over the receipt instead of Go code.
+type CustomRule struct {
+	Name       string
+	Expression string
+}
+
+// RuleSchema lists the fields of a receipt that custom rules read. Optional amounts a receipt leaves out are 0,
+// an item without a quantity has quantity 1 and other optional fields are empty.
+var RuleSchema = expression.Schema{
+	Fields: map[string]expression.Type{
+		"retailer":      expression.String,
+		"purchaseDate":  expression.String,
+		"purchaseTime":  expression.String,
+		"total":         expression.Number,
+		"subtotal":      expression.Number,
+		"tax":           expression.Number,
+		"tip":           expression.Number,
+		"paymentMethod": expression.String,
+	},
+	Lists: map[string]expression.ListSchema{
+		"items": {Element: "item", Fields: map[string]expression.Type{
+			"shortDescription": expression.String,
+			"price":            expression.Number,
+			"quantity":         expression.Number,
+			"unitPrice":        expression.Number,
+			"sku":              expression.String,
+			"upc":              expression.String,
+			"category":         expression.String,
+		}},
+		"discounts": {Element: "discount", Fields: map[string]expression.Type{
+			"description": expression.String,
+			"amount":      expression.Number,
+			"coupon":      expression.Bool,
+		}},
+	},
+}
+
+var customRuleNamePattern = regexp.MustCompile(`^[a-z][a-z0-9_]*$`)
+
+// CompileRule parses and type checks the expression of a custom rule, which must compute a number of points.
+// Compile errors of the expression are *expression.Error.
+func CompileRule(custom CustomRule) (ScoringRule, error) {
+	if !match(customRuleNamePattern, custom.Name) {
+		return nil, fmt.Errorf("custom rule name %q must match %s", custom.Name, customRuleNamePattern)
+	}
+	program, err := expression.Compile(custom.Expression, RuleSchema)
+	if err != nil {
+		return nil, err
+	}
+	if program.Type() != expression.Number {
+		return nil, &expression.Error{Column: 1, Message: fmt.Sprintf("expression must compute a number of points, found %s", program.Type())}
+	}
+	return expressionRule{name: custom.Name, program: program}, nil
+}
+
+// RegisterCustom compiles the custom rules and registers them after the rules already registered.
+// They are enabled by default and can not replace a registered rule.
+func (r *RuleRegistry) RegisterCustom(customs ...CustomRule) error {
+	for _, custom := range customs {
+		if r.Has(custom.Name) {
+			return fmt.Errorf("custom rule %s: a rule with this name is already registered", custom.Name)
+		}
+		rule, err := CompileRule(custom)
+		if err != nil {
+			return fmt.Errorf("custom rule %s: %w", custom.Name, err)
+		}
+		r.Register(custom.Name, func(opts Options, mults Multipliers) ScoringRule {
+			return rule
+		})
+	}
+	return nil
+}
+
+type expressionRule struct {
+	name    string
+	program *expression.Program
+}
+
+func (rule expressionRule) Name() string {
+	return rule.name
+}
+
+// Points rounds the value of the expression half away from zero. A value that fails to compute scores 0 points.
+func (rule expressionRule) Points(ctx context.Context, receipt Receipt) (int64, string) {
+	value, err := rule.program.Eval(receiptEnv(receipt))
+	if err != nil {
+		slog.WarnContext(ctx, "Custom rule failed.", slog.String("rule", rule.name), slog.Any("error", err))
+		return 0, fmt.Sprintf("0 points - %s failed: %v", rule.program, err)
+	}
+	points, ok := value.Int64()
+	if !ok {
+		slog.WarnContext(ctx, "Custom rule is out of range.", slog.String("rule", rule.name), slog.String("value", value.Format()))
+		return 0, fmt.Sprintf("0 points - %s = %s is out of range", rule.program, value.Format())
+	}
+	return points, fmt.Sprintf("%d points - %s = %s", points, rule.program, value.Format())
+}
+
+// receiptEnv holds the fields of RuleSchema of the receipt.
+func receiptEnv(receipt Receipt) expression.Env {
+	items := make([]expression.Record, len(receipt.Items))
+	for i, item := range receipt.Items {
+		items[i] = expression.Record{
+			"shortDescription": expression.NewString(item.ShortDescription),
+			"price":            moneyValue(receipt.PriceAmount(i)),
+			"quantity":         expression.NewInt(receipt.ItemQuantity(i)),
+			"unitPrice":        moneyValue(receipt.UnitPriceAmount(i)),
+			"sku":              expression.NewString(item.SKU),
+			"upc":              expression.NewString(item.UPC),
+			"category":         expression.NewString(item.Category),
+		}
+	}
+	discounts := make([]expression.Record, len(receipt.Discounts))
+	for i, discount := range receipt.Discounts {
+		discounts[i] = expression.Record{
+			"description": expression.NewString(discount.Description),
+			"amount":      moneyValue(receipt.DiscountAmount(i)),
+			"coupon":      expression.NewBool(discount.Coupon),
+		}
+	}
+
+	return expression.Env{
+		"retailer":      expression.NewString(receipt.Retailer),
+		"purchaseDate":  expression.NewString(receipt.PurchaseDate),
+		"purchaseTime":  expression.NewString(receipt.PurchaseTime),
+		"total":         moneyValue(receipt.TotalAmount()),
+		"subtotal":      moneyValue(receipt.SubtotalAmount()),
+		"tax":           moneyValue(receipt.TaxAmount()),
+		"tip":           moneyValue(receipt.TipAmount()),
+		"paymentMethod": expression.NewString(receipt.PaymentMethod),
+		"items":         expression.NewList(items),
+		"discounts":     expression.NewList(discounts),
+	}
+}
+
+func moneyValue(m Money) expression.Value {
+	return expression.NewNumber(big.NewRat(int64(m), 100))
+}
@@ -0,0 +1,105 @@
+package receipt_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/kevin07696/receipt-processor/domain"
+	"github.com/kevin07696/receipt-processor/domain/receipt"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCustomRulePoints(t *testing.T) {
+	rcpt := validReceipt("35.35", "12.25", "2.25", "10.01")
+	rcpt.Items[0].ShortDescription = "Doritos Nacho Cheese"
+	rcpt.Items[0].Quantity = "5"
+	rcpt.Items[0].UnitPrice = "2.45"
+	rcpt.Tax = "0.84"
+	rcpt.Discounts = []receipt.Discount{{Description: "Coupon", Amount: "1.00", Coupon: true}}
+	assert.Empty(t, rcpt.Validate(context.TODO()))
+
+	testCases := []struct {
+		title          string
+		expression     string
+		expectedPoints int64
+		expectedReason string
+	}{
+		{
+			title:          "GivenPointsPerItemOverAnAmount_ReturnPoints",
+			expression:     "5 * count(items, item.price > 10.00)",
+			expectedPoints: 10,
+			expectedReason: "10 points - 5 * count(items, item.price > 10.00) = 10",
+		},
+		{
+			title:          "GivenUnitsAndDiscounts_ReturnPoints",
+			expression:     "sum(items, item.quantity) + 10 * count(discounts, discount.coupon) + tax * 100",
+			expectedPoints: 101,
+			expectedReason: "101 points - sum(items, item.quantity) + 10 * count(discounts, discount.coupon) + tax * 100 = 101",
+		},
+		{
+			title:          "GivenAFraction_RoundHalfAwayFromZero",
+			expression:     "total / 10",
+			expectedPoints: 4,
+			expectedReason: "4 points - total / 10 = 3.535",
+		},
+		{
+			title:          "GivenAFailure_ReturnZeroPoints",
+			expression:     "100 / (len(items) - 3)",
+			expectedPoints: 0,
+			expectedReason: "0 points - 100 / (len(items) - 3) failed: column 5: division by zero",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.title, func(t *testing.T) {
+			rule, err := receipt.CompileRule(receipt.CustomRule{Name: "custom", Expression: tc.expression})
+			assert.NoError(t, err)
+
+			points, reason := rule.Points(context.TODO(), rcpt)
+			assert.Equal(t, tc.expectedPoints, points)
+			assert.Equal(t, tc.expectedReason, reason)
+		})
+	}
+}
+
+func TestCompileRule(t *testing.T) {
+	testCases := []struct {
+		title    string
+		rule     receipt.CustomRule
+		expected string
+	}{
+		{title: "GivenAnInvalidName_ReturnError", rule: receipt.CustomRule{Name: "Big Items", Expression: "1"}, expected: "custom rule name \"Big Items\" must match ^[a-z][a-z0-9_]*$"},
+		{title: "GivenAnUnknownField_ReturnError", rule: receipt.CustomRule{Name: "big_items", Expression: "count(items, item.cost > 10)"}, expected: `column 19: item has no field "cost", expected one of category, price, quantity, shortDescription, sku, unitPrice, upc`},
+		{title: "GivenABoolExpression_ReturnError", rule: receipt.CustomRule{Name: "big_items", Expression: "total > 10"}, expected: "column 1: expression must compute a number of points, found bool"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.title, func(t *testing.T) {
+			_, err := receipt.CompileRule(tc.rule)
+			assert.EqualError(t, err, tc.expected)
+		})
+	}
+}
+
+func TestRegisterCustom(t *testing.T) {
+	registry := receipt.NewDefaultRuleRegistry()
+	assert.Error(t, registry.RegisterCustom(receipt.CustomRule{Name: receipt.RuleRetailer, Expression: "1"}))
+	assert.Error(t, registry.RegisterCustom(receipt.CustomRule{Name: "broken", Expression: "1 +"}))
+	assert.NoError(t, registry.RegisterCustom(receipt.CustomRule{Name: "big_items", Expression: "5 * count(items, item.price > 10.00)"}))
+
+	// Custom rules are enabled by default after the rules registered before them
+	defaults := registry.Defaults()
+	assert.Equal(t, "big_items", defaults[len(defaults)-1])
+
+	mults := receipt.Multipliers{RoundTotal: 50}
+	rules, err := registry.Build(receipt.Options{}, mults, []string{receipt.RuleRoundTotal, "big_items"})
+	assert.NoError(t, err)
+	services := receipt.NewReceiptProcessorServiceWithRules(NewMapReceiptRepository(), receipt.Options{}, mults, rules)
+
+	_, status := services.ProcessReceipt(context.TODO(), receipt.ReceiptProcessorRequest{ID: "id", Receipt: validReceipt("25.00", "12.00", "13.00")})
+	assert.Equal(t, domain.StatusOK, status)
+
+	breakdown, _ := services.GetReceiptBreakdown(context.TODO(), receipt.ReceiptBreakdownRequest{ID: "id"})
+	assert.Equal(t, int64(60), breakdown.Points)
+	assert.Equal(t, receipt.RuleScore{Rule: "big_items", Points: 10, Reason: "10 points - 5 * count(items, item.price > 10.00) = 10"}, breakdown.Breakdown[1])
+}
@@ -9,15 +9,24 @@ import (
 type IReceiptProcessorService interface {
 	ProcessReceipt(ctx context.Context, request ReceiptProcessorRequest) (ReceiptProcessorResponse, domain.StatusCode)
 	GetReceiptScore(ctx context.Context, request ReceiptScoreRequest) (ReceiptScoreResponse, domain.StatusCode)
+	GetReceiptBreakdown(ctx context.Context, request ReceiptBreakdownRequest) (ReceiptBreakdownResponse, domain.StatusCode)
+	ValidateReceipt(ctx context.Context, receipt *Receipt) (violations []domain.Violation, warnings []domain.Violation)
+	SimulateReceipt(ctx context.Context, request ReceiptSimulationRequest) (ReceiptSimulationResponse, domain.StatusCode, []domain.Violation)
 	GenerateID(ctx context.Context, input string) string
 }
 
 type IReceiptProcessorRepository interface {
-	WriteReceiptScore(ctx context.Context, id string, value int64) domain.StatusCode
-	ReadReceiptScore(ctx context.Context, id string) (int64, domain.StatusCode)
+	WriteReceiptScore(ctx context.Context, id string, record ScoreRecord) domain.StatusCode
+	ReadReceiptScore(ctx context.Context, id string) (ScoreRecord, domain.StatusCode)
+	ListReceiptIDs(ctx context.Context) ([]string, domain.StatusCode)
 }
 
 type IRepository interface {
 	Set(ctx context.Context, id string, value interface{}) domain.StatusCode
 	Get(ctx context.Context, id string) (interface{}, domain.StatusCode)
 }
+
+// IKeyLister is implemented by repositories that can list their keys, which lets stored receipts be walked.
+type IKeyLister interface {
+	Keys(ctx context.Context) ([]string, domain.StatusCode)
+}
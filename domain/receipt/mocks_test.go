@@ -2,20 +2,170 @@ package receipt_test
 
 import (
 	"context"
+	"errors"
+	"sort"
+	"sync"
 
 	"github.com/kevin07696/receipt-processor/domain"
+	"github.com/kevin07696/receipt-processor/domain/receipt"
 )
 
 type MockReceiptRepository struct {
-	WriteReceiptScoreMock func(ctx context.Context, id string, points int64, scores map[string]int64) domain.StatusCode
-	ReadReceiptScoreMock  func(ctx context.Context, id string, scores map[string]int64) (int64, domain.StatusCode)
-	Scores                map[string]int64
+	WriteReceiptScoreMock func(ctx context.Context, id string, record receipt.ScoreRecord, scores map[string]receipt.ScoreRecord) domain.StatusCode
+	ReadReceiptScoreMock  func(ctx context.Context, id string, scores map[string]receipt.ScoreRecord) (receipt.ScoreRecord, domain.StatusCode)
+	Scores                map[string]receipt.ScoreRecord
 }
 
-func (m MockReceiptRepository) WriteReceiptScore(ctx context.Context, id string, points int64) domain.StatusCode {
-	return m.WriteReceiptScoreMock(ctx, id, points, m.Scores)
+func (m MockReceiptRepository) WriteReceiptScore(ctx context.Context, id string, record receipt.ScoreRecord) domain.StatusCode {
+	return m.WriteReceiptScoreMock(ctx, id, record, m.Scores)
 }
 
-func (m MockReceiptRepository) ReadReceiptScore(ctx context.Context, id string) (int64, domain.StatusCode) {
+func (m MockReceiptRepository) ReadReceiptScore(ctx context.Context, id string) (receipt.ScoreRecord, domain.StatusCode) {
 	return m.ReadReceiptScoreMock(ctx, id, m.Scores)
 }
+
+func (m MockReceiptRepository) ListReceiptIDs(ctx context.Context) ([]string, domain.StatusCode) {
+	ids := make([]string, 0, len(m.Scores))
+	for id := range m.Scores {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids, domain.StatusOK
+}
+
+// MockRepository is an in-memory IRepository that counts its calls and can be made to fail or block writes.
+// SetErr fails every write, or only the first SetErrs writes when SetErrs is set.
+type MockRepository struct {
+	mu      sync.Mutex
+	Values  map[string]interface{}
+	Gets    int
+	Sets    int
+	SetErr  domain.StatusCode
+	SetErrs int
+	Blocked chan struct{}
+}
+
+func NewMockRepository() *MockRepository {
+	return &MockRepository{Values: map[string]interface{}{}}
+}
+
+func (m *MockRepository) Get(ctx context.Context, id string) (interface{}, domain.StatusCode) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.Gets++
+	value, ok := m.Values[id]
+	if !ok {
+		return nil, domain.ErrNotFound
+	}
+	return value, domain.StatusOK
+}
+
+func (m *MockRepository) Set(ctx context.Context, id string, value interface{}) domain.StatusCode {
+	if m.Blocked != nil {
+		<-m.Blocked
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.Sets++
+	if m.SetErr > 0 && (m.SetErrs == 0 || m.Sets <= m.SetErrs) {
+		return m.SetErr
+	}
+	m.Values[id] = value
+	return domain.StatusOK
+}
+
+func (m *MockRepository) Len() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return len(m.Values)
+}
+
+// NewMapReceiptRepository stores scores in its Scores map.
+func NewMapReceiptRepository() MockReceiptRepository {
+	return MockReceiptRepository{
+		WriteReceiptScoreMock: func(ctx context.Context, id string, record receipt.ScoreRecord, scores map[string]receipt.ScoreRecord) domain.StatusCode {
+			scores[id] = record
+			return domain.StatusOK
+		},
+		ReadReceiptScoreMock: func(ctx context.Context, id string, scores map[string]receipt.ScoreRecord) (receipt.ScoreRecord, domain.StatusCode) {
+			record, ok := scores[id]
+			if !ok {
+				return receipt.ScoreRecord{}, domain.ErrNotFound
+			}
+			return record, domain.StatusOK
+		},
+		Scores: map[string]receipt.ScoreRecord{},
+	}
+}
+
+// MockRescoreService blocks each RescoreReceipt until Release receives or the job is cancelled, when Release is set.
+// Started receives the ID of each receipt it begins to rescore, when it is set.
+type MockRescoreService struct {
+	IDs      []string
+	Versions []string
+	Fail     map[string]bool
+	Started  chan string
+	Release  chan struct{}
+	mu       sync.Mutex
+	Rescored []string
+}
+
+func (m *MockRescoreService) HasRuleSetVersion(version string) bool {
+	for _, v := range m.Versions {
+		if v == version {
+			return true
+		}
+	}
+	return false
+}
+
+func (m *MockRescoreService) ListReceiptIDs(ctx context.Context) ([]string, domain.StatusCode) {
+	return m.IDs, domain.StatusOK
+}
+
+func (m *MockRescoreService) RescoreReceipt(ctx context.Context, id string, version string) domain.StatusCode {
+	if m.Started != nil {
+		m.Started <- id
+	}
+	if m.Release != nil {
+		select {
+		case <-m.Release:
+		case <-ctx.Done():
+		}
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.Rescored = append(m.Rescored, id)
+	if m.Fail[id] {
+		return domain.ErrInternal
+	}
+	return domain.StatusOK
+}
+
+// MockRetailerStore records the retailers of each save and fails them while Fail is set.
+type MockRetailerStore struct {
+	Saved [][]receipt.Retailer
+	Fail  bool
+}
+
+func (m *MockRetailerStore) SaveRetailers(retailers []receipt.Retailer) error {
+	if m.Fail {
+		return errors.New("disk full")
+	}
+	m.Saved = append(m.Saved, retailers)
+	return nil
+}
+
+// MockCampaignStore records the campaigns of each save and fails them while Fail is set.
+type MockCampaignStore struct {
+	Saved [][]receipt.Campaign
+	Fail  bool
+}
+
+func (m *MockCampaignStore) SaveCampaigns(campaigns []receipt.Campaign) error {
+	if m.Fail {
+		return errors.New("disk full")
+	}
+	m.Saved = append(m.Saved, campaigns)
+	return nil
+}
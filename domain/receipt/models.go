@@ -3,21 +3,68 @@ package receipt
 import (
 	"context"
 	"fmt"
+	"log"
 	"log/slog"
+	"math"
 	"regexp"
+	"slices"
+	"strconv"
+
+	"github.com/kevin07696/receipt-processor/domain"
 )
 
 type Item struct {
 	ShortDescription string `json:"shortDescription" validate:"description"`
 	Price            string `json:"price" validate:"currency"`
+	// The fields below are optional, items without them keep the original payload. Quantity defaults to 1.
+	Quantity  string `json:"quantity,omitempty" validate:"omitempty,quantity"`
+	UnitPrice string `json:"unitPrice,omitempty" validate:"omitempty,currency"`
+	SKU       string `json:"sku,omitempty" validate:"omitempty,sku"`
+	UPC       string `json:"upc,omitempty" validate:"omitempty,upc"`
+	Category  string `json:"category,omitempty" validate:"omitempty,description"`
+	// price, unitPrice and quantity are parsed by Receipt.Validate
+	price, unitPrice Money
+	quantity         int64
+}
+
+// Discount is an amount taken off the receipt. Coupon marks discounts redeemed with a coupon.
+type Discount struct {
+	Description string `json:"description" validate:"description"`
+	Amount      string `json:"amount" validate:"currency"`
+	Coupon      bool   `json:"coupon,omitempty"`
+	// amount is Amount parsed by Receipt.Validate
+	amount Money
 }
 
+// Payment methods a receipt may name.
+const (
+	PaymentCash     = "cash"
+	PaymentCredit   = "credit"
+	PaymentDebit    = "debit"
+	PaymentGiftCard = "gift_card"
+	PaymentMobile   = "mobile"
+	PaymentOther    = "other"
+)
+
+var paymentMethods = []string{PaymentCash, PaymentCredit, PaymentDebit, PaymentGiftCard, PaymentMobile, PaymentOther}
+
 type Receipt struct {
 	Retailer     string `json:"retailer" validate:"retailer"`
 	PurchaseDate string `json:"purchaseDate" validate:"date"`
 	PurchaseTime string `json:"purchaseTime" validate:"time"`
 	Items        []Item `json:"" validate:"required,min=1,dive,required"`
 	Total        string `json:"" validate:"currency"`
+	// The fields below are optional, receipts without them keep the original payload
+	Subtotal      string     `json:"subtotal,omitempty" validate:"omitempty,currency"`
+	Tax           string     `json:"tax,omitempty" validate:"omitempty,currency"`
+	Tip           string     `json:"tip,omitempty" validate:"omitempty,currency"`
+	Discounts     []Discount `json:"discounts,omitempty" validate:"omitempty,dive,required"`
+	PaymentMethod string     `json:"paymentMethod,omitempty" validate:"omitempty,oneof"`
+	// UserID identifies the customer the user point caps apply to
+	UserID string `json:"userId,omitempty" validate:"omitempty,user"`
+	// total is Total parsed by Validate, which also parses the other amounts and sets parsed
+	total, subtotal, tax, tip Money
+	parsed                    bool
 }
 
 type ID string
@@ -29,46 +76,216 @@ var (
 	timePattern        = regexp.MustCompile(`^(0[0-9]|1[0-9]|2[0-3]):([0-5][0-9])$`)
 	datePattern        = regexp.MustCompile(`^[0-9]{4}-(0[1-9]|1[0-2])-(0[1-9]|[12][0-9]|3[01])$`)
 	currencyPattern    = regexp.MustCompile(`^\d+\.\d{2}$`)
+	// quantityPattern keeps quantities below a billion, so units and unit price products are easy to bound
+	quantityPattern = regexp.MustCompile(`^[1-9]\d{0,8}$`)
+	skuPattern      = regexp.MustCompile(`^[\w\-]+$`)
+	upcPattern      = regexp.MustCompile(`^(\d{12}|\d{13})$`)
+	userPattern     = regexp.MustCompile(`^[\w\-]{1,64}$`)
 )
 
 func match(pattern *regexp.Regexp, value string) bool {
 	return pattern.MatchString(value)
 }
 
-func (r Receipt) Validate(ctx context.Context) bool {
-	var debugMessages []string
+// Validate returns every field that does not match its pattern. A valid receipt has no violations
+// and has its amounts parsed, so rules do not parse them again.
+func (r *Receipt) Validate(ctx context.Context) []domain.Violation {
+	var violations []domain.Violation
+	check := func(pattern *regexp.Regexp, path, value string) {
+		if value == "" {
+			violations = append(violations, domain.Violation{Path: path, Rule: domain.RuleRequired, Value: value})
+		} else if !match(pattern, value) {
+			violations = append(violations, domain.Violation{Path: path, Rule: domain.RulePattern, Value: value})
+		}
+	}
+
+	check(retailerPattern, "/retailer", r.Retailer)
+	check(datePattern, "/purchaseDate", r.PurchaseDate)
+	check(timePattern, "/purchaseTime", r.PurchaseTime)
+
+	if len(r.Items) == 0 {
+		violations = append(violations, domain.Violation{Path: "/items", Rule: domain.RuleMin, Value: "[]"})
+	}
+	// Amounts that match the pattern can still be too large for Money
+	amount := func(path, value string) Money {
+		before := len(violations)
+		check(currencyPattern, path, value)
+		if len(violations) > before {
+			return 0
+		}
+		parsed, err := ParseMoney(value)
+		if err != nil {
+			violations = append(violations, domain.Violation{Path: path, Rule: domain.RuleMax, Value: value})
+		}
+		return parsed
+	}
 
-	for _, i := range r.Items {
-		if !match(descriptionPattern, i.ShortDescription) {
-			debugMessages = append(debugMessages, fmt.Sprintf("ShortDescription failed validation: %s", i.ShortDescription))
+	optionalAmount := func(path, value string) Money {
+		if value == "" {
+			return 0
 		}
-		if !match(currencyPattern, i.Price) {
-			debugMessages = append(debugMessages, fmt.Sprintf("Price failed validation: %s", i.Price))
+		return amount(path, value)
+	}
+	optional := func(pattern *regexp.Regexp, path, value string) {
+		if value != "" {
+			check(pattern, path, value)
+		}
+	}
+
+	prices := make([]Money, len(r.Items))
+	unitPrices := make([]Money, len(r.Items))
+	for i, item := range r.Items {
+		check(descriptionPattern, fmt.Sprintf("/items/%d/shortDescription", i), item.ShortDescription)
+		before := len(violations)
+		prices[i] = amount(fmt.Sprintf("/items/%d/price", i), item.Price)
+		optional(quantityPattern, fmt.Sprintf("/items/%d/quantity", i), item.Quantity)
+		unitPrices[i] = optionalAmount(fmt.Sprintf("/items/%d/unitPrice", i), item.UnitPrice)
+		// An item with a unit price must cost its quantity times it
+		if item.UnitPrice != "" && len(violations) == before {
+			quantity := Money(parseQuantity(item.Quantity))
+			if unitPrices[i] > math.MaxInt64/quantity || unitPrices[i]*quantity != prices[i] {
+				violations = append(violations, domain.Violation{Path: fmt.Sprintf("/items/%d/price", i), Rule: domain.RuleSum, Value: item.Price})
+			}
 		}
+		optional(skuPattern, fmt.Sprintf("/items/%d/sku", i), item.SKU)
+		optional(upcPattern, fmt.Sprintf("/items/%d/upc", i), item.UPC)
+		optional(descriptionPattern, fmt.Sprintf("/items/%d/category", i), item.Category)
+	}
+
+	total := amount("/total", r.Total)
+	subtotal := optionalAmount("/subtotal", r.Subtotal)
+	tax := optionalAmount("/tax", r.Tax)
+	tip := optionalAmount("/tip", r.Tip)
+
+	discounts := make([]Money, len(r.Discounts))
+	for i, discount := range r.Discounts {
+		check(descriptionPattern, fmt.Sprintf("/discounts/%d/description", i), discount.Description)
+		discounts[i] = amount(fmt.Sprintf("/discounts/%d/amount", i), discount.Amount)
 	}
 
-	if !match(retailerPattern, r.Retailer) {
-		debugMessages = append(debugMessages, fmt.Sprintf("Retailer failed validation: %s", r.Retailer))
+	optional(userPattern, "/userId", r.UserID)
+
+	if r.PaymentMethod != "" && !slices.Contains(paymentMethods, r.PaymentMethod) {
+		violations = append(violations, domain.Violation{Path: "/paymentMethod", Rule: domain.RuleOneOf, Value: r.PaymentMethod})
 	}
 
-	if !match(timePattern, r.PurchaseTime) {
-		debugMessages = append(debugMessages, fmt.Sprintf("PurchaseTime failed validation: %s", r.PurchaseTime))
+	if len(violations) > 0 {
+		slog.DebugContext(ctx, "Receipt failed validation", slog.Any("ReceiptInvalidMsgs", violations))
+		return violations
 	}
 
-	if !match(datePattern, r.PurchaseDate) {
-		debugMessages = append(debugMessages, fmt.Sprintf("PurchaseDate failed validation: %s", r.PurchaseDate))
+	for i := range r.Items {
+		r.Items[i].price = prices[i]
+		r.Items[i].unitPrice = unitPrices[i]
+		r.Items[i].quantity = parseQuantity(r.Items[i].Quantity)
+	}
+	for i := range r.Discounts {
+		r.Discounts[i].amount = discounts[i]
 	}
+	r.total, r.subtotal, r.tax, r.tip = total, subtotal, tax, tip
+	r.parsed = true
+	return nil
+}
 
-	if !match(currencyPattern, r.Total) {
-		debugMessages = append(debugMessages, fmt.Sprintf("Total failed validation: %s", r.Total))
+// TotalAmount is Total in cents. Receipts that were not validated, e.g. read from a repository, are parsed on each call.
+func (r Receipt) TotalAmount() Money {
+	if r.parsed {
+		return r.total
 	}
+	return mustParseMoney(r.Total)
+}
 
-	if len(debugMessages) > 0 {
-		slog.DebugContext(ctx, "Receipt failed validation", slog.Any("ReceiptInvalidMsgs", debugMessages))
-		return false
+// PriceAmount is the price of item i in cents.
+func (r Receipt) PriceAmount(i int) Money {
+	if r.parsed {
+		return r.Items[i].price
 	}
+	return mustParseMoney(r.Items[i].Price)
+}
 
-	return true
+// UnitPriceAmount is the unit price of item i in cents, 0 when the item has none.
+func (r Receipt) UnitPriceAmount(i int) Money {
+	if r.parsed {
+		return r.Items[i].unitPrice
+	}
+	return mustParseOptionalMoney(r.Items[i].UnitPrice)
+}
+
+// ItemQuantity is the quantity of item i, 1 when the item has none.
+func (r Receipt) ItemQuantity(i int) int64 {
+	if r.parsed {
+		return r.Items[i].quantity
+	}
+	return parseQuantity(r.Items[i].Quantity)
+}
+
+// Units adds up the item quantities.
+func (r Receipt) Units() int64 {
+	var units int64
+	for i := range r.Items {
+		units += r.ItemQuantity(i)
+	}
+	return units
+}
+
+func parseQuantity(value string) int64 {
+	if value == "" {
+		return 1
+	}
+	// This should not happen unless quantities are not properly validated
+	quantity, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		log.Fatalf("Failed to parse quantity. Check validation: %v", err)
+	}
+	return quantity
+}
+
+// SubtotalAmount is Subtotal in cents, 0 when the receipt has none.
+func (r Receipt) SubtotalAmount() Money {
+	if r.parsed {
+		return r.subtotal
+	}
+	return mustParseOptionalMoney(r.Subtotal)
+}
+
+// TaxAmount is Tax in cents, 0 when the receipt has none.
+func (r Receipt) TaxAmount() Money {
+	if r.parsed {
+		return r.tax
+	}
+	return mustParseOptionalMoney(r.Tax)
+}
+
+// TipAmount is Tip in cents, 0 when the receipt has none.
+func (r Receipt) TipAmount() Money {
+	if r.parsed {
+		return r.tip
+	}
+	return mustParseOptionalMoney(r.Tip)
+}
+
+// DiscountAmount is the amount of discount i in cents.
+func (r Receipt) DiscountAmount(i int) Money {
+	if r.parsed {
+		return r.Discounts[i].amount
+	}
+	return mustParseMoney(r.Discounts[i].Amount)
+}
+
+func mustParseOptionalMoney(value string) Money {
+	if value == "" {
+		return 0
+	}
+	return mustParseMoney(value)
+}
+
+func mustParseMoney(value string) Money {
+	// This should not happen unless amounts are not properly validated
+	amount, err := ParseMoney(value)
+	if err != nil {
+		log.Fatalf("Failed to parse amount. Check validation: %v", err)
+	}
+	return amount
 }
 
 func (id ID) Validate() bool {
@@ -0,0 +1,168 @@
+package receipt_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/kevin07696/receipt-processor/domain"
+	"github.com/kevin07696/receipt-processor/domain/receipt"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidate(t *testing.T) {
+	testCases := []struct {
+		title              string
+		receipt            receipt.Receipt
+		expectedViolations []domain.Violation
+	}{
+		{
+			title: "GivenAValidReceipt_ReturnNoViolations",
+			receipt: receipt.Receipt{
+				Retailer:     "M&M Corner Market",
+				PurchaseDate: "2022-03-20",
+				PurchaseTime: "14:33",
+				Items:        []receipt.Item{{ShortDescription: "Gatorade", Price: "2.25"}},
+				Total:        "2.25",
+			},
+		},
+		{
+			title: "GivenInvalidItems_ReturnItemPaths",
+			receipt: receipt.Receipt{
+				Retailer:     "Target",
+				PurchaseDate: "2022-03-20",
+				PurchaseTime: "14:33",
+				Items: []receipt.Item{
+					{ShortDescription: "Gatorade", Price: "2.25"},
+					{ShortDescription: "", Price: "2.2"},
+				},
+				Total: "4.50",
+			},
+			expectedViolations: []domain.Violation{
+				{Path: "/items/1/shortDescription", Rule: domain.RuleRequired, Value: ""},
+				{Path: "/items/1/price", Rule: domain.RulePattern, Value: "2.2"},
+			},
+		},
+		{
+			title: "GivenAnAmountTooLargeForMoney_ReturnMaxViolation",
+			receipt: receipt.Receipt{
+				Retailer:     "Target",
+				PurchaseDate: "2022-03-20",
+				PurchaseTime: "14:33",
+				Items:        []receipt.Item{{ShortDescription: "Gatorade", Price: "92233720368547758.08"}},
+				Total:        "92233720368547758.08",
+			},
+			expectedViolations: []domain.Violation{
+				{Path: "/items/0/price", Rule: domain.RuleMax, Value: "92233720368547758.08"},
+				{Path: "/total", Rule: domain.RuleMax, Value: "92233720368547758.08"},
+			},
+		},
+		{
+			title: "GivenValidOptionalFields_ReturnNoViolations",
+			receipt: receipt.Receipt{
+				Retailer:      "Target",
+				PurchaseDate:  "2022-03-20",
+				PurchaseTime:  "14:33",
+				Items:         []receipt.Item{{ShortDescription: "Gatorade", Price: "2.25"}},
+				Total:         "2.93",
+				Subtotal:      "2.25",
+				Tax:           "0.18",
+				Tip:           "1.00",
+				Discounts:     []receipt.Discount{{Description: "Store coupon", Amount: "0.50", Coupon: true}},
+				PaymentMethod: receipt.PaymentGiftCard,
+				UserID:        "user-42",
+			},
+		},
+		{
+			title: "GivenInvalidOptionalFields_ReturnTheirPaths",
+			receipt: receipt.Receipt{
+				Retailer:      "Target",
+				PurchaseDate:  "2022-03-20",
+				PurchaseTime:  "14:33",
+				Items:         []receipt.Item{{ShortDescription: "Gatorade", Price: "2.25"}},
+				Total:         "2.25",
+				Subtotal:      "2.2",
+				Tip:           "92233720368547758.08",
+				Discounts:     []receipt.Discount{{Description: "10% off"}},
+				PaymentMethod: "cheque",
+				UserID:        "user 42",
+			},
+			expectedViolations: []domain.Violation{
+				{Path: "/subtotal", Rule: domain.RulePattern, Value: "2.2"},
+				{Path: "/tip", Rule: domain.RuleMax, Value: "92233720368547758.08"},
+				{Path: "/discounts/0/description", Rule: domain.RulePattern, Value: "10% off"},
+				{Path: "/discounts/0/amount", Rule: domain.RuleRequired, Value: ""},
+				{Path: "/userId", Rule: domain.RulePattern, Value: "user 42"},
+				{Path: "/paymentMethod", Rule: domain.RuleOneOf, Value: "cheque"},
+			},
+		},
+		{
+			title: "GivenInvalidOptionalItemFields_ReturnTheirPaths",
+			receipt: receipt.Receipt{
+				Retailer:     "Target",
+				PurchaseDate: "2022-03-20",
+				PurchaseTime: "14:33",
+				Items: []receipt.Item{
+					{ShortDescription: "Gatorade", Price: "2.25", Quantity: "3", UnitPrice: "0.75", SKU: "GT-20", UPC: "012345678905", Category: "Drinks"},
+					{ShortDescription: "Gatorade", Price: "2.25", Quantity: "0", UnitPrice: "0.7", SKU: "GT 20", UPC: "12345", Category: "Drinks!"},
+				},
+				Total: "4.50",
+			},
+			expectedViolations: []domain.Violation{
+				{Path: "/items/1/quantity", Rule: domain.RulePattern, Value: "0"},
+				{Path: "/items/1/unitPrice", Rule: domain.RulePattern, Value: "0.7"},
+				{Path: "/items/1/sku", Rule: domain.RulePattern, Value: "GT 20"},
+				{Path: "/items/1/upc", Rule: domain.RulePattern, Value: "12345"},
+				{Path: "/items/1/category", Rule: domain.RulePattern, Value: "Drinks!"},
+			},
+		},
+		{
+			title: "GivenItemsThatCostTheirQuantityTimesUnitPrice_ReturnNoViolations",
+			receipt: receipt.Receipt{
+				Retailer:     "Target",
+				PurchaseDate: "2022-03-20",
+				PurchaseTime: "14:33",
+				Items: []receipt.Item{
+					{ShortDescription: "Gatorade", Price: "6.00", Quantity: "3", UnitPrice: "2.00"},
+					{ShortDescription: "Pepsi", Price: "3.51", UnitPrice: "3.51"},
+				},
+				Total: "9.51",
+			},
+		},
+		{
+			title: "GivenItemsThatDoNotCostTheirQuantityTimesUnitPrice_ReturnPriceViolations",
+			receipt: receipt.Receipt{
+				Retailer:     "Target",
+				PurchaseDate: "2022-03-20",
+				PurchaseTime: "14:33",
+				Items: []receipt.Item{
+					{ShortDescription: "Gatorade", Price: "6.49", Quantity: "3", UnitPrice: "2.00"},
+					{ShortDescription: "Pepsi", Price: "1.00", Quantity: "999999999", UnitPrice: "92233720368547758.07"},
+				},
+				Total: "7.49",
+			},
+			expectedViolations: []domain.Violation{
+				{Path: "/items/0/price", Rule: domain.RuleSum, Value: "6.49"},
+				{Path: "/items/1/price", Rule: domain.RuleSum, Value: "1.00"},
+			},
+		},
+		{
+			title: "GivenAnEmptyReceipt_ReturnEveryField",
+			receipt: receipt.Receipt{
+				Retailer: "#1",
+			},
+			expectedViolations: []domain.Violation{
+				{Path: "/retailer", Rule: domain.RulePattern, Value: "#1"},
+				{Path: "/purchaseDate", Rule: domain.RuleRequired, Value: ""},
+				{Path: "/purchaseTime", Rule: domain.RuleRequired, Value: ""},
+				{Path: "/items", Rule: domain.RuleMin, Value: "[]"},
+				{Path: "/total", Rule: domain.RuleRequired, Value: ""},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.title, func(t *testing.T) {
+			assert.Equal(t, tc.expectedViolations, tc.receipt.Validate(context.TODO()))
+		})
+	}
+}
@@ -0,0 +1,112 @@
+package receipt
+
+import (
+	"fmt"
+	"math"
+	"math/bits"
+	"strings"
+)
+
+// Money is an amount in cents. Receipt amounts are parsed into Money so that rules compute exactly.
+type Money int64
+
+// ParseMoney parses a decimal amount with up to two decimals, e.g. 12.25, 12.5 or 12.
+// Receipts are validated to always have two decimals.
+func ParseMoney(value string) (Money, error) {
+	units, fraction, hasPoint := strings.Cut(value, ".")
+	if units == "" || len(fraction) > 2 || (hasPoint && fraction == "") {
+		return 0, fmt.Errorf("amount %q must be a decimal with up to two decimals", value)
+	}
+	fraction += strings.Repeat("0", 2-len(fraction))
+
+	var cents Money
+	for _, c := range units + fraction {
+		if c < '0' || c > '9' {
+			return 0, fmt.Errorf("amount %q must only have digits", value)
+		}
+		digit := Money(c - '0')
+		if cents > (math.MaxInt64-digit)/10 {
+			return 0, fmt.Errorf("amount %q is too large", value)
+		}
+		cents = cents*10 + digit
+	}
+	return cents, nil
+}
+
+// MoneyFromFloat rounds a configured amount, e.g. TOTAL_MULTIPLE, to the nearest cent.
+func MoneyFromFloat(value float64) Money {
+	return Money(math.Round(value * 100))
+}
+
+func (m Money) String() string {
+	sign := ""
+	cents := int64(m)
+	if cents < 0 {
+		sign = "-"
+		cents = -cents
+	}
+	return fmt.Sprintf("%s%d.%02d", sign, cents/100, cents%100)
+}
+
+func (m Money) Float64() float64 {
+	return float64(m) / 100
+}
+
+// IsMultipleOf reports whether m is a whole multiple of a positive amount.
+func (m Money) IsMultipleOf(multiple Money) bool {
+	return multiple > 0 && m%multiple == 0
+}
+
+// Ratio is a configured multiplier, e.g. MULT_DESCRIPTION, exact to millionths.
+type Ratio int64
+
+const ratioScale = 1_000_000
+
+func NewRatio(value float64) Ratio {
+	return Ratio(math.Round(value * ratioScale))
+}
+
+func (r Ratio) Float64() float64 {
+	return float64(r) / ratioScale
+}
+
+// MulCeil multiplies m by r and rounds up to a whole currency unit. Results beyond int64 saturate.
+func (m Money) MulCeil(r Ratio) int64 {
+	negative := (m < 0) != (r < 0)
+	hi, lo := bits.Mul64(abs(int64(m)), abs(int64(r)))
+
+	const denominator = 100 * ratioScale
+	if hi >= denominator {
+		return saturate(negative)
+	}
+	quotient, remainder := bits.Div64(hi, lo, denominator)
+	if quotient > math.MaxInt64 {
+		return saturate(negative)
+	}
+
+	if negative {
+		// Rounding up a negative product drops its fraction
+		return -int64(quotient)
+	}
+	if remainder > 0 {
+		if quotient == math.MaxInt64 {
+			return math.MaxInt64
+		}
+		quotient++
+	}
+	return int64(quotient)
+}
+
+func abs(value int64) uint64 {
+	if value < 0 {
+		return uint64(-value)
+	}
+	return uint64(value)
+}
+
+func saturate(negative bool) int64 {
+	if negative {
+		return math.MinInt64
+	}
+	return math.MaxInt64
+}
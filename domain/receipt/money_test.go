@@ -0,0 +1,223 @@
+package receipt_test
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+	"testing"
+	"testing/quick"
+
+	"github.com/kevin07696/receipt-processor/domain/receipt"
+	"github.com/stretchr/testify/assert"
+)
+
+// maxParityCents keeps amounts within the range float64 parsed exactly enough for the float rules.
+const maxParityCents = 100_000_000_000
+
+// The float implementations the money rules replaced. They are the reference for the parity properties.
+func floatDivisibleTotalPoints(total string, multiple float64, multiplier int64) int64 {
+	currency, _ := strconv.ParseFloat(total, 64)
+	if currency == 0 || math.Mod(currency, multiple) > 0 {
+		return 0
+	}
+	return multiplier
+}
+
+func floatDescriptionPoints(price string, multiplier float64) int64 {
+	currency, _ := strconv.ParseFloat(price, 64)
+	return int64(math.Ceil(currency * multiplier))
+}
+
+func floatRoundTotalPoints(total string, multiplier int64) int64 {
+	if total[len(total)-2:] > "00" {
+		return 0
+	}
+	return multiplier
+}
+
+func amount(cents uint64) string {
+	cents %= maxParityCents
+	return fmt.Sprintf("%d.%02d", cents/100, cents%100)
+}
+
+func points(t *testing.T, name string, opts receipt.Options, mults receipt.Multipliers, rcpt receipt.Receipt) int64 {
+	rules, err := receipt.DefaultRules.Build(opts, mults, []string{name})
+	if err != nil {
+		t.Fatalf("Failed to build rule: %v", err)
+	}
+	if violations := rcpt.Validate(context.TODO()); len(violations) > 0 {
+		t.Fatalf("Invalid receipt: %v", violations)
+	}
+	points, _ := rules[0].Points(context.TODO(), rcpt)
+	return points
+}
+
+func validReceipt(total string, prices ...string) receipt.Receipt {
+	rcpt := receipt.Receipt{Retailer: "Target", PurchaseDate: "2022-01-01", PurchaseTime: "13:01", Total: total}
+	for _, price := range prices {
+		rcpt.Items = append(rcpt.Items, receipt.Item{ShortDescription: "cat", Price: price})
+	}
+	return rcpt
+}
+
+func TestMoneyProperties(t *testing.T) {
+	config := &quick.Config{MaxCount: 5000}
+
+	t.Run("GivenAnAmount_ParseItsString", func(t *testing.T) {
+		assert.NoError(t, quick.Check(func(cents int64) bool {
+			if cents < 0 {
+				cents = -(cents + 1)
+			}
+			parsed, err := receipt.ParseMoney(receipt.Money(cents).String())
+			return err == nil && parsed == receipt.Money(cents)
+		}, config))
+	})
+
+	// Multiples that float64 represents exactly score the same as before
+	for _, multiple := range []float64{0.25, 0.5, 1} {
+		t.Run(fmt.Sprintf("GivenTotalMultiple%.2f_MatchFloatDivisibleTotal", multiple), func(t *testing.T) {
+			opts := receipt.Options{TotalMultiple: multiple}
+			mults := receipt.Multipliers{DivisibleTotal: 25}
+			assert.NoError(t, quick.Check(func(cents uint64) bool {
+				total := amount(cents)
+				expected := floatDivisibleTotalPoints(total, multiple, 25)
+				return points(t, receipt.RuleDivisibleTotal, opts, mults, validReceipt(total, "1.00")) == expected
+			}, config))
+		})
+	}
+
+	t.Run("GivenAValidTotal_MatchFloatRoundTotal", func(t *testing.T) {
+		mults := receipt.Multipliers{RoundTotal: 50}
+		assert.NoError(t, quick.Check(func(cents uint64) bool {
+			total := amount(cents)
+			return points(t, receipt.RuleRoundTotal, receipt.Options{}, mults, validReceipt(total, "1.00")) == floatRoundTotalPoints(total, 50)
+		}, config))
+	})
+
+	// The float product only rounds past a whole number when the exact product is one,
+	// where the exact ceiling is that number and the float ceiling may be one more
+	for _, multiplier := range []float64{0.2, 0.25, 0.1, 1.5} {
+		t.Run(fmt.Sprintf("GivenMultiplier%.2f_MatchFloatDescriptionUnlessExactlyWhole", multiplier), func(t *testing.T) {
+			opts := receipt.Options{DescriptionMultiple: 3}
+			mults := receipt.Multipliers{Description: multiplier}
+			exactMultiplier, _ := new(big.Rat).SetString(strconv.FormatFloat(multiplier, 'f', -1, 64))
+
+			assert.NoError(t, quick.Check(func(cents uint64) bool {
+				price := amount(cents)
+				actual := points(t, receipt.RuleDescription, opts, mults, validReceipt("1.00", price))
+				expected := floatDescriptionPoints(price, multiplier)
+
+				product, _ := new(big.Rat).SetString(price)
+				product.Mul(product, exactMultiplier)
+				if !product.IsInt() {
+					return actual == expected
+				}
+				whole := product.Num().Int64()
+				return actual == whole && (expected == whole || expected == whole+1)
+			}, config))
+		})
+	}
+}
+
+func TestMoneyExactness(t *testing.T) {
+	testCases := []struct {
+		title          string
+		rule           string
+		opts           receipt.Options
+		mults          receipt.Multipliers
+		receipt        receipt.Receipt
+		expectedPoints int64
+	}{
+		{
+			title:          "GivenATenCentMultiple_ReturnPoints",
+			rule:           receipt.RuleDivisibleTotal,
+			opts:           receipt.Options{TotalMultiple: 0.1},
+			mults:          receipt.Multipliers{DivisibleTotal: 25},
+			receipt:        validReceipt("0.30", "0.30"),
+			expectedPoints: 25,
+		},
+		{
+			title:          "GivenATenCentNonMultiple_Return0",
+			rule:           receipt.RuleDivisibleTotal,
+			opts:           receipt.Options{TotalMultiple: 0.1},
+			mults:          receipt.Multipliers{DivisibleTotal: 25},
+			receipt:        validReceipt("0.35", "0.35"),
+			expectedPoints: 0,
+		},
+		{
+			title:          "GivenALargeTotalMultiple_ReturnPoints",
+			rule:           receipt.RuleDivisibleTotal,
+			opts:           receipt.Options{TotalMultiple: 0.25},
+			mults:          receipt.Multipliers{DivisibleTotal: 25},
+			receipt:        validReceipt("92233720368547758.00", "1.00"),
+			expectedPoints: 25,
+		},
+		{
+			title:          "GivenALargeTotalNonMultiple_Return0",
+			rule:           receipt.RuleDivisibleTotal,
+			opts:           receipt.Options{TotalMultiple: 0.25},
+			mults:          receipt.Multipliers{DivisibleTotal: 25},
+			receipt:        validReceipt("92233720368547758.01", "1.00"),
+			expectedPoints: 0,
+		},
+		{
+			title:          "GivenAPriceWithAWholeProduct_ReturnTheProduct",
+			rule:           receipt.RuleDescription,
+			opts:           receipt.Options{DescriptionMultiple: 3},
+			mults:          receipt.Multipliers{Description: 0.2},
+			receipt:        validReceipt("15.00", "15.00"),
+			expectedPoints: 3,
+		},
+		{
+			title:          "GivenAPriceWithAFractionalProduct_RoundUp",
+			rule:           receipt.RuleDescription,
+			opts:           receipt.Options{DescriptionMultiple: 3},
+			mults:          receipt.Multipliers{Description: 0.2},
+			receipt:        validReceipt("12.26", "12.26"),
+			expectedPoints: 3,
+		},
+		{
+			title:          "GivenALargePrice_ReturnExactPoints",
+			rule:           receipt.RuleDescription,
+			opts:           receipt.Options{DescriptionMultiple: 3},
+			mults:          receipt.Multipliers{Description: 0.2},
+			receipt:        validReceipt("1.00", "92233720368547758.07"),
+			expectedPoints: 18446744073709552,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.title, func(t *testing.T) {
+			assert.Equal(t, tc.expectedPoints, points(t, tc.rule, tc.opts, tc.mults, tc.receipt))
+		})
+	}
+}
+
+func TestParseMoney(t *testing.T) {
+	testCases := []struct {
+		title          string
+		value          string
+		expectedAmount receipt.Money
+		expectedError  bool
+	}{
+		{title: "GivenTwoDecimals_ReturnCents", value: "12.25", expectedAmount: 1225},
+		{title: "GivenOneDecimal_ReturnCents", value: "12.5", expectedAmount: 1250},
+		{title: "GivenNoDecimals_ReturnCents", value: "12", expectedAmount: 1200},
+		{title: "GivenTheLargestAmount_ReturnCents", value: "92233720368547758.07", expectedAmount: math.MaxInt64},
+		{title: "GivenATooLargeAmount_ReturnError", value: "92233720368547758.08", expectedError: true},
+		{title: "GivenThreeDecimals_ReturnError", value: "12.250", expectedError: true},
+		{title: "GivenATrailingPoint_ReturnError", value: "12.", expectedError: true},
+		{title: "GivenNoUnits_ReturnError", value: ".25", expectedError: true},
+		{title: "GivenASign_ReturnError", value: "-1.00", expectedError: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.title, func(t *testing.T) {
+			amount, err := receipt.ParseMoney(tc.value)
+			assert.Equal(t, tc.expectedError, err != nil)
+			assert.Equal(t, tc.expectedAmount, amount)
+		})
+	}
+}
@@ -0,0 +1,90 @@
+package receipt
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strings"
+)
+
+// RuleRetailerOverride names the breakdown entry of a retailer override.
+const RuleRetailerOverride = "retailer_override"
+
+// MaxFactor bounds the factor that scales points.
+const MaxFactor = 100
+
+// RetailerOverride scores the receipts of matching retailers with its own options and multipliers,
+// then scales their points by Factor and adds Bonus.
+type RetailerOverride struct {
+	Name string
+	// Retailers match a canonical retailer ID or a retailer name ignoring case and punctuation.
+	Retailers []string
+	// Pattern matches the retailer as printed on the receipt.
+	Pattern *regexp.Regexp
+	// Options and Multipliers replace those of the rule set, so they hold every field, not only the overridden ones.
+	Options     Options
+	Multipliers Multipliers
+	// Factor multiplies the points of the rules. Zero keeps them.
+	Factor float64
+	Bonus  int64
+	Rules  []ScoringRule
+}
+
+// Matches reports whether the override applies to a receipt of the retailer resolved to retailerID.
+func (o RetailerOverride) Matches(retailer, retailerID string) bool {
+	if o.Pattern != nil && o.Pattern.MatchString(retailer) {
+		return true
+	}
+	name := NormalizeRetailer(retailer)
+	for _, match := range o.Retailers {
+		if match == retailerID || NormalizeRetailer(match) == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Override returns the first override of the rule set that matches the retailer.
+func (rs RuleSet) Override(retailer, retailerID string) (RetailerOverride, bool) {
+	for _, override := range rs.Overrides {
+		if override.Matches(retailer, retailerID) {
+			return override, true
+		}
+	}
+	return RetailerOverride{}, false
+}
+
+// apply returns the breakdown entry that scales the points of the rules by Factor and adds Bonus.
+func (o RetailerOverride) apply(retailer string, points int64) RuleScore {
+	var extra int64
+	var reasons []string
+	if o.Factor != 0 && o.Factor != 1 {
+		extra = scale(points, o.Factor) - points
+		reasons = append(reasons, fmt.Sprintf("%d points from %.2fx rule points", extra, o.Factor))
+	}
+	if o.Bonus != 0 {
+		reasons = append(reasons, fmt.Sprintf("%d points bonus", o.Bonus))
+	}
+	if len(reasons) == 0 {
+		reasons = append(reasons, "rules scored with the overridden options and multipliers")
+	}
+
+	extra += o.Bonus
+	return RuleScore{
+		Rule:   RuleRetailerOverride,
+		Points: extra,
+		Reason: fmt.Sprintf("%d points - override %s for retailer %s: %s", extra, o.Name, retailer, strings.Join(reasons, ", ")),
+	}
+}
+
+// scale rounds points times factor, saturating instead of overflowing int64.
+func scale(points int64, factor float64) int64 {
+	scaled := math.Round(float64(points) * factor)
+	if scaled >= math.MaxInt64 {
+		return math.MaxInt64
+	}
+	if scaled <= math.MinInt64 {
+		return math.MinInt64
+	}
+	return int64(scaled)
+}
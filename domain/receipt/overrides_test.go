@@ -0,0 +1,152 @@
+package receipt_test
+
+import (
+	"context"
+	"math"
+	"regexp"
+	"testing"
+
+	"github.com/kevin07696/receipt-processor/domain"
+	"github.com/kevin07696/receipt-processor/domain/receipt"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRetailerOverrideMatches(t *testing.T) {
+	override := receipt.RetailerOverride{Retailers: []string{"target", "M&M Corner Market"}, Pattern: regexp.MustCompile(`(?i)^walgreens\b`)}
+
+	testCases := []struct {
+		title      string
+		retailer   string
+		retailerID string
+		expected   bool
+	}{
+		{title: "GivenACanonicalRetailerID_ReturnTrue", retailer: "TARGET 1234", retailerID: "target", expected: true},
+		{title: "GivenANameIgnoringCaseAndPunctuation_ReturnTrue", retailer: "m m corner-market", expected: true},
+		{title: "GivenANameMatchingThePattern_ReturnTrue", retailer: "WALGREENS 0042", expected: true},
+		{title: "GivenAnotherRetailer_ReturnFalse", retailer: "Walmart", retailerID: "walmart", expected: false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.title, func(t *testing.T) {
+			assert.Equal(t, tc.expected, override.Matches(tc.retailer, tc.retailerID))
+		})
+	}
+}
+
+func TestProcessReceiptWithOverrides(t *testing.T) {
+	registry, err := receipt.NewRetailerRegistry(nil, target)
+	assert.NoError(t, err)
+
+	ruleOpts := receipt.Options{Rules: []string{receipt.RuleRetailer, receipt.RuleRoundTotal}, Retailers: registry}
+	ruleMults := receipt.Multipliers{Retailer: 1, RoundTotal: 50}
+	overridden := ruleMults
+	overridden.RoundTotal = 100
+	huge := ruleMults
+	huge.Retailer = 1 << 58
+
+	testCases := []struct {
+		title            string
+		retailer         string
+		overrides        []receipt.RetailerOverride
+		expectedPoints   int64
+		expectedOverride *receipt.RuleScore
+	}{
+		{
+			title:          "GivenNoMatchingOverride_ReturnRulePoints",
+			retailer:       "Walmart",
+			overrides:      []receipt.RetailerOverride{{Name: "partner", Retailers: []string{"target"}, Options: ruleOpts, Multipliers: ruleMults, Factor: 2}},
+			expectedPoints: 57,
+		},
+		{
+			title:          "GivenAFactor_ScaleRulePoints",
+			retailer:       "Target Store",
+			overrides:      []receipt.RetailerOverride{{Name: "partner", Retailers: []string{"target"}, Options: ruleOpts, Multipliers: ruleMults, Factor: 2}},
+			expectedPoints: 122,
+			expectedOverride: &receipt.RuleScore{
+				Rule:   receipt.RuleRetailerOverride,
+				Points: 61,
+				Reason: "61 points - override partner for retailer Target Store: 61 points from 2.00x rule points",
+			},
+		},
+		{
+			title:          "GivenAFactorThatOverflowsThePoints_SaturateThem",
+			retailer:       "Target Store",
+			overrides:      []receipt.RetailerOverride{{Name: "partner", Retailers: []string{"target"}, Options: ruleOpts, Multipliers: huge, Factor: receipt.MaxFactor}},
+			expectedPoints: math.MaxInt64,
+			expectedOverride: &receipt.RuleScore{
+				Rule:   receipt.RuleRetailerOverride,
+				Points: 6052837899185946573,
+				Reason: "6052837899185946573 points - override partner for retailer Target Store: 6052837899185946573 points from 100.00x rule points",
+			},
+		},
+		{
+			title:          "GivenOverriddenMultipliersAndABonus_ReturnTheirPoints",
+			retailer:       "Target",
+			overrides:      []receipt.RetailerOverride{{Name: "flagship", Retailers: []string{"target"}, Options: ruleOpts, Multipliers: overridden, Bonus: 25}},
+			expectedPoints: 131,
+			expectedOverride: &receipt.RuleScore{
+				Rule:   receipt.RuleRetailerOverride,
+				Points: 25,
+				Reason: "25 points - override flagship for retailer Target: 25 points bonus",
+			},
+		},
+		{
+			title:    "GivenTwoMatchingOverrides_ApplyTheFirst",
+			retailer: "Target",
+			overrides: []receipt.RetailerOverride{
+				{Name: "first", Pattern: regexp.MustCompile(`^Tar`), Options: ruleOpts, Multipliers: ruleMults, Bonus: 1},
+				{Name: "second", Retailers: []string{"target"}, Options: ruleOpts, Multipliers: ruleMults, Bonus: 1000},
+			},
+			expectedPoints: 57,
+			expectedOverride: &receipt.RuleScore{
+				Rule:   receipt.RuleRetailerOverride,
+				Points: 1,
+				Reason: "1 points - override first for retailer Target: 1 points bonus",
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.title, func(t *testing.T) {
+			ruleSets, err := receipt.NewRuleSets(receipt.SelectByPurchaseDate,
+				receipt.RuleSet{Version: "v1", Options: ruleOpts, Multipliers: ruleMults, Overrides: tc.overrides},
+			)
+			assert.NoError(t, err)
+			services := receipt.NewReceiptProcessorServiceWithRuleSets(NewMapReceiptRepository(), ruleOpts, ruleSets)
+
+			request := receipt.ReceiptProcessorRequest{ID: "id", Receipt: receipt.Receipt{Retailer: tc.retailer, PurchaseDate: "2022-01-01", Total: "1.00"}}
+			_, status := services.ProcessReceipt(context.TODO(), request)
+			assert.Equal(t, domain.StatusOK, status)
+
+			breakdown, _ := services.GetReceiptBreakdown(context.TODO(), receipt.ReceiptBreakdownRequest{ID: "id"})
+			assert.Equal(t, tc.expectedPoints, breakdown.Points)
+
+			last := breakdown.Breakdown[len(breakdown.Breakdown)-1]
+			if tc.expectedOverride == nil {
+				assert.NotEqual(t, receipt.RuleRetailerOverride, last.Rule)
+				return
+			}
+			assert.Equal(t, *tc.expectedOverride, last)
+		})
+	}
+}
+
+func TestNewRuleSetsWithOverrides(t *testing.T) {
+	testCases := []struct {
+		title    string
+		override receipt.RetailerOverride
+	}{
+		{title: "GivenAnOverrideWithoutName_ReturnError", override: receipt.RetailerOverride{Retailers: []string{"target"}}},
+		{title: "GivenAnOverrideWithoutRetailers_ReturnError", override: receipt.RetailerOverride{Name: "partner"}},
+		{title: "GivenAnOverrideWithAnUnknownRule_ReturnError", override: receipt.RetailerOverride{Name: "partner", Retailers: []string{"target"}, Options: receipt.Options{Rules: []string{"unknown"}}}},
+		{title: "GivenANegativeFactor_ReturnError", override: receipt.RetailerOverride{Name: "partner", Retailers: []string{"target"}, Factor: -1}},
+		{title: "GivenAFactorOverTheMax_ReturnError", override: receipt.RetailerOverride{Name: "partner", Retailers: []string{"target"}, Factor: receipt.MaxFactor + 1}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.title, func(t *testing.T) {
+			_, err := receipt.NewRuleSets(receipt.SelectByPurchaseDate, receipt.RuleSet{Version: "v1", Overrides: []receipt.RetailerOverride{tc.override}})
+			assert.Error(t, err)
+		})
+	}
+}
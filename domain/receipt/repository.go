@@ -2,10 +2,36 @@ package receipt
 
 import (
 	"context"
+	"fmt"
+	"log/slog"
+	"time"
 
 	"github.com/kevin07696/receipt-processor/domain"
 )
 
+// ScoreRecord is stored for each processed receipt so its score can be explained and recomputed later.
+type ScoreRecord struct {
+	Points    int64
+	Breakdown []RuleScore
+	Receipt   Receipt
+	// RuleSetVersion is the version of the rule set that produced the score.
+	RuleSetVersion string
+	// RetailerID is the canonical retailer the registry resolved when the receipt was scored. Empty when it knew none.
+	RetailerID string
+	// Campaigns are the IDs of the campaigns applied when the receipt was scored, ordered by ID.
+	Campaigns []string
+	ScoredAt  time.Time
+	// History holds the scores this score replaced, oldest first.
+	History []ScoreRevision
+}
+
+// ScoreRevision is a previous score of a receipt.
+type ScoreRevision struct {
+	Points         int64
+	RuleSetVersion string
+	ScoredAt       time.Time
+}
+
 type ReceiptProcessorRepository struct {
 	cache IRepository
 }
@@ -16,15 +42,24 @@ func NewReceiptProcessorRepository(cache IRepository) *ReceiptProcessorRepositor
 	}
 }
 
-func (r *ReceiptProcessorRepository) WriteReceiptScore(ctx context.Context, id string, points int64) domain.StatusCode {
-	return r.cache.Set(ctx, id, points)
+func (r *ReceiptProcessorRepository) WriteReceiptScore(ctx context.Context, id string, record ScoreRecord) domain.StatusCode {
+	return r.cache.Set(ctx, id, record)
 }
 
-func (r ReceiptProcessorRepository) ReadReceiptScore(ctx context.Context, id string) (int64, domain.StatusCode) {
-	score, status := r.cache.Get(ctx, id)
+func (r ReceiptProcessorRepository) ReadReceiptScore(ctx context.Context, id string) (ScoreRecord, domain.StatusCode) {
+	record, status := r.cache.Get(ctx, id)
 	if status > 0 {
-		return 0, status
+		return ScoreRecord{}, status
 	}
 
-	return score.(int64), domain.StatusOK
+	return record.(ScoreRecord), domain.StatusOK
+}
+
+func (r ReceiptProcessorRepository) ListReceiptIDs(ctx context.Context) ([]string, domain.StatusCode) {
+	lister, ok := r.cache.(IKeyLister)
+	if !ok {
+		slog.ErrorContext(ctx, "List Error: Repository can not list receipts.", slog.String("repository", fmt.Sprintf("%T", r.cache)))
+		return nil, domain.ErrInternal
+	}
+	return lister.Keys(ctx)
 }
@@ -0,0 +1,159 @@
+package receipt
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/kevin07696/receipt-processor/domain"
+)
+
+type RescoreState string
+
+const (
+	RescoreIdle      RescoreState = "idle"
+	RescoreRunning   RescoreState = "running"
+	RescoreCompleted RescoreState = "completed"
+	RescoreCancelled RescoreState = "cancelled"
+	RescoreFailed    RescoreState = "failed"
+)
+
+// RescoreStatus is the progress of the latest re-score job.
+type RescoreStatus struct {
+	State      RescoreState
+	Version    string
+	Total      int
+	Done       int
+	Failed     int
+	StartedAt  time.Time
+	FinishedAt time.Time
+}
+
+// IRescoreService scores stored receipts again.
+type IRescoreService interface {
+	HasRuleSetVersion(version string) bool
+	ListReceiptIDs(ctx context.Context) ([]string, domain.StatusCode)
+	RescoreReceipt(ctx context.Context, id string, version string) domain.StatusCode
+}
+
+// Rescorer runs one re-score job at a time in the background.
+// A job scores every stored receipt again with a rule set version until it is done or cancelled.
+type Rescorer struct {
+	service IRescoreService
+	mu      sync.Mutex
+	status  RescoreStatus
+	cancel  context.CancelFunc
+	done    chan struct{}
+}
+
+func NewRescorer(service IRescoreService) *Rescorer {
+	return &Rescorer{
+		service: service,
+		status:  RescoreStatus{State: RescoreIdle},
+	}
+}
+
+// Start begins re-scoring every stored receipt with the rule set version. An unknown version is ErrBadRequest.
+func (r *Rescorer) Start(version string) (RescoreStatus, domain.StatusCode) {
+	if !r.service.HasRuleSetVersion(version) {
+		slog.Debug("StatusBadRequest: unknown rule set version", slog.String("version", version))
+		return r.Status(), domain.ErrBadRequest
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.status.State == RescoreRunning {
+		return r.status, domain.ErrConflict
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	r.cancel = cancel
+	r.done = make(chan struct{})
+	r.status = RescoreStatus{State: RescoreRunning, Version: version, StartedAt: time.Now()}
+	go r.run(ctx, version, r.done)
+
+	return r.status, domain.StatusOK
+}
+
+func (r *Rescorer) run(ctx context.Context, version string, done chan struct{}) {
+	defer close(done)
+
+	ids, status := r.service.ListReceiptIDs(ctx)
+	if status > 0 {
+		slog.Error("Rescore Error: Failed to list receipts.", slog.String("version", version))
+		r.finish(RescoreFailed)
+		return
+	}
+	r.update(func(s *RescoreStatus) { s.Total = len(ids) })
+	slog.Info(fmt.Sprintf("Rescoring %d receipts", len(ids)), slog.String("ruleSet", version))
+
+	for _, id := range ids {
+		if ctx.Err() != nil {
+			r.finish(RescoreCancelled)
+			return
+		}
+
+		status := r.service.RescoreReceipt(ctx, id, version)
+		if status > 0 {
+			slog.Error("Rescore Error: Failed to rescore receipt.", slog.String("id", id), slog.String("status", domain.ErrorToCodes[status].Name))
+		}
+		r.update(func(s *RescoreStatus) {
+			s.Done++
+			if status > 0 {
+				s.Failed++
+			}
+		})
+	}
+	r.finish(RescoreCompleted)
+}
+
+func (r *Rescorer) update(fn func(s *RescoreStatus)) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	fn(&r.status)
+}
+
+func (r *Rescorer) finish(state RescoreState) {
+	r.update(func(s *RescoreStatus) {
+		s.State = state
+		s.FinishedAt = time.Now()
+	})
+	slog.Info(fmt.Sprintf("Rescore %s", state), slog.String("ruleSet", r.Status().Version))
+}
+
+func (r *Rescorer) Status() RescoreStatus {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.status
+}
+
+// Cancel stops a running job after the receipt it is scoring and waits for it to stop.
+func (r *Rescorer) Cancel() RescoreStatus {
+	r.mu.Lock()
+	cancel, done := r.cancel, r.done
+	r.mu.Unlock()
+
+	if cancel != nil {
+		cancel()
+		<-done
+	}
+	return r.Status()
+}
+
+// Close cancels a running job. It is meant to run on shutdown, before the repository closes.
+func (r *Rescorer) Close(ctx context.Context) error {
+	stopped := make(chan struct{})
+	go func() {
+		r.Cancel()
+		close(stopped)
+	}()
+
+	select {
+	case <-stopped:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,173 @@
+package receipt_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/kevin07696/receipt-processor/domain"
+	"github.com/kevin07696/receipt-processor/domain/receipt"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRescoreReceipt(t *testing.T) {
+	ctx := context.TODO()
+	opts := receipt.Options{GenerateID: func(input string) string { return input }}
+	ruleSets, err := receipt.NewRuleSets(receipt.SelectByProcessedAt,
+		receipt.RuleSet{Version: "v1", Options: receipt.Options{Rules: []string{receipt.RuleRetailer}}, Multipliers: receipt.Multipliers{Retailer: 1}},
+		receipt.RuleSet{Version: "v2", EffectiveFrom: date("2999-01-01"), Options: receipt.Options{Rules: []string{receipt.RuleRetailer}}, Multipliers: receipt.Multipliers{Retailer: 3}},
+	)
+	assert.NoError(t, err)
+
+	testCases := []struct {
+		title           string
+		id              string
+		versions        []string
+		expectedStatus  domain.StatusCode
+		expectedPoints  int64
+		expectedVersion string
+		expectedHistory []string
+	}{
+		{
+			title:           "GivenANewVersion_RescoreAndKeepHistory",
+			id:              "target",
+			versions:        []string{"v2"},
+			expectedPoints:  18,
+			expectedVersion: "v2",
+			expectedHistory: []string{"v1"},
+		},
+		{
+			title:           "GivenSeveralRescores_KeepEveryRevision",
+			id:              "target",
+			versions:        []string{"v2", "v1"},
+			expectedPoints:  6,
+			expectedVersion: "v1",
+			expectedHistory: []string{"v1", "v2"},
+		},
+		{
+			title:           "GivenAnUnknownVersion_ReturnBadRequestError",
+			id:              "target",
+			versions:        []string{"v3"},
+			expectedStatus:  domain.ErrBadRequest,
+			expectedPoints:  6,
+			expectedVersion: "v1",
+		},
+		{
+			title:          "GivenAScoreWithoutReceipt_ReturnInternalError",
+			id:             "legacy",
+			versions:       []string{"v2"},
+			expectedStatus: domain.ErrInternal,
+			expectedPoints: 28,
+		},
+		{
+			title:          "GivenAnUnknownID_ReturnNotFoundError",
+			id:             "unknown",
+			versions:       []string{"v2"},
+			expectedStatus: domain.ErrNotFound,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.title, func(t *testing.T) {
+			repository := NewMapReceiptRepository()
+			services := receipt.NewReceiptProcessorServiceWithRuleSets(repository, opts, ruleSets)
+			services.ProcessReceipt(ctx, receipt.ReceiptProcessorRequest{ID: "target", Receipt: receipt.Receipt{Retailer: "Target", PurchaseDate: "2022-01-01"}})
+			// Stored before receipts were kept with their score
+			repository.Scores["legacy"] = receipt.ScoreRecord{Points: 28}
+
+			var status domain.StatusCode
+			for _, version := range tc.versions {
+				status = services.RescoreReceipt(ctx, tc.id, version)
+			}
+			assert.Equal(t, tc.expectedStatus, status)
+
+			record := repository.Scores[tc.id]
+			assert.Equal(t, tc.expectedPoints, record.Points)
+			assert.Equal(t, tc.expectedVersion, record.RuleSetVersion)
+
+			var history []string
+			for _, revision := range record.History {
+				history = append(history, revision.RuleSetVersion)
+				assert.False(t, revision.ScoredAt.IsZero())
+			}
+			assert.Equal(t, tc.expectedHistory, history)
+		})
+	}
+}
+
+func TestRescorer(t *testing.T) {
+	testCases := []struct {
+		title          string
+		version        string
+		fail           map[string]bool
+		expectedStatus domain.StatusCode
+		expectedState  receipt.RescoreState
+		expectedDone   int
+		expectedFailed int
+	}{
+		{
+			title:         "GivenAKnownVersion_RescoreEveryReceipt",
+			version:       "v2",
+			expectedState: receipt.RescoreCompleted,
+			expectedDone:  3,
+		},
+		{
+			title:          "GivenAFailingReceipt_CountFailureAndContinue",
+			version:        "v2",
+			fail:           map[string]bool{"b": true},
+			expectedState:  receipt.RescoreCompleted,
+			expectedDone:   3,
+			expectedFailed: 1,
+		},
+		{
+			title:          "GivenAnUnknownVersion_ReturnBadRequestError",
+			version:        "v3",
+			expectedStatus: domain.ErrBadRequest,
+			expectedState:  receipt.RescoreIdle,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.title, func(t *testing.T) {
+			service := &MockRescoreService{IDs: []string{"a", "b", "c"}, Versions: []string{"v1", "v2"}, Fail: tc.fail}
+			rescorer := receipt.NewRescorer(service)
+
+			_, status := rescorer.Start(tc.version)
+			assert.Equal(t, tc.expectedStatus, status)
+
+			assert.Eventually(t, func() bool {
+				return rescorer.Status().State != receipt.RescoreRunning
+			}, time.Second, time.Millisecond)
+
+			progress := rescorer.Status()
+			assert.Equal(t, tc.expectedState, progress.State)
+			assert.Equal(t, tc.expectedDone, progress.Done)
+			assert.Equal(t, tc.expectedFailed, progress.Failed)
+		})
+	}
+}
+
+func TestRescorerConflictAndCancel(t *testing.T) {
+	service := &MockRescoreService{IDs: []string{"a", "b", "c"}, Versions: []string{"v2"}, Started: make(chan string, 3), Release: make(chan struct{})}
+	rescorer := receipt.NewRescorer(service)
+
+	progress, status := rescorer.Start("v2")
+	assert.Equal(t, domain.StatusOK, status)
+	assert.Equal(t, receipt.RescoreRunning, progress.State)
+
+	_, status = rescorer.Start("v2")
+	assert.Equal(t, domain.ErrConflict, status)
+
+	// The job stops after the receipt it is scoring
+	assert.Equal(t, "a", <-service.Started)
+	progress = rescorer.Cancel()
+	assert.Equal(t, receipt.RescoreCancelled, progress.State)
+	assert.Equal(t, 3, progress.Total)
+	assert.Equal(t, 1, progress.Done)
+	assert.False(t, progress.FinishedAt.IsZero())
+
+	close(service.Release)
+	_, status = rescorer.Start("v2")
+	assert.Equal(t, domain.StatusOK, status)
+	assert.NoError(t, rescorer.Close(context.TODO()))
+}
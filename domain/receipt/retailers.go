@@ -0,0 +1,231 @@
+package receipt
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+	"unicode"
+
+	"github.com/kevin07696/receipt-processor/domain"
+)
+
+// Retailer is a canonical retailer and the names receipts print for it.
+type Retailer struct {
+	ID      string
+	Name    string
+	Aliases []string
+}
+
+// RetailerName decides which retailer name the scoring rules see.
+type RetailerName string
+
+const (
+	// RetailerNameRaw scores the retailer as printed on the receipt.
+	RetailerNameRaw RetailerName = "raw"
+	// RetailerNameCanonical scores the name of the canonical retailer, when the registry knows one.
+	RetailerNameCanonical RetailerName = "canonical"
+)
+
+// Valid reports whether n is a known retailer name. The empty name scores the raw retailer.
+func (n RetailerName) Valid() bool {
+	return n == "" || n == RetailerNameRaw || n == RetailerNameCanonical
+}
+
+// IRetailerStore persists the retailers of a RetailerRegistry after each change.
+type IRetailerStore interface {
+	SaveRetailers(retailers []Retailer) error
+}
+
+var (
+	retailerIDPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9\-_]*$`)
+	// storeNumberPattern matches store number suffixes, e.g. "#1234", "store 0042" or "no. 7"
+	storeNumberPattern = regexp.MustCompile(`\s*(\bno\.?|\bstore|\bstr)?\s*#?\s*\d+\s*$`)
+)
+
+// RetailerRegistry maps retailer names and aliases to canonical retailers. It is safe for concurrent use.
+type RetailerRegistry struct {
+	mu        sync.RWMutex
+	retailers map[string]Retailer
+	// names maps normalized names and aliases to retailer IDs
+	names map[string]string
+	store IRetailerStore
+}
+
+// NewRetailerRegistry holds the retailers and saves changes to store. A nil store keeps changes in memory.
+func NewRetailerRegistry(store IRetailerStore, retailers ...Retailer) (*RetailerRegistry, error) {
+	r := &RetailerRegistry{retailers: map[string]Retailer{}, names: map[string]string{}, store: store}
+	for i, retailer := range retailers {
+		if violations := r.check(retailer); len(violations) > 0 {
+			return nil, fmt.Errorf("retailer %d: %s %s %s", i, violations[0].Path, violations[0].Rule, violations[0].Value)
+		}
+		r.add(retailer)
+	}
+	return r, nil
+}
+
+// NormalizeRetailer lowercases a retailer name and reduces everything but letters and digits to single spaces.
+func NormalizeRetailer(name string) string {
+	fields := strings.FieldsFunc(strings.ToLower(name), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	return strings.Join(fields, " ")
+}
+
+// Resolve returns the retailer known by name. Names that are not known are tried again without their store number.
+// A nil registry knows no retailers.
+func (r *RetailerRegistry) Resolve(name string) (Retailer, bool) {
+	if r == nil {
+		return Retailer{}, false
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	id, ok := r.names[NormalizeRetailer(name)]
+	if !ok {
+		id, ok = r.names[NormalizeRetailer(storeNumberPattern.ReplaceAllString(strings.ToLower(name), ""))]
+	}
+	if !ok {
+		return Retailer{}, false
+	}
+	return r.retailers[id], true
+}
+
+// List returns every retailer ordered by ID.
+func (r *RetailerRegistry) List() []Retailer {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.list()
+}
+
+func (r *RetailerRegistry) Get(id string) (Retailer, domain.StatusCode) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	retailer, ok := r.retailers[id]
+	if !ok {
+		return Retailer{}, domain.ErrNotFound
+	}
+	return retailer, domain.StatusOK
+}
+
+// Put adds the retailer or replaces the retailer with its ID. Names already used by another retailer are violations.
+func (r *RetailerRegistry) Put(ctx context.Context, retailer Retailer) ([]domain.Violation, domain.StatusCode) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	previous, existed := r.retailers[retailer.ID]
+	r.remove(retailer.ID)
+	if violations := r.check(retailer); len(violations) > 0 {
+		if existed {
+			r.add(previous)
+		}
+		slog.DebugContext(ctx, "Retailer failed validation", slog.Any("RetailerInvalidMsgs", violations))
+		return violations, domain.ErrBadRequest
+	}
+	r.add(retailer)
+
+	if status := r.save(ctx); status > 0 {
+		r.remove(retailer.ID)
+		if existed {
+			r.add(previous)
+		}
+		return nil, status
+	}
+	return nil, domain.StatusOK
+}
+
+func (r *RetailerRegistry) Delete(ctx context.Context, id string) domain.StatusCode {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	previous, ok := r.retailers[id]
+	if !ok {
+		return domain.ErrNotFound
+	}
+	r.remove(id)
+
+	if status := r.save(ctx); status > 0 {
+		r.add(previous)
+		return status
+	}
+	return domain.StatusOK
+}
+
+// check returns the fields of the retailer that are invalid or whose names another retailer uses.
+func (r *RetailerRegistry) check(retailer Retailer) []domain.Violation {
+	var violations []domain.Violation
+	switch {
+	case retailer.ID == "":
+		violations = append(violations, domain.Violation{Path: "/ID", Rule: domain.RuleRequired, Value: retailer.ID})
+	case !match(retailerIDPattern, retailer.ID):
+		violations = append(violations, domain.Violation{Path: "/ID", Rule: domain.RulePattern, Value: retailer.ID})
+	}
+
+	seen := map[string]bool{}
+	name := func(path, value string) {
+		key := NormalizeRetailer(value)
+		switch {
+		case value == "":
+			violations = append(violations, domain.Violation{Path: path, Rule: domain.RuleRequired, Value: value})
+		case !match(retailerPattern, value) || key == "":
+			violations = append(violations, domain.Violation{Path: path, Rule: domain.RulePattern, Value: value})
+		case seen[key]:
+		case r.names[key] != "" && r.names[key] != retailer.ID:
+			violations = append(violations, domain.Violation{Path: path, Rule: domain.RuleUnique, Value: value})
+		}
+		seen[key] = true
+	}
+
+	name("/Name", retailer.Name)
+	for i, alias := range retailer.Aliases {
+		name(fmt.Sprintf("/Aliases/%d", i), alias)
+	}
+	return violations
+}
+
+func (r *RetailerRegistry) add(retailer Retailer) {
+	r.retailers[retailer.ID] = retailer
+	r.names[NormalizeRetailer(retailer.Name)] = retailer.ID
+	for _, alias := range retailer.Aliases {
+		r.names[NormalizeRetailer(alias)] = retailer.ID
+	}
+}
+
+func (r *RetailerRegistry) remove(id string) {
+	retailer, ok := r.retailers[id]
+	if !ok {
+		return
+	}
+	delete(r.retailers, id)
+	for _, name := range append([]string{retailer.Name}, retailer.Aliases...) {
+		if key := NormalizeRetailer(name); r.names[key] == id {
+			delete(r.names, key)
+		}
+	}
+}
+
+func (r *RetailerRegistry) list() []Retailer {
+	retailers := make([]Retailer, 0, len(r.retailers))
+	for _, retailer := range r.retailers {
+		retailers = append(retailers, retailer)
+	}
+	slices.SortFunc(retailers, func(a, b Retailer) int {
+		return strings.Compare(a.ID, b.ID)
+	})
+	return retailers
+}
+
+func (r *RetailerRegistry) save(ctx context.Context) domain.StatusCode {
+	if r.store == nil {
+		return domain.StatusOK
+	}
+	if err := r.store.SaveRetailers(r.list()); err != nil {
+		slog.ErrorContext(ctx, "Failed to save retailers.", slog.Any("error", err))
+		return domain.ErrInternal
+	}
+	return domain.StatusOK
+}
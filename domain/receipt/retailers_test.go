@@ -0,0 +1,187 @@
+package receipt_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/kevin07696/receipt-processor/domain"
+	"github.com/kevin07696/receipt-processor/domain/receipt"
+	"github.com/stretchr/testify/assert"
+)
+
+var target = receipt.Retailer{ID: "target", Name: "Target", Aliases: []string{"Target Store", "Super Target"}}
+
+func TestRetailerRegistryResolve(t *testing.T) {
+	registry, err := receipt.NewRetailerRegistry(nil, target, receipt.Retailer{ID: "store-24", Name: "Store 24"})
+	assert.NoError(t, err)
+
+	testCases := []struct {
+		title      string
+		name       string
+		expectedID string
+	}{
+		{title: "GivenTheName_ReturnRetailer", name: "Target", expectedID: "target"},
+		{title: "GivenADifferentCase_ReturnRetailer", name: "TARGET", expectedID: "target"},
+		{title: "GivenAnAlias_ReturnRetailer", name: "target  store", expectedID: "target"},
+		{title: "GivenAStoreNumber_ReturnRetailer", name: "TARGET #1234", expectedID: "target"},
+		{title: "GivenAnAliasAndStoreNumber_ReturnRetailer", name: "Super Target Store 0042", expectedID: "target"},
+		{title: "GivenANameEndingInDigits_MatchItBeforeStrippingThem", name: "Store 24", expectedID: "store-24"},
+		{title: "GivenAnUnknownName_ReturnNoRetailer", name: "Walmart #12", expectedID: ""},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.title, func(t *testing.T) {
+			retailer, ok := registry.Resolve(tc.name)
+			assert.Equal(t, tc.expectedID != "", ok)
+			assert.Equal(t, tc.expectedID, retailer.ID)
+		})
+	}
+}
+
+func TestRetailerRegistryPut(t *testing.T) {
+	testCases := []struct {
+		title              string
+		retailer           receipt.Retailer
+		fail               bool
+		expectedStatus     domain.StatusCode
+		expectedViolations []domain.Violation
+		expectedIDs        []string
+	}{
+		{
+			title:          "GivenANewRetailer_AddIt",
+			retailer:       receipt.Retailer{ID: "walmart", Name: "Walmart", Aliases: []string{"Wal-Mart"}},
+			expectedStatus: domain.StatusOK,
+			expectedIDs:    []string{"target", "walmart"},
+		},
+		{
+			title:          "GivenAnExistingRetailer_ReplaceItsNames",
+			retailer:       receipt.Retailer{ID: "target", Name: "Target", Aliases: []string{"Target Express"}},
+			expectedStatus: domain.StatusOK,
+			expectedIDs:    []string{"target"},
+		},
+		{
+			title:          "GivenAnAliasOfAnotherRetailer_ReturnUniqueViolation",
+			retailer:       receipt.Retailer{ID: "supertarget", Name: "SUPER TARGET"},
+			expectedStatus: domain.ErrBadRequest,
+			expectedViolations: []domain.Violation{
+				{Path: "/Name", Rule: domain.RuleUnique, Value: "SUPER TARGET"},
+			},
+			expectedIDs: []string{"target"},
+		},
+		{
+			title:          "GivenAnInvalidRetailer_ReturnViolations",
+			retailer:       receipt.Retailer{ID: "Wal Mart", Aliases: []string{"#1"}},
+			expectedStatus: domain.ErrBadRequest,
+			expectedViolations: []domain.Violation{
+				{Path: "/ID", Rule: domain.RulePattern, Value: "Wal Mart"},
+				{Path: "/Name", Rule: domain.RuleRequired, Value: ""},
+				{Path: "/Aliases/0", Rule: domain.RulePattern, Value: "#1"},
+			},
+			expectedIDs: []string{"target"},
+		},
+		{
+			title:          "GivenAFailedSave_KeepThePreviousRetailers",
+			retailer:       receipt.Retailer{ID: "walmart", Name: "Walmart"},
+			fail:           true,
+			expectedStatus: domain.ErrInternal,
+			expectedIDs:    []string{"target"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.title, func(t *testing.T) {
+			store := &MockRetailerStore{Fail: tc.fail}
+			registry, err := receipt.NewRetailerRegistry(store, target)
+			assert.NoError(t, err)
+
+			violations, status := registry.Put(context.TODO(), tc.retailer)
+			assert.Equal(t, tc.expectedStatus, status)
+			assert.Equal(t, tc.expectedViolations, violations)
+
+			var ids []string
+			for _, retailer := range registry.List() {
+				ids = append(ids, retailer.ID)
+			}
+			assert.Equal(t, tc.expectedIDs, ids)
+			if status == domain.StatusOK {
+				assert.Equal(t, [][]receipt.Retailer{registry.List()}, store.Saved)
+			}
+
+			// "Target Store" only stops resolving when the retailer that replaced target dropped it
+			_, ok := registry.Resolve("Target Store")
+			assert.Equal(t, tc.retailer.ID != "target" || status > 0, ok)
+		})
+	}
+}
+
+func TestRetailerRegistryDelete(t *testing.T) {
+	store := &MockRetailerStore{}
+	registry, err := receipt.NewRetailerRegistry(store, target)
+	assert.NoError(t, err)
+
+	store.Fail = true
+	assert.Equal(t, domain.ErrInternal, registry.Delete(context.TODO(), "target"))
+	_, ok := registry.Resolve("Target")
+	assert.True(t, ok)
+
+	store.Fail = false
+	assert.Equal(t, domain.StatusOK, registry.Delete(context.TODO(), "target"))
+	assert.Equal(t, domain.ErrNotFound, registry.Delete(context.TODO(), "target"))
+	_, ok = registry.Resolve("Target")
+	assert.False(t, ok)
+	assert.Equal(t, [][]receipt.Retailer{{}}, store.Saved)
+}
+
+func TestNewRetailerRegistry(t *testing.T) {
+	_, err := receipt.NewRetailerRegistry(nil, target, receipt.Retailer{ID: "other", Name: "Target Store"})
+	assert.Error(t, err)
+}
+
+func TestProcessReceiptWithRetailers(t *testing.T) {
+	registry, err := receipt.NewRetailerRegistry(nil, target)
+	assert.NoError(t, err)
+
+	testCases := []struct {
+		title              string
+		retailer           string
+		retailerName       receipt.RetailerName
+		expectedPoints     int64
+		expectedRetailerID string
+	}{
+		{
+			title:              "GivenRawNames_ScoreThePrintedName",
+			retailer:           "TARGET 1234",
+			retailerName:       receipt.RetailerNameRaw,
+			expectedPoints:     10,
+			expectedRetailerID: "target",
+		},
+		{
+			title:              "GivenCanonicalNames_ScoreTheCanonicalName",
+			retailer:           "TARGET 1234",
+			retailerName:       receipt.RetailerNameCanonical,
+			expectedPoints:     6,
+			expectedRetailerID: "target",
+		},
+		{
+			title:          "GivenCanonicalNamesAndAnUnknownRetailer_ScoreThePrintedName",
+			retailer:       "Walmart 12",
+			retailerName:   receipt.RetailerNameCanonical,
+			expectedPoints: 9,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.title, func(t *testing.T) {
+			opts := receipt.Options{Rules: []string{receipt.RuleRetailer}, RetailerName: tc.retailerName, Retailers: registry}
+			services := receipt.NewReceiptProcessorService(NewMapReceiptRepository(), opts, receipt.Multipliers{Retailer: 1})
+
+			request := receipt.ReceiptProcessorRequest{ID: "id", Receipt: receipt.Receipt{Retailer: tc.retailer, PurchaseDate: "2022-01-01", Total: "1.00"}}
+			_, status := services.ProcessReceipt(context.TODO(), request)
+			assert.Equal(t, domain.StatusOK, status)
+
+			breakdown, _ := services.GetReceiptBreakdown(context.TODO(), receipt.ReceiptBreakdownRequest{ID: "id"})
+			assert.Equal(t, tc.expectedPoints, breakdown.Points)
+			assert.Equal(t, tc.expectedRetailerID, breakdown.RetailerID)
+		})
+	}
+}
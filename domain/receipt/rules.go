@@ -0,0 +1,377 @@
+package receipt
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Names of the default scoring rules. They are used to enable rules through config.
+const (
+	RuleRetailer       = "retailer"
+	RuleItems          = "items"
+	RuleRoundTotal     = "round_total"
+	RuleDivisibleTotal = "divisible_total"
+	RulePurchaseDate   = "purchase_date"
+	RulePurchaseTime   = "purchase_time"
+	RuleDescription    = "description"
+	RuleCoupon         = "coupon"
+)
+
+// ScoringRule awards points for a single property of a receipt and explains why.
+type ScoringRule interface {
+	Name() string
+	Points(ctx context.Context, receipt Receipt) (int64, string)
+}
+
+// RuleScore is the points a rule awarded to a receipt and the reason for them.
+type RuleScore struct {
+	Rule   string
+	Points int64
+	Reason string
+	// Capped is the points a cap took off the entry.
+	Capped int64 `json:",omitempty"`
+}
+
+// RuleFactory builds a rule from the configured options and multipliers.
+type RuleFactory func(opts Options, mults Multipliers) ScoringRule
+
+// RuleRegistry keeps the rule factories by name in registration order.
+type RuleRegistry struct {
+	names     []string
+	factories map[string]RuleFactory
+	optIn     map[string]bool
+}
+
+func NewRuleRegistry() *RuleRegistry {
+	return &RuleRegistry{
+		factories: map[string]RuleFactory{},
+		optIn:     map[string]bool{},
+	}
+}
+
+// Register adds a rule factory. Registering the same name twice replaces the factory but keeps its order.
+func (r *RuleRegistry) Register(name string, factory RuleFactory) {
+	if _, ok := r.factories[name]; !ok {
+		r.names = append(r.names, name)
+	}
+	r.factories[name] = factory
+	delete(r.optIn, name)
+}
+
+// RegisterOptIn adds a rule factory that only runs when it is enabled by name.
+func (r *RuleRegistry) RegisterOptIn(name string, factory RuleFactory) {
+	r.Register(name, factory)
+	r.optIn[name] = true
+}
+
+func (r RuleRegistry) Has(name string) bool {
+	_, ok := r.factories[name]
+	return ok
+}
+
+// Names returns every registered rule, including opt-in rules.
+func (r RuleRegistry) Names() []string {
+	names := make([]string, len(r.names))
+	copy(names, r.names)
+	return names
+}
+
+// Defaults returns the rules that an empty list enables, which are the registered rules that are not opt-in.
+func (r RuleRegistry) Defaults() []string {
+	names := make([]string, 0, len(r.names))
+	for _, name := range r.names {
+		if !r.optIn[name] {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// Build creates the enabled rules in the given order. An empty list enables every default rule.
+func (r RuleRegistry) Build(opts Options, mults Multipliers, enabled []string) ([]ScoringRule, error) {
+	if len(enabled) == 0 {
+		enabled = r.Defaults()
+	}
+
+	rules := make([]ScoringRule, 0, len(enabled))
+	for _, name := range enabled {
+		factory, ok := r.factories[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown scoring rule %q", name)
+		}
+		rules = append(rules, factory(opts, mults))
+	}
+
+	return rules, nil
+}
+
+// DefaultRules holds the rules of the receipt processor challenge.
+var DefaultRules = NewDefaultRuleRegistry()
+
+func NewDefaultRuleRegistry() *RuleRegistry {
+	registry := NewRuleRegistry()
+	registry.Register(RuleRetailer, func(opts Options, mults Multipliers) ScoringRule {
+		return retailerRule{multiplier: mults.Retailer}
+	})
+	registry.Register(RuleItems, func(opts Options, mults Multipliers) ScoringRule {
+		return itemsRule{multiple: opts.ItemsMultiple, multiplier: mults.Items, count: opts.ItemsCount}
+	})
+	registry.Register(RuleRoundTotal, func(opts Options, mults Multipliers) ScoringRule {
+		return roundTotalRule{multiplier: mults.RoundTotal}
+	})
+	registry.Register(RuleDivisibleTotal, func(opts Options, mults Multipliers) ScoringRule {
+		return divisibleTotalRule{multiple: MoneyFromFloat(opts.TotalMultiple), multiplier: mults.DivisibleTotal}
+	})
+	registry.Register(RulePurchaseDate, func(opts Options, mults Multipliers) ScoringRule {
+		return purchaseDateRule{multiplier: mults.PurchaseDate}
+	})
+	registry.Register(RulePurchaseTime, func(opts Options, mults Multipliers) ScoringRule {
+		return purchaseTimeRule{start: opts.StartPurchaseTime, end: opts.EndPurchaseTime, multiplier: mults.PurchaseTime}
+	})
+	registry.Register(RuleDescription, func(opts Options, mults Multipliers) ScoringRule {
+		return descriptionRule{multiple: opts.DescriptionMultiple, multiplier: NewRatio(mults.Description)}
+	})
+	registry.RegisterOptIn(RuleCoupon, func(opts Options, mults Multipliers) ScoringRule {
+		return couponRule{multiplier: mults.Coupon}
+	})
+	return registry
+}
+
+type retailerRule struct {
+	multiplier int64
+}
+
+func (rule retailerRule) Name() string {
+	return RuleRetailer
+}
+
+func (rule retailerRule) Points(ctx context.Context, receipt Receipt) (int64, string) {
+	name := receipt.Retailer
+
+	var alphaNums int64
+	var buf bytes.Buffer
+	for _, r := range name {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			alphaNums++
+		} else {
+			buf.WriteRune(r)
+		}
+	}
+
+	points := alphaNums * rule.multiplier
+
+	if buf.Len() > 0 {
+		return points, fmt.Sprintf("%d points - retailer name %s has %d alphanumeric characters note: '&' is not alphanumeric", points, name, alphaNums)
+	}
+	return points, fmt.Sprintf("%d points - retailer name has %d characters", points, alphaNums)
+}
+
+type roundTotalRule struct {
+	multiplier int64
+}
+
+func (rule roundTotalRule) Name() string {
+	return RuleRoundTotal
+}
+
+func (rule roundTotalRule) Points(ctx context.Context, receipt Receipt) (int64, string) {
+	if !receipt.TotalAmount().IsMultipleOf(100) {
+		return 0, fmt.Sprintf("0 points - total %s is not a round dollar amount", receipt.Total)
+	}
+
+	return rule.multiplier, fmt.Sprintf("%d points - total is a round dollar amount", rule.multiplier)
+}
+
+type divisibleTotalRule struct {
+	multiple   Money
+	multiplier int64
+}
+
+func (rule divisibleTotalRule) Name() string {
+	return RuleDivisibleTotal
+}
+
+func (rule divisibleTotalRule) Points(ctx context.Context, receipt Receipt) (int64, string) {
+	total := receipt.TotalAmount()
+	if total == 0 {
+		return 0, "0 points - total is zero"
+	}
+
+	if !total.IsMultipleOf(rule.multiple) {
+		return 0, fmt.Sprintf("0 points - total %s is not a multiple of %s", receipt.Total, rule.multiple)
+	}
+
+	return rule.multiplier, fmt.Sprintf("%d points - total is a multiple of %s", rule.multiplier, rule.multiple)
+}
+
+// ItemCount is what the items rule counts.
+type ItemCount string
+
+const (
+	// ItemCountLines counts each item once, whatever its quantity.
+	ItemCountLines ItemCount = "lines"
+	// ItemCountUnits counts the quantity of each item.
+	ItemCountUnits ItemCount = "units"
+)
+
+// Valid reports whether c is a known count. The empty count counts lines.
+func (c ItemCount) Valid() bool {
+	return c == "" || c == ItemCountLines || c == ItemCountUnits
+}
+
+type itemsRule struct {
+	multiple   int64
+	multiplier float64
+	count      ItemCount
+}
+
+func (rule itemsRule) Name() string {
+	return RuleItems
+}
+
+func (rule itemsRule) Points(ctx context.Context, receipt Receipt) (int64, string) {
+	itemLength := int64(len(receipt.Items))
+	noun := "items"
+	if rule.count == ItemCountUnits {
+		itemLength = receipt.Units()
+		noun = "units"
+	}
+	multiples := float64(itemLength) / float64(rule.multiple)
+
+	points := int64(math.Floor(multiples) * rule.multiplier)
+	return points, fmt.Sprintf("%d points - %d %s (%d batches @ %.2f points each)", points, itemLength, noun, int64(multiples), rule.multiplier)
+}
+
+type descriptionRule struct {
+	multiple   int64
+	multiplier Ratio
+}
+
+func (rule descriptionRule) Name() string {
+	return RuleDescription
+}
+
+func (rule descriptionRule) Points(ctx context.Context, receipt Receipt) (int64, string) {
+	var total int64
+	var n, spaces int
+	var reasons []string
+
+Outerloop:
+	for i, item := range receipt.Items {
+		var points int64
+		var left, right int
+		n = len(item.ShortDescription)
+		for i := range item.ShortDescription {
+			if item.ShortDescription[i] == byte(' ') {
+				left++
+			} else {
+				break
+			}
+		}
+
+		if spaces == n {
+			continue Outerloop
+		}
+
+		for i := n - 1; i >= 0; i-- {
+			if item.ShortDescription[i] == byte(' ') {
+				right++
+			} else {
+				break
+			}
+		}
+
+		trimmedLength := n - left - right
+		if trimmedLength%int(rule.multiple) != 0 {
+			continue Outerloop
+		}
+		price := receipt.PriceAmount(i)
+		points = price.MulCeil(rule.multiplier)
+
+		trimmedDescription := item.ShortDescription[left : n-right]
+
+		reasons = append(reasons, fmt.Sprintf(`%d Points - "%s" is %d characters (a multiple of %d) item price of %s * %.2f = %.2f is rounded up is %d`,
+			points, trimmedDescription, trimmedLength, rule.multiple, item.Price, rule.multiplier.Float64(), price.Float64()*rule.multiplier.Float64(), points))
+
+		total += points
+	}
+
+	if len(reasons) == 0 {
+		return total, fmt.Sprintf("0 points - no item description is a multiple of %d characters", rule.multiple)
+	}
+	return total, strings.Join(reasons, "; ")
+}
+
+type couponRule struct {
+	multiplier int64
+}
+
+func (rule couponRule) Name() string {
+	return RuleCoupon
+}
+
+func (rule couponRule) Points(ctx context.Context, receipt Receipt) (int64, string) {
+	var coupons int64
+	for _, discount := range receipt.Discounts {
+		if discount.Coupon {
+			coupons++
+		}
+	}
+	if coupons == 0 {
+		return 0, "0 points - no coupon was used"
+	}
+
+	points := coupons * rule.multiplier
+	return points, fmt.Sprintf("%d points - %d coupons used", points, coupons)
+}
+
+type purchaseDateRule struct {
+	multiplier int64
+}
+
+func (rule purchaseDateRule) Name() string {
+	return RulePurchaseDate
+}
+
+func (rule purchaseDateRule) Points(ctx context.Context, receipt Receipt) (int64, string) {
+	date := receipt.PurchaseDate
+	// This should not happen unless date is not validated properly
+	dayNum, err := strconv.Atoi(date[8:])
+	if err != nil {
+		log.Fatalf("Failed to parse day %s. Check validation: %v", date, err)
+	}
+	if dayNum%2 == 0 {
+		return 0, "0 points - purchase day is even"
+	}
+
+	return rule.multiplier, fmt.Sprintf("%d points - purchase day is odd", rule.multiplier)
+}
+
+type purchaseTimeRule struct {
+	start      string
+	end        string
+	multiplier int64
+}
+
+func (rule purchaseTimeRule) Name() string {
+	return RulePurchaseTime
+}
+
+func (rule purchaseTimeRule) Points(ctx context.Context, receipt Receipt) (int64, string) {
+	time := receipt.PurchaseTime
+	if time[:2] == rule.start[:2] && time[3:] == rule.start[3:] {
+		return 0, fmt.Sprintf("0 points - %s is not after %s", time, rule.start)
+	}
+
+	if rule.start[:2] > time[:2] || time[:2] >= rule.end[:2] {
+		return 0, fmt.Sprintf("0 points - %s is not between %s and %s", time, rule.start, rule.end)
+	}
+
+	return rule.multiplier, fmt.Sprintf("%d points - %s is between %s and %s", rule.multiplier, time, rule.start, rule.end)
+}
@@ -0,0 +1,186 @@
+package receipt_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/kevin07696/receipt-processor/domain/receipt"
+	"github.com/stretchr/testify/assert"
+)
+
+type constantRule struct {
+	name   string
+	points int64
+}
+
+func (r constantRule) Name() string {
+	return r.name
+}
+
+func (r constantRule) Points(ctx context.Context, receipt receipt.Receipt) (int64, string) {
+	return r.points, "constant"
+}
+
+func TestRuleRegistry(t *testing.T) {
+	testCases := []struct {
+		title         string
+		enabled       []string
+		expectedNames []string
+		expectedError bool
+	}{
+		{
+			title:         "GivenNoEnabledRules_ReturnAllDefaultRules",
+			enabled:       nil,
+			expectedNames: receipt.DefaultRules.Defaults(),
+		},
+		{
+			title:         "GivenEnabledRules_ReturnRulesInOrder",
+			enabled:       []string{receipt.RulePurchaseTime, receipt.RuleRetailer},
+			expectedNames: []string{receipt.RulePurchaseTime, receipt.RuleRetailer},
+		},
+		{
+			title:         "GivenAnOptInRule_ReturnItOnlyWhenEnabled",
+			enabled:       []string{receipt.RuleCoupon},
+			expectedNames: []string{receipt.RuleCoupon},
+		},
+		{
+			title:         "GivenAnUnknownRule_ReturnError",
+			enabled:       []string{"unknown"},
+			expectedError: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.title, func(t *testing.T) {
+			rules, err := receipt.DefaultRules.Build(opts, mults, tc.enabled)
+			if tc.expectedError {
+				assert.Error(t, err)
+				return
+			}
+
+			assert.NoError(t, err)
+			names := make([]string, len(rules))
+			for i, rule := range rules {
+				names[i] = rule.Name()
+			}
+			assert.Equal(t, tc.expectedNames, names)
+		})
+	}
+}
+
+func TestCouponRule(t *testing.T) {
+	testCases := []struct {
+		title          string
+		discounts      []receipt.Discount
+		expectedPoints int64
+	}{
+		{
+			title:          "GivenNoDiscounts_Return0",
+			expectedPoints: 0,
+		},
+		{
+			title: "GivenCoupons_ReturnPointsForEachCoupon",
+			discounts: []receipt.Discount{
+				{Description: "Store coupon", Amount: "0.50", Coupon: true},
+				{Description: "Member discount", Amount: "0.25"},
+				{Description: "Brand coupon", Amount: "0.25", Coupon: true},
+			},
+			expectedPoints: 20,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.title, func(t *testing.T) {
+			rcpt := validReceipt("1.00", "2.00")
+			rcpt.Discounts = tc.discounts
+			assert.Equal(t, tc.expectedPoints, points(t, receipt.RuleCoupon, receipt.Options{}, receipt.Multipliers{Coupon: 10}, rcpt))
+		})
+	}
+}
+
+func TestItemsRuleCount(t *testing.T) {
+	testCases := []struct {
+		title          string
+		count          receipt.ItemCount
+		expectedPoints int64
+	}{
+		{
+			title:          "GivenLines_CountEachItemOnce",
+			count:          receipt.ItemCountLines,
+			expectedPoints: 5,
+		},
+		{
+			title:          "GivenNoCount_CountLines",
+			expectedPoints: 5,
+		},
+		{
+			title:          "GivenUnits_CountItemQuantities",
+			count:          receipt.ItemCountUnits,
+			expectedPoints: 10,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.title, func(t *testing.T) {
+			rcpt := validReceipt("8.00", "6.00", "2.00")
+			rcpt.Items[0].Quantity = "3"
+			ruleOpts := receipt.Options{ItemsMultiple: 2, ItemsCount: tc.count}
+			assert.Equal(t, tc.expectedPoints, points(t, receipt.RuleItems, ruleOpts, receipt.Multipliers{Items: 5}, rcpt))
+		})
+	}
+}
+
+func TestProcessReceiptWithRules(t *testing.T) {
+	request := receipt.ReceiptProcessorRequest{
+		Receipt: receipt.Receipt{
+			Retailer:     "Target",
+			PurchaseDate: "2022-01-01",
+			PurchaseTime: "14:01",
+			Items:        []receipt.Item{},
+			Total:        "35.00",
+		},
+	}
+
+	testCases := []struct {
+		title          string
+		enabled        []string
+		rules          []receipt.ScoringRule
+		expectedPoints int64
+	}{
+		{
+			title:          "GivenOnlyRetailerRule_ReturnRetailerPoints",
+			enabled:        []string{receipt.RuleRetailer},
+			expectedPoints: 6,
+		},
+		{
+			title:          "GivenRetailerRuleDisabled_ReturnPointsWithoutRetailer",
+			enabled:        []string{receipt.RuleRoundTotal, receipt.RuleDivisibleTotal, receipt.RulePurchaseDate, receipt.RulePurchaseTime},
+			expectedPoints: 91,
+		},
+		{
+			title:          "GivenCustomRules_ReturnSumOfCustomRules",
+			rules:          []receipt.ScoringRule{constantRule{name: "bonus", points: 100}, constantRule{name: "penalty", points: -1}},
+			expectedPoints: 99,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.title, func(t *testing.T) {
+			mockRepository.Scores = map[string]receipt.ScoreRecord{}
+			ruleOpts := opts
+			ruleOpts.Rules = tc.enabled
+
+			services := receipt.NewReceiptProcessorService(mockRepository, ruleOpts, mults)
+			if tc.rules != nil {
+				services = receipt.NewReceiptProcessorServiceWithRules(mockRepository, ruleOpts, mults, tc.rules)
+			}
+
+			request.ID = opts.GenerateID("")
+			services.ProcessReceipt(context.TODO(), request)
+
+			scoreResponse, _ := services.GetReceiptScore(context.TODO(), receipt.ReceiptScoreRequest{ID: request.ID})
+
+			assert.Equal(t, tc.expectedPoints, scoreResponse.Points)
+		})
+	}
+}
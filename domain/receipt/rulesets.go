@@ -0,0 +1,134 @@
+package receipt
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+// DefaultRuleSetVersion names the only rule set of a service created without rule sets.
+const DefaultRuleSetVersion = "default"
+
+// SelectBy decides which date picks the rule set of a receipt.
+type SelectBy string
+
+const (
+	// SelectByPurchaseDate scores a receipt with the rule set effective on its purchase date.
+	SelectByPurchaseDate SelectBy = "purchase_date"
+	// SelectByProcessedAt scores a receipt with the rule set effective when it is processed.
+	SelectByProcessedAt SelectBy = "processed_at"
+)
+
+// RuleSet is a named version of the scoring rules that is effective from EffectiveFrom until the next version.
+type RuleSet struct {
+	Version       string
+	EffectiveFrom time.Time
+	Options       Options
+	Multipliers   Multipliers
+	// Rules are built from Options and Multipliers by NewRuleSets when they are not set.
+	Rules []ScoringRule
+	// Overrides score the receipts of matching retailers instead of Rules. The first match wins.
+	Overrides []RetailerOverride
+}
+
+// RuleSets keeps the rule set versions ordered by the date they become effective.
+type RuleSets struct {
+	selectBy SelectBy
+	sets     []RuleSet
+}
+
+// NewRuleSets orders the rule sets and builds their rules with DefaultRules.
+// Versions must be unique and at least one rule set is required.
+func NewRuleSets(selectBy SelectBy, sets ...RuleSet) (*RuleSets, error) {
+	if len(sets) == 0 {
+		return nil, fmt.Errorf("no rule sets")
+	}
+	if selectBy != SelectByPurchaseDate && selectBy != SelectByProcessedAt {
+		return nil, fmt.Errorf("unknown rule set selection %s, expected %s or %s", selectBy, SelectByPurchaseDate, SelectByProcessedAt)
+	}
+
+	versions := map[string]bool{}
+	ordered := make([]RuleSet, len(sets))
+	for i, set := range sets {
+		if set.Version == "" {
+			return nil, fmt.Errorf("rule set %d has no version", i)
+		}
+		if versions[set.Version] {
+			return nil, fmt.Errorf("rule set version %s is not unique", set.Version)
+		}
+		versions[set.Version] = true
+
+		if set.Rules == nil {
+			rules, err := DefaultRules.Build(set.Options, set.Multipliers, set.Options.Rules)
+			if err != nil {
+				return nil, fmt.Errorf("rule set %s: %w", set.Version, err)
+			}
+			set.Rules = rules
+		}
+
+		set.Overrides = append([]RetailerOverride(nil), set.Overrides...)
+		for j, override := range set.Overrides {
+			if override.Name == "" {
+				return nil, fmt.Errorf("rule set %s: override %d has no name", set.Version, j)
+			}
+			if len(override.Retailers) == 0 && override.Pattern == nil {
+				return nil, fmt.Errorf("rule set %s: override %s matches no retailer", set.Version, override.Name)
+			}
+			if override.Factor < 0 || override.Factor > MaxFactor {
+				return nil, fmt.Errorf("rule set %s: override %s: factor %v is not between 0 and %d", set.Version, override.Name, override.Factor, MaxFactor)
+			}
+			if override.Rules == nil {
+				rules, err := DefaultRules.Build(override.Options, override.Multipliers, override.Options.Rules)
+				if err != nil {
+					return nil, fmt.Errorf("rule set %s: override %s: %w", set.Version, override.Name, err)
+				}
+				set.Overrides[j].Rules = rules
+			}
+		}
+		ordered[i] = set
+	}
+
+	sort.SliceStable(ordered, func(i, j int) bool {
+		return ordered[i].EffectiveFrom.Before(ordered[j].EffectiveFrom)
+	})
+
+	return &RuleSets{selectBy: selectBy, sets: ordered}, nil
+}
+
+// Select returns the latest rule set effective on the purchase date or processing time of the receipt.
+// A receipt older than every rule set is scored with the earliest one.
+func (r RuleSets) Select(receipt Receipt, processedAt time.Time) RuleSet {
+	at := processedAt
+	if r.selectBy == SelectByPurchaseDate {
+		// Purchase dates are validated, so this only falls back for receipts scored without validation
+		if purchaseDate, err := time.Parse(time.DateOnly, receipt.PurchaseDate); err == nil {
+			at = purchaseDate
+		}
+	}
+
+	selected := r.sets[0]
+	for _, set := range r.sets[1:] {
+		if set.EffectiveFrom.After(at) {
+			break
+		}
+		selected = set
+	}
+	return selected
+}
+
+// Version returns the rule set of version.
+func (r RuleSets) Version(version string) (RuleSet, bool) {
+	for _, set := range r.sets {
+		if set.Version == version {
+			return set, true
+		}
+	}
+	return RuleSet{}, false
+}
+
+// Versions lists every rule set in effective order.
+func (r RuleSets) Versions() []RuleSet {
+	sets := make([]RuleSet, len(r.sets))
+	copy(sets, r.sets)
+	return sets
+}
@@ -0,0 +1,171 @@
+package receipt_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/kevin07696/receipt-processor/domain"
+	"github.com/kevin07696/receipt-processor/domain/receipt"
+	"github.com/stretchr/testify/assert"
+)
+
+func date(value string) time.Time {
+	parsed, err := time.Parse(time.DateOnly, value)
+	if err != nil {
+		panic(err)
+	}
+	return parsed
+}
+
+func TestNewRuleSets(t *testing.T) {
+	testCases := []struct {
+		title         string
+		selectBy      receipt.SelectBy
+		sets          []receipt.RuleSet
+		expectedError bool
+	}{
+		{
+			title:    "GivenUniqueVersions_ReturnRuleSets",
+			selectBy: receipt.SelectByPurchaseDate,
+			sets:     []receipt.RuleSet{{Version: "v1"}, {Version: "v2", EffectiveFrom: date("2024-01-01")}},
+		},
+		{
+			title:         "GivenNoRuleSets_ReturnError",
+			selectBy:      receipt.SelectByPurchaseDate,
+			expectedError: true,
+		},
+		{
+			title:         "GivenADuplicateVersion_ReturnError",
+			selectBy:      receipt.SelectByPurchaseDate,
+			sets:          []receipt.RuleSet{{Version: "v1"}, {Version: "v1"}},
+			expectedError: true,
+		},
+		{
+			title:         "GivenAnEmptyVersion_ReturnError",
+			selectBy:      receipt.SelectByPurchaseDate,
+			sets:          []receipt.RuleSet{{}},
+			expectedError: true,
+		},
+		{
+			title:         "GivenAnUnknownRule_ReturnError",
+			selectBy:      receipt.SelectByPurchaseDate,
+			sets:          []receipt.RuleSet{{Version: "v1", Options: receipt.Options{Rules: []string{"unknown"}}}},
+			expectedError: true,
+		},
+		{
+			title:         "GivenAnUnknownSelection_ReturnError",
+			selectBy:      "unknown",
+			sets:          []receipt.RuleSet{{Version: "v1"}},
+			expectedError: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.title, func(t *testing.T) {
+			_, err := receipt.NewRuleSets(tc.selectBy, tc.sets...)
+			assert.Equal(t, tc.expectedError, err != nil)
+		})
+	}
+}
+
+func TestRuleSetsSelect(t *testing.T) {
+	sets := []receipt.RuleSet{
+		{Version: "2024", EffectiveFrom: date("2024-01-01")},
+		{Version: "2022", EffectiveFrom: date("2022-01-01")},
+		{Version: "2023", EffectiveFrom: date("2023-01-01")},
+	}
+	processedAt := date("2023-06-01")
+
+	testCases := []struct {
+		title           string
+		selectBy        receipt.SelectBy
+		purchaseDate    string
+		expectedVersion string
+	}{
+		{
+			title:           "GivenAPurchaseDate_SelectLatestEffectiveVersion",
+			selectBy:        receipt.SelectByPurchaseDate,
+			purchaseDate:    "2024-03-20",
+			expectedVersion: "2024",
+		},
+		{
+			title:           "GivenThePurchaseDateAVersionStarts_SelectThatVersion",
+			selectBy:        receipt.SelectByPurchaseDate,
+			purchaseDate:    "2023-01-01",
+			expectedVersion: "2023",
+		},
+		{
+			title:           "GivenAPurchaseDateBeforeEveryVersion_SelectEarliestVersion",
+			selectBy:        receipt.SelectByPurchaseDate,
+			purchaseDate:    "2021-12-31",
+			expectedVersion: "2022",
+		},
+		{
+			title:           "GivenAnInvalidPurchaseDate_SelectByProcessingTime",
+			selectBy:        receipt.SelectByPurchaseDate,
+			purchaseDate:    "2024/03/20",
+			expectedVersion: "2023",
+		},
+		{
+			title:           "GivenSelectionByProcessingTime_IgnorePurchaseDate",
+			selectBy:        receipt.SelectByProcessedAt,
+			purchaseDate:    "2024-03-20",
+			expectedVersion: "2023",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.title, func(t *testing.T) {
+			ruleSets, err := receipt.NewRuleSets(tc.selectBy, sets...)
+			assert.NoError(t, err)
+
+			selected := ruleSets.Select(receipt.Receipt{PurchaseDate: tc.purchaseDate}, processedAt)
+			assert.Equal(t, tc.expectedVersion, selected.Version)
+		})
+	}
+}
+
+func TestProcessReceiptWithRuleSets(t *testing.T) {
+	ctx := context.TODO()
+	opts := receipt.Options{GenerateID: func(input string) string { return input }}
+	ruleSets, err := receipt.NewRuleSets(receipt.SelectByPurchaseDate,
+		receipt.RuleSet{Version: "v1", Options: receipt.Options{Rules: []string{receipt.RuleRetailer}}, Multipliers: receipt.Multipliers{Retailer: 1}},
+		receipt.RuleSet{Version: "v2", EffectiveFrom: date("2024-01-01"), Options: receipt.Options{Rules: []string{receipt.RuleRetailer}}, Multipliers: receipt.Multipliers{Retailer: 2}},
+	)
+	assert.NoError(t, err)
+
+	testCases := []struct {
+		title            string
+		purchaseDate     string
+		expectedResponse receipt.ReceiptScoreResponse
+	}{
+		{
+			title:            "GivenAnOldReceipt_ScoreWithOldVersion",
+			purchaseDate:     "2023-12-31",
+			expectedResponse: receipt.ReceiptScoreResponse{Points: 6, RuleSetVersion: "v1"},
+		},
+		{
+			title:            "GivenANewReceipt_ScoreWithNewVersion",
+			purchaseDate:     "2024-01-01",
+			expectedResponse: receipt.ReceiptScoreResponse{Points: 12, RuleSetVersion: "v2"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.title, func(t *testing.T) {
+			repository := NewMapReceiptRepository()
+			services := receipt.NewReceiptProcessorServiceWithRuleSets(repository, opts, ruleSets)
+
+			_, status := services.ProcessReceipt(ctx, receipt.ReceiptProcessorRequest{
+				ID:      tc.purchaseDate,
+				Receipt: receipt.Receipt{Retailer: "Target", PurchaseDate: tc.purchaseDate},
+			})
+			assert.Equal(t, domain.StatusOK, status)
+
+			response, status := services.GetReceiptScore(ctx, receipt.ReceiptScoreRequest{ID: tc.purchaseDate})
+			assert.Equal(t, domain.StatusOK, status)
+			assert.Equal(t, tc.expectedResponse, response)
+		})
+	}
+}
@@ -1,25 +1,82 @@
 package receipt
 
 import (
-	"bytes"
 	"context"
 	"fmt"
 	"log"
 	"log/slog"
 	"math"
-	"strconv"
-	"unicode"
+	"time"
 
 	"github.com/kevin07696/receipt-processor/domain"
 )
 
 type Options struct {
-	GenerateID          func(input string) string
-	StartPurchaseTime   string
-	EndPurchaseTime     string
-	TotalMultiple       float64
-	ItemsMultiple       int64
+	GenerateID        func(input string) string
+	StartPurchaseTime string
+	EndPurchaseTime   string
+	TotalMultiple     float64
+	ItemsMultiple     int64
+	// ItemsCount decides whether the items rule counts item lines or units. Empty counts lines.
+	ItemsCount          ItemCount
 	DescriptionMultiple int64
+	// Rules lists the enabled scoring rules by name in evaluation order. Empty enables every default rule.
+	Rules []string
+	// Consistency configures the cross-field checks of ValidateReceipt. The zero value skips them.
+	Consistency ConsistencyOptions
+	// RetailerName decides whether rules score the raw retailer or its canonical name. Empty scores the raw retailer.
+	RetailerName RetailerName
+	// Retailers resolves the canonical retailer stored with each score. Nil stores none.
+	Retailers *RetailerRegistry `json:"-"`
+	// Campaigns add points to matching receipts after the rules. Nil runs no campaigns.
+	Campaigns *CampaignRegistry `json:"-"`
+	// Caps bound the points of each rule and receipt. The zero value bounds nothing.
+	Caps Caps
+	// Ledger sums the points of each user for the user caps. Nil caps no user.
+	Ledger *PointsLedger `json:"-"`
+}
+
+// Validate returns every scoring option that would make a rule fail or is unknown. Paths are relative to the options.
+func (opts Options) Validate() []domain.Violation {
+	var violations []domain.Violation
+	for i, name := range opts.Rules {
+		if !DefaultRules.Has(name) {
+			violations = append(violations, domain.Violation{Path: fmt.Sprintf("/Rules/%d", i), Rule: domain.RuleOneOf, Value: name})
+		}
+	}
+	if !opts.ItemsCount.Valid() {
+		violations = append(violations, domain.Violation{Path: "/ItemsCount", Rule: domain.RuleOneOf, Value: string(opts.ItemsCount)})
+	}
+	if !opts.RetailerName.Valid() {
+		violations = append(violations, domain.Violation{Path: "/RetailerName", Rule: domain.RuleOneOf, Value: string(opts.RetailerName)})
+	}
+
+	// The items and description rules divide by their multiples and the total rule takes its multiple in cents
+	if opts.ItemsMultiple <= 0 {
+		violations = append(violations, domain.Violation{Path: "/ItemsMultiple", Rule: domain.RuleMin, Value: fmt.Sprint(opts.ItemsMultiple)})
+	}
+	if opts.DescriptionMultiple <= 0 {
+		violations = append(violations, domain.Violation{Path: "/DescriptionMultiple", Rule: domain.RuleMin, Value: fmt.Sprint(opts.DescriptionMultiple)})
+	}
+	if opts.TotalMultiple*100 >= math.MaxInt64 {
+		violations = append(violations, domain.Violation{Path: "/TotalMultiple", Rule: domain.RuleMax, Value: fmt.Sprint(opts.TotalMultiple)})
+	} else if MoneyFromFloat(opts.TotalMultiple) <= 0 {
+		violations = append(violations, domain.Violation{Path: "/TotalMultiple", Rule: domain.RuleMin, Value: fmt.Sprint(opts.TotalMultiple)})
+	}
+
+	check := func(path, value string) {
+		if value == "" {
+			violations = append(violations, domain.Violation{Path: path, Rule: domain.RuleRequired, Value: value})
+		} else if !match(timePattern, value) {
+			violations = append(violations, domain.Violation{Path: path, Rule: domain.RulePattern, Value: value})
+		}
+	}
+	check("/StartPurchaseTime", opts.StartPurchaseTime)
+	check("/EndPurchaseTime", opts.EndPurchaseTime)
+	if match(timePattern, opts.StartPurchaseTime) && match(timePattern, opts.EndPurchaseTime) && opts.EndPurchaseTime <= opts.StartPurchaseTime {
+		violations = append(violations, domain.Violation{Path: "/EndPurchaseTime", Rule: domain.RuleMin, Value: opts.EndPurchaseTime})
+	}
+	return append(violations, opts.Caps.Validate()...)
 }
 
 type Multipliers struct {
@@ -30,19 +87,42 @@ type Multipliers struct {
 	Description    float64
 	PurchaseTime   int64
 	PurchaseDate   int64
+	Coupon         int64
 }
 
 type ReceiptProcessorService struct {
 	repository IReceiptProcessorRepository
 	opts       Options
-	mults      Multipliers
+	ruleSets   *RuleSets
 }
 
 func NewReceiptProcessorService(repository IReceiptProcessorRepository, opts Options, mults Multipliers) ReceiptProcessorService {
+	// This should not happen unless the rule names are not validated by config
+	rules, err := DefaultRules.Build(opts, mults, opts.Rules)
+	if err != nil {
+		log.Fatalf("Failed to build scoring rules. Check config: %v", err)
+	}
+
+	return NewReceiptProcessorServiceWithRules(repository, opts, mults, rules)
+}
+
+// NewReceiptProcessorServiceWithRules scores receipts with the given rules instead of the default rule set.
+func NewReceiptProcessorServiceWithRules(repository IReceiptProcessorRepository, opts Options, mults Multipliers, rules []ScoringRule) ReceiptProcessorService {
+	ruleSets, err := NewRuleSets(SelectByProcessedAt, RuleSet{Version: DefaultRuleSetVersion, Options: opts, Multipliers: mults, Rules: rules})
+	if err != nil {
+		log.Fatalf("Failed to create rule set: %v", err)
+	}
+
+	return NewReceiptProcessorServiceWithRuleSets(repository, opts, ruleSets)
+}
+
+// NewReceiptProcessorServiceWithRuleSets scores each receipt with the rule set version effective for it.
+// opts only provides GenerateID, Consistency, Retailers, Campaigns and Ledger, the scoring options and caps come from each rule set.
+func NewReceiptProcessorServiceWithRuleSets(repository IReceiptProcessorRepository, opts Options, ruleSets *RuleSets) ReceiptProcessorService {
 	return ReceiptProcessorService{
 		repository: repository,
 		opts:       opts,
-		mults:      mults,
+		ruleSets:   ruleSets,
 	}
 }
 
@@ -53,6 +133,8 @@ type ReceiptProcessorRequest struct {
 
 type ReceiptProcessorResponse struct {
 	ID string
+	// Warnings are the inconsistencies of a receipt accepted in lenient mode.
+	Warnings []domain.Violation `json:",omitempty"`
 }
 
 func (rps *ReceiptProcessorService) GenerateID(ctx context.Context, input string) string {
@@ -64,186 +146,156 @@ func (rps *ReceiptProcessorService) ProcessReceipt(ctx context.Context, request
 		return ReceiptProcessorResponse{ID: request.ID}, domain.StatusOK
 	}
 
-	var points int64
-	points += rps.pointsForEachAlphaNumeric(ctx, request.Receipt.Retailer)
-	points += rps.pointsForEachItemMultiples(ctx, len(request.Receipt.Items))
-	points += rps.pointsIfRoundTotal(ctx, request.Receipt.Total)
-	points += rps.pointsIfDivisibleTotal(ctx, request.Receipt.Total)
-	points += rps.pointsIfOddPurchaseDate(ctx, request.Receipt.PurchaseDate)
-	points += rps.pointsIfBetweenPurchaseTime(ctx, request.Receipt.PurchaseTime)
-	points += rps.pointsForEachDivisibleItemDescription(ctx, request.Receipt.Items)
+	now := time.Now()
+	record, caps := rps.score(ctx, request.Receipt, rps.ruleSets.Select(request.Receipt, now), now)
+	rps.awardUser(request.ID, &record, caps)
 
-	slog.InfoContext(ctx, fmt.Sprintf("Total Points: %d", points))
-
-	status := rps.repository.WriteReceiptScore(ctx, request.ID, points)
+	status := rps.repository.WriteReceiptScore(ctx, request.ID, record)
 	if status > 0 {
+		rps.opts.Ledger.Remove(request.ID)
 		return ReceiptProcessorResponse{}, status
 	}
 
 	return ReceiptProcessorResponse{ID: request.ID}, domain.StatusOK
 }
 
-type ReceiptScoreRequest struct {
-	ID string
-}
-
-type ReceiptScoreResponse struct {
-	Points int64
-}
-
-func (rps ReceiptProcessorService) GetReceiptScore(ctx context.Context, request ReceiptScoreRequest) (ReceiptScoreResponse, domain.StatusCode) {
-	points, status := rps.repository.ReadReceiptScore(ctx, request.ID)
-	if status > 0 {
-		return ReceiptScoreResponse{}, domain.ErrNotFound
+// score runs every rule of the rule set, or of the override matching the retailer, then the active campaigns,
+// and bounds the points with the caps of the rule set or override, which it returns for the user caps.
+// It records the canonical retailer and the campaigns applied.
+func (rps ReceiptProcessorService) score(ctx context.Context, receipt Receipt, ruleSet RuleSet, scoredAt time.Time) (ScoreRecord, Caps) {
+	record := ScoreRecord{Receipt: receipt, RuleSetVersion: ruleSet.Version, ScoredAt: scoredAt}
+	retailer, known := rps.opts.Retailers.Resolve(receipt.Retailer)
+	if known {
+		record.RetailerID = retailer.ID
 	}
 
-	return ReceiptScoreResponse{Points: points}, domain.StatusOK
-}
-
-func (rps ReceiptProcessorService) pointsForEachAlphaNumeric(ctx context.Context, name string) int64 {
-	var alphaNums int64
-	var buf bytes.Buffer
-	for _, r := range name {
-		if unicode.IsLetter(r) || unicode.IsDigit(r) {
-			alphaNums++
-		} else {
-			buf.WriteRune(r)
-		}
+	rules, retailerName, caps := ruleSet.Rules, ruleSet.Options.RetailerName, ruleSet.Options.Caps
+	override, overridden := ruleSet.Override(receipt.Retailer, record.RetailerID)
+	if overridden {
+		rules, retailerName, caps = override.Rules, override.Options.RetailerName, override.Options.Caps
 	}
-
-	points := alphaNums * rps.mults.Retailer
-
-	if points > 0 {
-		var message string
-		if buf.Len() > 0 {
-			message = fmt.Sprintf("%d points - retailer name %s has %d alphanumeric characters note: '&' is not alphanumeric", points, name, alphaNums)
-		} else {
-			message = fmt.Sprintf("%d points - retailer name has %d characters", points, alphaNums)
-		}
-		slog.DebugContext(ctx, message)
+	if known && retailerName == RetailerNameCanonical {
+		receipt.Retailer = retailer.Name
 	}
 
-	return points
-}
-
-func (rps ReceiptProcessorService) pointsIfRoundTotal(ctx context.Context, total string) int64 {
-	n := len(total)
-
-	decimals := total[n-2:]
+	add := func(score RuleScore) {
+		score = caps.capRule(score)
+		slog.DebugContext(ctx, score.Reason)
 
-	if decimals > "00" {
-		return 0
+		record.Points += score.Points
+		record.Breakdown = append(record.Breakdown, score)
 	}
 
-	slog.DebugContext(ctx, fmt.Sprintf("%d points - total is a round dollar amount", rps.mults.RoundTotal))
-
-	return rps.mults.RoundTotal
-}
+	for _, rule := range rules {
+		points, reason := rule.Points(ctx, receipt)
+		add(RuleScore{Rule: rule.Name(), Points: points, Reason: reason})
+	}
 
-func (rps ReceiptProcessorService) pointsIfDivisibleTotal(ctx context.Context, total string) int64 {
-	// This should not happen unless total is not properly validated
-	currency, err := strconv.ParseFloat(total, 64)
-	if err != nil {
-		log.Fatalf("Failed to parse total, %s. Check validation: %v", total, err)
+	if overridden {
+		add(override.apply(record.Receipt.Retailer, record.Points))
 	}
-	if currency == 0 {
-		return 0
+
+	// Campaigns scale the points of the rules and override, so they do not compound
+	points := record.Points
+	for _, campaign := range rps.opts.Campaigns.Active(record.Receipt, record.RetailerID) {
+		add(campaign.apply(points))
+		record.Campaigns = append(record.Campaigns, campaign.ID)
 	}
 
-	remainder := math.Mod(currency, rps.opts.TotalMultiple)
+	for _, score := range caps.bound(record.Points) {
+		slog.DebugContext(ctx, score.Reason)
 
-	if remainder > 0 {
-		return 0
+		record.Points += score.Points
+		record.Breakdown = append(record.Breakdown, score)
 	}
 
-	slog.DebugContext(ctx, fmt.Sprintf("%d points - total is a multiple of %.2f", rps.mults.DivisibleTotal, rps.opts.TotalMultiple))
-
-	return rps.mults.DivisibleTotal
+	slog.InfoContext(ctx, fmt.Sprintf("Total Points: %d", record.Points), slog.String("ruleSet", ruleSet.Version))
+	return record, caps
 }
 
-func (rps ReceiptProcessorService) pointsForEachItemMultiples(ctx context.Context, itemLength int) int64 {
-	multiples := float64(itemLength) / float64(rps.opts.ItemsMultiple)
-
-	slog.DebugContext(ctx, fmt.Sprintf("%d items (%d batches @ %.2f points each)", itemLength, rps.opts.ItemsMultiple, rps.mults.Items))
-	return int64(math.Floor(multiples) * rps.mults.Items)
+// HasRuleSetVersion reports whether receipts can be scored with the rule set version.
+func (rps ReceiptProcessorService) HasRuleSetVersion(version string) bool {
+	_, ok := rps.ruleSets.Version(version)
+	return ok
 }
 
-func (rps ReceiptProcessorService) pointsForEachDivisibleItemDescription(ctx context.Context, items []Item) int64 {
-	var total int64
-	var n, spaces int
-
-Outerloop:
-	for _, item := range items {
-		var points int64
-		var left, right int
-		n = len(item.ShortDescription)
-		for i := range item.ShortDescription {
-			if item.ShortDescription[i] == byte(' ') {
-				left++
-			} else {
-				break
-			}
-		}
-
-		if spaces == n {
-			continue Outerloop
-		}
+// ListReceiptIDs lists the IDs of every stored receipt.
+func (rps ReceiptProcessorService) ListReceiptIDs(ctx context.Context) ([]string, domain.StatusCode) {
+	return rps.repository.ListReceiptIDs(ctx)
+}
 
-		for i := n - 1; i >= 0; i-- {
-			if item.ShortDescription[i] == byte(' ') {
-				right++
-			} else {
-				break
-			}
-		}
+// RescoreReceipt scores a stored receipt again with the rule set version. The replaced score is kept in its history.
+func (rps ReceiptProcessorService) RescoreReceipt(ctx context.Context, id string, version string) domain.StatusCode {
+	ruleSet, ok := rps.ruleSets.Version(version)
+	if !ok {
+		slog.DebugContext(ctx, "StatusBadRequest: unknown rule set version", slog.String("version", version))
+		return domain.ErrBadRequest
+	}
 
-		trimmedLength := n - left - right
-		if trimmedLength%int(rps.opts.DescriptionMultiple) != 0 {
-			continue Outerloop
-		}
-		// This should not happen unless price is not properly validated
-		price, err := strconv.ParseFloat(item.Price, 64)
-		if err != nil {
-			log.Fatalf("Failed to parse price, %s: %v", item.Price, err)
-		}
+	previous, status := rps.repository.ReadReceiptScore(ctx, id)
+	if status > 0 {
+		return status
+	}
+	// Scores stored before receipts were kept with them have an empty receipt, which the rules can not score
+	if previous.Receipt.PurchaseDate == "" {
+		slog.WarnContext(ctx, "Rescore Error: The score has no stored receipt.", slog.String("id", id))
+		return domain.ErrInternal
+	}
 
-		points = int64(math.Ceil(price * rps.mults.Description))
+	record, caps := rps.score(ctx, previous.Receipt, ruleSet, time.Now())
+	rps.awardUser(id, &record, caps)
+	// Copied, because in-memory repositories share the slice with the stored record
+	record.History = append(append([]ScoreRevision(nil), previous.History...), ScoreRevision{
+		Points:         previous.Points,
+		RuleSetVersion: previous.RuleSetVersion,
+		ScoredAt:       previous.ScoredAt,
+	})
 
-		trimmedDescription := item.ShortDescription[left : n-right]
+	status = rps.repository.WriteReceiptScore(ctx, id, record)
+	if status > 0 {
+		rps.opts.Ledger.Record(id, previous)
+	}
+	return status
+}
 
-		slog.DebugContext(ctx, fmt.Sprintf(`%d Points - "%s" is %d characters (a multiple of %d) item price of %s * %.2f = %.2f is rounded up is %d`,
-			points, trimmedDescription, trimmedLength, rps.opts.DescriptionMultiple, item.Price, rps.mults.Description, price*rps.mults.Description, points))
+type ReceiptScoreRequest struct {
+	ID string
+}
 
-		total += points
-	}
-	return total
+type ReceiptScoreResponse struct {
+	Points         int64
+	RuleSetVersion string
 }
 
-func (rps ReceiptProcessorService) pointsIfOddPurchaseDate(ctx context.Context, date string) int64 {
-	// This should not happen unless date is not validated properly
-	dayNum, err := strconv.Atoi(date[8:])
-	if err != nil {
-		log.Fatalf("Failed to parse day %s. Check validation: %v", date, err)
-	}
-	if dayNum%2 == 0 {
-		return 0
+func (rps ReceiptProcessorService) GetReceiptScore(ctx context.Context, request ReceiptScoreRequest) (ReceiptScoreResponse, domain.StatusCode) {
+	record, status := rps.repository.ReadReceiptScore(ctx, request.ID)
+	if status > 0 {
+		return ReceiptScoreResponse{}, domain.ErrNotFound
 	}
 
-	slog.DebugContext(ctx, fmt.Sprintf("%d points - purchase day is odd", rps.mults.PurchaseDate))
+	return ReceiptScoreResponse{Points: record.Points, RuleSetVersion: record.RuleSetVersion}, domain.StatusOK
+}
 
-	return rps.mults.PurchaseDate
+type ReceiptBreakdownRequest struct {
+	ID string
 }
 
-func (rps ReceiptProcessorService) pointsIfBetweenPurchaseTime(ctx context.Context, time string) int64 {
-	if time[:2] == rps.opts.StartPurchaseTime[:2] && time[3:] == rps.opts.StartPurchaseTime[3:] {
-		return 0
-	}
+type ReceiptBreakdownResponse struct {
+	Points         int64
+	RuleSetVersion string
+	// RetailerID is the canonical retailer of the receipt, when the retailer registry knew it.
+	RetailerID string `json:",omitempty"`
+	// Campaigns are the IDs of the campaigns applied to the receipt.
+	Campaigns []string `json:",omitempty"`
+	Breakdown []RuleScore
+	History   []ScoreRevision
+}
 
-	if rps.opts.StartPurchaseTime[:2] > time[:2] || time[:2] >= rps.opts.EndPurchaseTime[:2] {
-		return 0
+func (rps ReceiptProcessorService) GetReceiptBreakdown(ctx context.Context, request ReceiptBreakdownRequest) (ReceiptBreakdownResponse, domain.StatusCode) {
+	record, status := rps.repository.ReadReceiptScore(ctx, request.ID)
+	if status > 0 {
+		return ReceiptBreakdownResponse{}, domain.ErrNotFound
 	}
 
-	slog.DebugContext(ctx, fmt.Sprintf("%d points - %s is between %s and %s", rps.mults.PurchaseTime, time, rps.opts.StartPurchaseTime, rps.opts.EndPurchaseTime))
-
-	return rps.mults.PurchaseTime
+	return ReceiptBreakdownResponse{Points: record.Points, RuleSetVersion: record.RuleSetVersion, RetailerID: record.RetailerID, Campaigns: record.Campaigns, Breakdown: record.Breakdown, History: record.History}, domain.StatusOK
 }
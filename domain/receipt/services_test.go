@@ -44,16 +44,16 @@ var opts = receipt.Options{
 }
 
 var mockRepository = MockReceiptRepository{
-	WriteReceiptScoreMock: func(ctx context.Context, id string, points int64, scores map[string]int64) domain.StatusCode {
-		scores[id] = points
+	WriteReceiptScoreMock: func(ctx context.Context, id string, record receipt.ScoreRecord, scores map[string]receipt.ScoreRecord) domain.StatusCode {
+		scores[id] = record
 		return domain.StatusOK
 	},
-	ReadReceiptScoreMock: func(ctx context.Context, id string, scores map[string]int64) (int64, domain.StatusCode) {
-		points, ok := scores[id]
+	ReadReceiptScoreMock: func(ctx context.Context, id string, scores map[string]receipt.ScoreRecord) (receipt.ScoreRecord, domain.StatusCode) {
+		record, ok := scores[id]
 		if !ok {
-			return points, domain.ErrNotFound
+			return record, domain.ErrNotFound
 		}
-		return points, domain.StatusOK
+		return record, domain.StatusOK
 	},
 }
 
@@ -83,10 +83,10 @@ func TestProcessReceipt(t *testing.T) {
 		{
 			title: "GivenAValidRequest_ReturnID",
 			mockRepository: MockReceiptRepository{
-				ReadReceiptScoreMock: func(ctx context.Context, id string, scores map[string]int64) (int64, domain.StatusCode) {
-					return 0, domain.ErrNotFound
+				ReadReceiptScoreMock: func(ctx context.Context, id string, scores map[string]receipt.ScoreRecord) (receipt.ScoreRecord, domain.StatusCode) {
+					return receipt.ScoreRecord{}, domain.ErrNotFound
 				},
-				WriteReceiptScoreMock: func(ctx context.Context, id string, points int64, scores map[string]int64) domain.StatusCode {
+				WriteReceiptScoreMock: func(ctx context.Context, id string, record receipt.ScoreRecord, scores map[string]receipt.ScoreRecord) domain.StatusCode {
 					return domain.StatusOK
 				},
 			},
@@ -96,11 +96,11 @@ func TestProcessReceipt(t *testing.T) {
 		{
 			title: "GivenARepeatedRequest_ReturnID",
 			mockRepository: MockReceiptRepository{
-				ReadReceiptScoreMock: func(ctx context.Context, id string, scores map[string]int64) (int64, domain.StatusCode) {
-					return 0, domain.StatusOK
+				ReadReceiptScoreMock: func(ctx context.Context, id string, scores map[string]receipt.ScoreRecord) (receipt.ScoreRecord, domain.StatusCode) {
+					return receipt.ScoreRecord{}, domain.StatusOK
 				},
 				// If write method runs, this test will fail. Status error is arbitrary.
-				WriteReceiptScoreMock: func(ctx context.Context, id string, points int64, scores map[string]int64) domain.StatusCode {
+				WriteReceiptScoreMock: func(ctx context.Context, id string, record receipt.ScoreRecord, scores map[string]receipt.ScoreRecord) domain.StatusCode {
 					return domain.ErrInternal
 				},
 			},
@@ -110,10 +110,10 @@ func TestProcessReceipt(t *testing.T) {
 		{
 			title: "GivenAValidRequest_ReturnInternalServerError",
 			mockRepository: MockReceiptRepository{
-				ReadReceiptScoreMock: func(ctx context.Context, id string, scores map[string]int64) (int64, domain.StatusCode) {
-					return 0, domain.ErrNotFound
+				ReadReceiptScoreMock: func(ctx context.Context, id string, scores map[string]receipt.ScoreRecord) (receipt.ScoreRecord, domain.StatusCode) {
+					return receipt.ScoreRecord{}, domain.ErrNotFound
 				},
-				WriteReceiptScoreMock: func(ctx context.Context, id string, points int64, scores map[string]int64) domain.StatusCode {
+				WriteReceiptScoreMock: func(ctx context.Context, id string, record receipt.ScoreRecord, scores map[string]receipt.ScoreRecord) domain.StatusCode {
 					return domain.ErrInternal
 				},
 			},
@@ -135,9 +135,9 @@ func TestProcessReceipt(t *testing.T) {
 }
 
 func TestGetRequest(t *testing.T) {
-	scores := map[string]int64{}
+	scores := map[string]receipt.ScoreRecord{}
 	id := opts.GenerateID("receipt data")
-	scores[id] = 28
+	scores[id] = receipt.ScoreRecord{Points: 28}
 
 	testCases := []struct {
 		title            string
@@ -664,7 +664,7 @@ func TestProcessReceiptRules(t *testing.T) {
 
 	for _, tc := range testCases {
 		t.Run(tc.title, func(t *testing.T) {
-			mockRepository.Scores = map[string]int64{}
+			mockRepository.Scores = map[string]receipt.ScoreRecord{}
 
 			services := receipt.NewReceiptProcessorService(mockRepository, opts, mults)
 
@@ -679,3 +679,65 @@ func TestProcessReceiptRules(t *testing.T) {
 		})
 	}
 }
+
+func TestGetReceiptBreakdown(t *testing.T) {
+	request := receipt.ReceiptProcessorRequest{
+		Receipt: receipt.Receipt{
+			Retailer:     "Target",
+			PurchaseDate: "2022-01-01",
+			PurchaseTime: "13:01",
+			Items: []receipt.Item{
+				{
+					ShortDescription: "Emils Cheese Pizza",
+					Price:            "12.25",
+				},
+			},
+			Total: "12.25",
+		},
+		ID: opts.GenerateID("breakdown"),
+	}
+
+	testCases := []struct {
+		title            string
+		request          receipt.ReceiptBreakdownRequest
+		expectedResponse receipt.ReceiptBreakdownResponse
+		expectedStatus   domain.StatusCode
+	}{
+		{
+			title:   "GivenAProcessedReceipt_ReturnBreakdown",
+			request: receipt.ReceiptBreakdownRequest{ID: request.ID},
+			expectedResponse: receipt.ReceiptBreakdownResponse{
+				Points:         40,
+				RuleSetVersion: receipt.DefaultRuleSetVersion,
+				Breakdown: []receipt.RuleScore{
+					{Rule: receipt.RuleRetailer, Points: 6, Reason: "6 points - retailer name has 6 characters"},
+					{Rule: receipt.RuleItems, Points: 0, Reason: "0 points - 1 items (0 batches @ 5.00 points each)"},
+					{Rule: receipt.RuleRoundTotal, Points: 0, Reason: "0 points - total 12.25 is not a round dollar amount"},
+					{Rule: receipt.RuleDivisibleTotal, Points: 25, Reason: "25 points - total is a multiple of 0.25"},
+					{Rule: receipt.RulePurchaseDate, Points: 6, Reason: "6 points - purchase day is odd"},
+					{Rule: receipt.RulePurchaseTime, Points: 0, Reason: "0 points - 13:01 is not between 14:00 and 16:00"},
+					{Rule: receipt.RuleDescription, Points: 3, Reason: `3 Points - "Emils Cheese Pizza" is 18 characters (a multiple of 3) item price of 12.25 * 0.20 = 2.45 is rounded up is 3`},
+				},
+			},
+		},
+		{
+			title:            "GivenAnUnknownID_ReturnNotFoundError",
+			request:          receipt.ReceiptBreakdownRequest{ID: "id"},
+			expectedResponse: receipt.ReceiptBreakdownResponse{},
+			expectedStatus:   domain.ErrNotFound,
+		},
+	}
+
+	mockRepository.Scores = map[string]receipt.ScoreRecord{}
+	services := receipt.NewReceiptProcessorService(mockRepository, opts, mults)
+	services.ProcessReceipt(context.TODO(), request)
+
+	for _, tc := range testCases {
+		t.Run(tc.title, func(t *testing.T) {
+			response, status := services.GetReceiptBreakdown(context.TODO(), tc.request)
+
+			assert.Equal(t, tc.expectedResponse, response)
+			assert.Equal(t, tc.expectedStatus, status)
+		})
+	}
+}
@@ -0,0 +1,90 @@
+package receipt
+
+import (
+	"context"
+	"log/slog"
+	"maps"
+	"time"
+
+	"github.com/kevin07696/receipt-processor/domain"
+)
+
+// ReceiptSimulationRequest scores a receipt with the live rule set and with a proposed change to it.
+type ReceiptSimulationRequest struct {
+	Receipt Receipt
+	// Propose changes copies of the options and multipliers of the live rule set. Nil proposes no change.
+	Propose func(opts *Options, mults *Multipliers) error
+}
+
+type ScoreSimulation struct {
+	Points    int64
+	Breakdown []RuleScore
+}
+
+type ReceiptSimulationResponse struct {
+	// RuleSetVersion is the live rule set the proposal is applied to.
+	RuleSetVersion string
+	Live           ScoreSimulation
+	Proposed       ScoreSimulation
+	// Difference is the proposed points minus the live points.
+	Difference int64
+	Warnings   []domain.Violation `json:",omitempty"`
+}
+
+// SimulateReceipt scores the receipt without storing it. The proposal is applied to the live rule set and to each of
+// its retailer overrides, and their rules are built by DefaultRules, so they replace custom rules. Campaigns are kept
+// as they are live. User caps are left out, so points are only bounded by the caps of the rules and receipt.
+// Proposed options that rules can not score with are returned as violations under /Options.
+func (rps ReceiptProcessorService) SimulateReceipt(ctx context.Context, request ReceiptSimulationRequest) (ReceiptSimulationResponse, domain.StatusCode, []domain.Violation) {
+	now := time.Now()
+	live := rps.ruleSets.Select(request.Receipt, now)
+
+	proposed := RuleSet{Version: live.Version, Options: live.Options, Multipliers: live.Multipliers}
+	rules, violations, err := propose(request.Propose, &proposed.Options, &proposed.Multipliers)
+	if err != nil || len(violations) > 0 {
+		slog.DebugContext(ctx, "StatusBadRequest: invalid proposal", slog.Any("error", err), slog.Any("violations", violations))
+		return ReceiptSimulationResponse{}, domain.ErrBadRequest, violations
+	}
+	proposed.Rules = rules
+
+	proposed.Overrides = make([]RetailerOverride, len(live.Overrides))
+	for i, override := range live.Overrides {
+		override.Rules, violations, err = propose(request.Propose, &override.Options, &override.Multipliers)
+		if err != nil || len(violations) > 0 {
+			slog.DebugContext(ctx, "StatusBadRequest: invalid proposal for override", slog.String("override", override.Name), slog.Any("error", err), slog.Any("violations", violations))
+			return ReceiptSimulationResponse{}, domain.ErrBadRequest, violations
+		}
+		proposed.Overrides[i] = override
+	}
+
+	liveRecord, _ := rps.score(ctx, request.Receipt, live, now)
+	proposedRecord, _ := rps.score(ctx, request.Receipt, proposed, now)
+
+	return ReceiptSimulationResponse{
+		RuleSetVersion: live.Version,
+		Live:           ScoreSimulation{Points: liveRecord.Points, Breakdown: liveRecord.Breakdown},
+		Proposed:       ScoreSimulation{Points: proposedRecord.Points, Breakdown: proposedRecord.Breakdown},
+		Difference:     proposedRecord.Points - liveRecord.Points,
+	}, domain.StatusOK, nil
+}
+
+// propose applies the proposal to the options and multipliers, which are copies of live ones, and builds their rules.
+func propose(proposal func(opts *Options, mults *Multipliers) error, opts *Options, mults *Multipliers) ([]ScoringRule, []domain.Violation, error) {
+	opts.Rules = append([]string(nil), opts.Rules...)
+	opts.Caps.Rules = maps.Clone(opts.Caps.Rules)
+	if proposal != nil {
+		if err := proposal(opts, mults); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	violations := opts.Validate()
+	for i := range violations {
+		violations[i].Path = "/Options" + violations[i].Path
+	}
+	if len(violations) > 0 {
+		return nil, violations, nil
+	}
+	rules, err := DefaultRules.Build(*opts, *mults, opts.Rules)
+	return rules, nil, err
+}
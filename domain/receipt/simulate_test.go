@@ -0,0 +1,142 @@
+package receipt_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/kevin07696/receipt-processor/domain"
+	"github.com/kevin07696/receipt-processor/domain/receipt"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSimulateReceipt(t *testing.T) {
+	ctx := context.TODO()
+	opts := receipt.Options{GenerateID: func(input string) string { return input }}
+	liveOpts := receipt.Options{
+		StartPurchaseTime:   "14:00",
+		EndPurchaseTime:     "16:00",
+		TotalMultiple:       0.25,
+		ItemsMultiple:       2,
+		DescriptionMultiple: 3,
+		Rules:               []string{receipt.RuleRetailer},
+	}
+	liveMults := receipt.Multipliers{Retailer: 1, RoundTotal: 50}
+	ruleSets, err := receipt.NewRuleSets(receipt.SelectByPurchaseDate,
+		receipt.RuleSet{Version: "v1", Options: liveOpts, Multipliers: liveMults,
+			Overrides: []receipt.RetailerOverride{{Name: "partner", Retailers: []string{"Walmart"}, Options: liveOpts, Multipliers: liveMults, Bonus: 10}},
+		},
+	)
+	assert.NoError(t, err)
+	target := receipt.Receipt{Retailer: "Target", PurchaseDate: "2022-01-01", Total: "35.00"}
+	walmart := receipt.Receipt{Retailer: "Walmart", PurchaseDate: "2022-01-01", Total: "35.00"}
+
+	testCases := []struct {
+		title              string
+		receipt            receipt.Receipt
+		propose            func(opts *receipt.Options, mults *receipt.Multipliers) error
+		expectedStatus     domain.StatusCode
+		expectedViolations []domain.Violation
+		expectedLive       int64
+		expectedProposed   int64
+		expectedRules      []string
+	}{
+		{
+			title:            "GivenNoProposal_ReturnLiveScoreTwice",
+			expectedLive:     6,
+			expectedProposed: 6,
+			expectedRules:    []string{receipt.RuleRetailer},
+		},
+		{
+			title: "GivenAProposedMultiplier_ReturnProposedScore",
+			propose: func(opts *receipt.Options, mults *receipt.Multipliers) error {
+				mults.Retailer = 2
+				return nil
+			},
+			expectedLive:     6,
+			expectedProposed: 12,
+			expectedRules:    []string{receipt.RuleRetailer},
+		},
+		{
+			title: "GivenProposedRules_ScoreWithProposedRules",
+			propose: func(opts *receipt.Options, mults *receipt.Multipliers) error {
+				opts.Rules = append(opts.Rules, receipt.RuleRoundTotal)
+				return nil
+			},
+			expectedLive:     6,
+			expectedProposed: 56,
+			expectedRules:    []string{receipt.RuleRetailer, receipt.RuleRoundTotal},
+		},
+		{
+			title:   "GivenAProposedMultiplierForAnOverriddenRetailer_ScoreTheOverrideWithIt",
+			receipt: walmart,
+			propose: func(opts *receipt.Options, mults *receipt.Multipliers) error {
+				mults.Retailer = 2
+				return nil
+			},
+			expectedLive:     17,
+			expectedProposed: 24,
+			expectedRules:    []string{receipt.RuleRetailer, receipt.RuleRetailerOverride},
+		},
+		{
+			title: "GivenAnUnknownRule_ReturnBadRequestError",
+			propose: func(opts *receipt.Options, mults *receipt.Multipliers) error {
+				opts.Rules = []string{"unknown"}
+				return nil
+			},
+			expectedStatus:     domain.ErrBadRequest,
+			expectedViolations: []domain.Violation{{Path: "/Options/Rules/0", Rule: domain.RuleOneOf, Value: "unknown"}},
+		},
+		{
+			title: "GivenAStartPurchaseTimeAfterTheLiveEnd_ReturnEndViolation",
+			propose: func(opts *receipt.Options, mults *receipt.Multipliers) error {
+				opts.StartPurchaseTime = "17:00"
+				return nil
+			},
+			expectedStatus:     domain.ErrBadRequest,
+			expectedViolations: []domain.Violation{{Path: "/Options/EndPurchaseTime", Rule: domain.RuleMin, Value: "16:00"}},
+		},
+		{
+			title: "GivenAFailingProposal_ReturnBadRequestError",
+			propose: func(opts *receipt.Options, mults *receipt.Multipliers) error {
+				return errors.New("invalid")
+			},
+			expectedStatus: domain.ErrBadRequest,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.title, func(t *testing.T) {
+			repository := NewMapReceiptRepository()
+			services := receipt.NewReceiptProcessorServiceWithRuleSets(repository, opts, ruleSets)
+
+			request := receipt.ReceiptSimulationRequest{Receipt: target, Propose: tc.propose}
+			if tc.receipt.Retailer != "" {
+				request.Receipt = tc.receipt
+			}
+			response, status, violations := services.SimulateReceipt(ctx, request)
+			assert.Equal(t, tc.expectedStatus, status)
+			assert.Equal(t, tc.expectedViolations, violations)
+			assert.Empty(t, repository.Scores)
+			if status > 0 {
+				return
+			}
+
+			assert.Equal(t, "v1", response.RuleSetVersion)
+			assert.Equal(t, tc.expectedLive, response.Live.Points)
+			assert.Equal(t, tc.expectedProposed, response.Proposed.Points)
+			assert.Equal(t, tc.expectedProposed-tc.expectedLive, response.Difference)
+
+			var rules []string
+			for _, ruleScore := range response.Proposed.Breakdown {
+				rules = append(rules, ruleScore.Rule)
+			}
+			assert.Equal(t, tc.expectedRules, rules)
+		})
+	}
+
+	// Proposals must not leak into the live rule set
+	services := receipt.NewReceiptProcessorServiceWithRuleSets(NewMapReceiptRepository(), opts, ruleSets)
+	response, _, _ := services.SimulateReceipt(ctx, receipt.ReceiptSimulationRequest{Receipt: target})
+	assert.Len(t, response.Live.Breakdown, 1)
+}
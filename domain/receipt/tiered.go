@@ -0,0 +1,244 @@
+package receipt
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/kevin07696/receipt-processor/domain"
+)
+
+type WriteMode string
+
+const (
+	// WriteThrough stores a value in the cold tier before the write returns.
+	WriteThrough WriteMode = "through"
+	// WriteBehind returns once the hot tier has a value and stores it in the cold tier in the background.
+	WriteBehind WriteMode = "behind"
+)
+
+type TierOptions struct {
+	WriteMode WriteMode
+	// QueueSize bounds the writes waiting for the cold tier in write behind mode. A full queue blocks writers.
+	QueueSize int
+	// Retries is how many more times a failed write behind is tried, waiting RetryBackoff before the first retry and
+	// twice as long before each next one. A write that still fails stays pending, so it is read from memory until a
+	// newer write of its ID replaces it, and Close reports it.
+	Retries      int
+	RetryBackoff time.Duration
+	// Negative remembers IDs the cold tier does not have, so repeated lookups of unknown IDs skip it.
+	// It holds true for an unknown ID and false once the ID is written. nil disables negative caching.
+	Negative IRepository
+}
+
+// TieredRepository puts a fast hot repository in front of a durable cold one.
+// Reads fall through to the cold tier on a miss and fill the hot tier, writes go to both tiers.
+type TieredRepository struct {
+	hot     IRepository
+	cold    IRepository
+	opts    TierOptions
+	mu      sync.Mutex
+	pending map[string]tieredWrite
+	seq     uint64
+	// queueMu is held for reading while sending to queue, so Close does not close it during a send
+	queueMu sync.RWMutex
+	queue   chan tieredWrite
+	done    chan struct{}
+	closed  bool
+	failed  atomic.Uint64
+}
+
+// TierStats are the write behind values that have not reached the cold tier and the writes that failed every retry.
+type TierStats struct {
+	Pending  int
+	Failures uint64
+}
+
+func (r *TieredRepository) Stats() TierStats {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return TierStats{Pending: len(r.pending), Failures: r.failed.Load()}
+}
+
+type tieredWrite struct {
+	id    string
+	seq   uint64
+	value interface{}
+}
+
+func NewTieredRepository(hot IRepository, cold IRepository, opts TierOptions) *TieredRepository {
+	r := &TieredRepository{
+		hot:     hot,
+		cold:    cold,
+		opts:    opts,
+		pending: map[string]tieredWrite{},
+	}
+	if opts.WriteMode == WriteBehind {
+		r.queue = make(chan tieredWrite, max(opts.QueueSize, 1))
+		r.done = make(chan struct{})
+		go r.writeBehind()
+	}
+	return r
+}
+
+func (r *TieredRepository) Get(ctx context.Context, id string) (interface{}, domain.StatusCode) {
+	if value, status := r.hot.Get(ctx, id); status == domain.StatusOK {
+		return value, status
+	}
+
+	// A write behind value is only in the hot tier until it reaches the cold tier, and the hot tier may have evicted it
+	r.mu.Lock()
+	write, ok := r.pending[id]
+	r.mu.Unlock()
+	if ok {
+		return write.value, domain.StatusOK
+	}
+
+	if r.opts.Negative != nil {
+		if unknown, status := r.opts.Negative.Get(ctx, id); status == domain.StatusOK && unknown.(bool) {
+			return nil, domain.ErrNotFound
+		}
+	}
+
+	value, status := r.cold.Get(ctx, id)
+	switch status {
+	case domain.StatusOK:
+		r.hot.Set(ctx, id, value)
+	case domain.ErrNotFound:
+		if r.opts.Negative != nil {
+			r.opts.Negative.Set(ctx, id, true)
+		}
+	}
+	return value, status
+}
+
+func (r *TieredRepository) Set(ctx context.Context, id string, value interface{}) domain.StatusCode {
+	if r.opts.Negative != nil {
+		r.opts.Negative.Set(ctx, id, false)
+	}
+
+	if r.opts.WriteMode != WriteBehind {
+		if status := r.cold.Set(ctx, id, value); status > 0 {
+			return status
+		}
+		return r.hot.Set(ctx, id, value)
+	}
+
+	r.queueMu.RLock()
+	defer r.queueMu.RUnlock()
+	if r.closed {
+		slog.ErrorContext(ctx, "Write Error: Tiered repository is closed.", slog.String("id", id))
+		return domain.ErrInternal
+	}
+
+	r.mu.Lock()
+	r.seq++
+	write := tieredWrite{id: id, seq: r.seq, value: value}
+	r.pending[id] = write
+	r.mu.Unlock()
+
+	if status := r.hot.Set(ctx, id, value); status > 0 {
+		slog.WarnContext(ctx, "Failed to write hot tier, the value is still written to the cold tier.", slog.String("id", id))
+	}
+
+	select {
+	case r.queue <- write:
+		return domain.StatusOK
+	case <-ctx.Done():
+		r.forget(write)
+		slog.ErrorContext(ctx, "Write Error: Timed out waiting for the write behind queue.", slog.String("id", id), slog.Any("error", ctx.Err()))
+		return domain.ErrInternal
+	}
+}
+
+// Keys lists the keys of the cold tier and the write behind values that have not reached it yet.
+func (r *TieredRepository) Keys(ctx context.Context) ([]string, domain.StatusCode) {
+	lister, ok := r.cold.(IKeyLister)
+	if !ok {
+		slog.ErrorContext(ctx, "List Error: Cold tier can not list keys.", slog.String("repository", fmt.Sprintf("%T", r.cold)))
+		return nil, domain.ErrInternal
+	}
+	keys, status := lister.Keys(ctx)
+	if status > 0 {
+		return nil, status
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if len(r.pending) == 0 {
+		return keys, domain.StatusOK
+	}
+	seen := make(map[string]bool, len(keys))
+	for _, key := range keys {
+		seen[key] = true
+	}
+	for key := range r.pending {
+		if !seen[key] {
+			keys = append(keys, key)
+		}
+	}
+	return keys, domain.StatusOK
+}
+
+// forget drops a pending write unless a newer write of the same ID replaced it.
+func (r *TieredRepository) forget(write tieredWrite) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.pending[write.id].seq == write.seq {
+		delete(r.pending, write.id)
+	}
+}
+
+func (r *TieredRepository) writeBehind() {
+	defer close(r.done)
+	for write := range r.queue {
+		ctx := context.Background()
+		status := r.cold.Set(ctx, write.id, write.value)
+		backoff := r.opts.RetryBackoff
+		for retry := 0; status > 0 && retry < r.opts.Retries; retry++ {
+			slog.WarnContext(ctx, "Failed to write behind to the cold tier, retrying.", slog.String("id", write.id), slog.Any("status", domain.ErrorToCodes[status].Name), slog.Duration("backoff", backoff))
+			time.Sleep(backoff)
+			backoff *= 2
+			status = r.cold.Set(ctx, write.id, write.value)
+		}
+		if status > 0 {
+			r.failed.Add(1)
+			slog.ErrorContext(ctx, "Write Error: Failed to write behind to the cold tier, the value stays pending.", slog.String("id", write.id), slog.Any("status", domain.ErrorToCodes[status].Name))
+			continue
+		}
+
+		r.forget(write)
+	}
+}
+
+// Close waits until every write behind value is in the cold tier or failed, or ctx is done.
+func (r *TieredRepository) Close(ctx context.Context) error {
+	if r.opts.WriteMode != WriteBehind {
+		return nil
+	}
+
+	r.queueMu.Lock()
+	if !r.closed {
+		r.closed = true
+		close(r.queue)
+	}
+	r.queueMu.Unlock()
+
+	select {
+	case <-r.done:
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		if len(r.pending) > 0 {
+			return fmt.Errorf("%d writes did not reach the cold tier", len(r.pending))
+		}
+		return nil
+	case <-ctx.Done():
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		return errors.Join(ctx.Err(), fmt.Errorf("%d writes did not reach the cold tier", len(r.pending)))
+	}
+}
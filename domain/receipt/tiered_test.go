@@ -0,0 +1,220 @@
+package receipt_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/kevin07696/receipt-processor/domain"
+	"github.com/kevin07696/receipt-processor/domain/receipt"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTieredRepositoryGet(t *testing.T) {
+	ctx := context.Background()
+	record := receipt.ScoreRecord{Points: 28}
+
+	testCases := []struct {
+		title          string
+		hot            map[string]interface{}
+		cold           map[string]interface{}
+		negative       bool
+		gets           int
+		expectedStatus domain.StatusCode
+		expectedValue  interface{}
+		expectedHot    int
+		expectedCold   int
+	}{
+		{
+			title:          "GivenAHotValue_SkipColdTier",
+			hot:            map[string]interface{}{"id": record},
+			cold:           map[string]interface{}{},
+			gets:           2,
+			expectedStatus: domain.StatusOK,
+			expectedValue:  record,
+			expectedHot:    1,
+			expectedCold:   0,
+		},
+		{
+			title:          "GivenAColdValue_ReadThroughAndFillHotTier",
+			hot:            map[string]interface{}{},
+			cold:           map[string]interface{}{"id": record},
+			gets:           2,
+			expectedStatus: domain.StatusOK,
+			expectedValue:  record,
+			expectedHot:    1,
+			expectedCold:   1,
+		},
+		{
+			title:          "GivenAnUnknownIDWithoutNegativeCache_AskColdTierEveryTime",
+			hot:            map[string]interface{}{},
+			cold:           map[string]interface{}{},
+			gets:           3,
+			expectedStatus: domain.ErrNotFound,
+			expectedCold:   3,
+		},
+		{
+			title:          "GivenAnUnknownIDWithNegativeCache_AskColdTierOnce",
+			hot:            map[string]interface{}{},
+			cold:           map[string]interface{}{},
+			negative:       true,
+			gets:           3,
+			expectedStatus: domain.ErrNotFound,
+			expectedCold:   1,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.title, func(t *testing.T) {
+			hot, cold := NewMockRepository(), NewMockRepository()
+			hot.Values, cold.Values = tc.hot, tc.cold
+			opts := receipt.TierOptions{WriteMode: receipt.WriteThrough}
+			if tc.negative {
+				opts.Negative = NewMockRepository()
+			}
+			repository := receipt.NewTieredRepository(hot, cold, opts)
+
+			var value interface{}
+			var status domain.StatusCode
+			for i := 0; i < tc.gets; i++ {
+				value, status = repository.Get(ctx, "id")
+			}
+
+			assert.Equal(t, tc.expectedStatus, status)
+			assert.Equal(t, tc.expectedValue, value)
+			assert.Equal(t, tc.expectedHot, hot.Len())
+			assert.Equal(t, tc.expectedCold, cold.Gets)
+		})
+	}
+}
+
+func TestTieredRepositoryNegativeCacheClearedByWrite(t *testing.T) {
+	ctx := context.Background()
+	hot, cold := NewMockRepository(), NewMockRepository()
+	repository := receipt.NewTieredRepository(hot, cold, receipt.TierOptions{WriteMode: receipt.WriteThrough, Negative: NewMockRepository()})
+
+	_, status := repository.Get(ctx, "id")
+	assert.Equal(t, domain.ErrNotFound, status)
+
+	assert.Equal(t, domain.StatusOK, repository.Set(ctx, "id", int64(5)))
+	// Evicted from the hot tier, so the read has to get past the negative cache
+	delete(hot.Values, "id")
+
+	value, status := repository.Get(ctx, "id")
+	assert.Equal(t, domain.StatusOK, status)
+	assert.Equal(t, int64(5), value)
+}
+
+func TestTieredRepositoryWriteThrough(t *testing.T) {
+	ctx := context.Background()
+
+	testCases := []struct {
+		title          string
+		coldErr        domain.StatusCode
+		expectedStatus domain.StatusCode
+		expectedHot    int
+		expectedCold   int
+	}{
+		{
+			title:          "GivenAWrite_StoreInBothTiers",
+			expectedStatus: domain.StatusOK,
+			expectedHot:    1,
+			expectedCold:   1,
+		},
+		{
+			title:          "GivenAFailingColdTier_ReturnErrorAndSkipHotTier",
+			coldErr:        domain.ErrInternal,
+			expectedStatus: domain.ErrInternal,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.title, func(t *testing.T) {
+			hot, cold := NewMockRepository(), NewMockRepository()
+			cold.SetErr = tc.coldErr
+			repository := receipt.NewTieredRepository(hot, cold, receipt.TierOptions{WriteMode: receipt.WriteThrough})
+
+			assert.Equal(t, tc.expectedStatus, repository.Set(ctx, "id", int64(5)))
+			assert.Equal(t, tc.expectedHot, hot.Len())
+			assert.Equal(t, tc.expectedCold, cold.Len())
+			assert.NoError(t, repository.Close(ctx))
+		})
+	}
+}
+
+func TestTieredRepositoryWriteBehind(t *testing.T) {
+	ctx := context.Background()
+	hot, cold := NewMockRepository(), NewMockRepository()
+	cold.Blocked = make(chan struct{})
+	repository := receipt.NewTieredRepository(hot, cold, receipt.TierOptions{WriteMode: receipt.WriteBehind, QueueSize: 4})
+
+	record := receipt.ScoreRecord{Points: 28, Breakdown: []receipt.RuleScore{{Rule: receipt.RuleRetailer, Points: 6}}}
+	assert.Equal(t, domain.StatusOK, repository.Set(ctx, "id", record))
+	assert.Equal(t, 1, hot.Len())
+	assert.Equal(t, 0, cold.Len())
+
+	// Evicted from the hot tier before the cold write finished, the pending value is still readable
+	delete(hot.Values, "id")
+	value, status := repository.Get(ctx, "id")
+	assert.Equal(t, domain.StatusOK, status)
+	assert.Equal(t, record, value)
+
+	close(cold.Blocked)
+	assert.NoError(t, repository.Close(ctx))
+	assert.Equal(t, 1, cold.Len())
+	assert.Equal(t, domain.ErrInternal, repository.Set(ctx, "other", record))
+}
+
+func TestTieredRepositoryWriteBehindRetries(t *testing.T) {
+	testCases := []struct {
+		title            string
+		retries          int
+		fails            int
+		expectedSets     int
+		expectedStats    receipt.TierStats
+		expectedCloseErr bool
+	}{
+		{title: "GivenASetThatRecovers_WriteTheColdTier", retries: 2, fails: 2, expectedSets: 3},
+		{title: "GivenASetThatKeepsFailing_KeepTheValuePending", retries: 2, fails: 3, expectedSets: 3,
+			expectedStats: receipt.TierStats{Pending: 1, Failures: 1}, expectedCloseErr: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.title, func(t *testing.T) {
+			ctx := context.Background()
+			hot, cold := NewMockRepository(), NewMockRepository()
+			cold.SetErr = domain.ErrInternal
+			cold.SetErrs = tc.fails
+			repository := receipt.NewTieredRepository(hot, cold, receipt.TierOptions{WriteMode: receipt.WriteBehind, QueueSize: 4, Retries: tc.retries, RetryBackoff: time.Millisecond})
+
+			assert.Equal(t, domain.StatusOK, repository.Set(ctx, "id", int64(5)))
+			err := repository.Close(ctx)
+			if tc.expectedCloseErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, tc.expectedSets, cold.Sets)
+			assert.Equal(t, tc.expectedStats, repository.Stats())
+
+			// Evicted from the hot tier, a value that never reached the cold tier is still readable
+			delete(hot.Values, "id")
+			value, status := repository.Get(ctx, "id")
+			assert.Equal(t, domain.StatusOK, status)
+			assert.Equal(t, int64(5), value)
+		})
+	}
+}
+
+func TestTieredRepositoryCloseTimeout(t *testing.T) {
+	hot, cold := NewMockRepository(), NewMockRepository()
+	cold.Blocked = make(chan struct{})
+	defer close(cold.Blocked)
+	repository := receipt.NewTieredRepository(hot, cold, receipt.TierOptions{WriteMode: receipt.WriteBehind, QueueSize: 4})
+
+	repository.Set(context.Background(), "id", int64(5))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, repository.Close(ctx), context.DeadlineExceeded)
+}
@@ -11,6 +11,7 @@ const (
 	ErrNotFound   StatusCode = 1
 	ErrBadRequest StatusCode = 2
 	ErrInternal   StatusCode = 3
+	ErrConflict   StatusCode = 4
 )
 
 type StatusMessage struct {
@@ -24,4 +25,5 @@ var ErrorToCodes = []StatusMessage{
 	{Code: http.StatusNotFound, Name: "ErrNotFound", Message: "No receipt found for that ID."},
 	{Code: http.StatusBadRequest, Name: "ErrBadRequest", Message: "The receipt is invalid."},
 	{Code: http.StatusInternalServerError, Name: "ErrInternalServer", Message: "Internal services have failed"},
+	{Code: http.StatusConflict, Name: "ErrConflict", Message: "The request conflicts with work already in progress."},
 }
@@ -0,0 +1,27 @@
+package domain
+
+// Violation describes a field that failed validation.
+type Violation struct {
+	// Path is a JSON pointer to the field in the request body, e.g. /items/0/price
+	Path  string `json:"path"`
+	Rule  string `json:"rule"`
+	Value string `json:"value"`
+}
+
+// Names of the validation rules reported in a Violation.
+const (
+	RuleRequired = "required"
+	RulePattern  = "pattern"
+	RuleMin      = "min"
+	RuleMax      = "max"
+	RuleType     = "type"
+	RuleOneOf    = "oneof"
+	// RuleSum is broken by a total that is not the sum of its parts.
+	RuleSum = "sum"
+	// RuleDate is broken by a date that does not exist, e.g. 2023-02-31.
+	RuleDate = "date"
+	// RuleFuture is broken by a date that has not happened yet.
+	RuleFuture = "future"
+	// RuleUnique is broken by a value that another resource already uses.
+	RuleUnique = "unique"
+)
@@ -0,0 +1,85 @@
+package admin
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+
+	"github.com/kevin07696/receipt-processor/domain"
+	"github.com/kevin07696/receipt-processor/domain/receipt"
+	"github.com/kevin07696/receipt-processor/handlers"
+)
+
+// Campaigns is the registry of the campaigns evaluated after the scoring rules.
+type Campaigns interface {
+	List() []receipt.Campaign
+	Get(id string) (receipt.Campaign, domain.StatusCode)
+	Put(ctx context.Context, campaign receipt.Campaign) ([]domain.Violation, domain.StatusCode)
+	Delete(ctx context.Context, id string) domain.StatusCode
+}
+
+type CampaignRequest struct {
+	Name   string
+	Start  string
+	End    string
+	Match  receipt.CampaignMatch
+	Bonus  int64
+	Factor float64
+}
+
+// ListCampaigns returns every campaign of the registry ordered by ID.
+func ListCampaigns(campaigns Campaigns) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, r, http.StatusOK, campaigns.List())
+	}
+}
+
+func GetCampaign(campaigns Campaigns) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		campaign, status := campaigns.Get(r.PathValue("id"))
+		if status > 0 {
+			handlers.WriteProblem(w, r, status)
+			return
+		}
+		writeJSON(w, r, http.StatusOK, campaign)
+	}
+}
+
+// PutCampaign adds the campaign of the path or replaces it. Scores are not changed until receipts are re-scored.
+func PutCampaign(campaigns Campaigns) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var request CampaignRequest
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+			slog.DebugContext(r.Context(), "Unmarshal Error: Failed to unmarshal campaign request.", slog.Any("error", err))
+			handlers.WriteProblem(w, r, domain.ErrBadRequest)
+			return
+		}
+
+		campaign := receipt.Campaign{
+			ID:     r.PathValue("id"),
+			Name:   request.Name,
+			Start:  request.Start,
+			End:    request.End,
+			Match:  request.Match,
+			Bonus:  request.Bonus,
+			Factor: request.Factor,
+		}
+		violations, status := campaigns.Put(r.Context(), campaign)
+		if status > 0 {
+			handlers.WriteProblem(w, r, status, violations...)
+			return
+		}
+		writeJSON(w, r, http.StatusOK, campaign)
+	}
+}
+
+func DeleteCampaign(campaigns Campaigns) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if status := campaigns.Delete(r.Context(), r.PathValue("id")); status > 0 {
+			handlers.WriteProblem(w, r, status)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
@@ -0,0 +1,113 @@
+package admin_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/kevin07696/receipt-processor/domain"
+	"github.com/kevin07696/receipt-processor/domain/receipt"
+	"github.com/kevin07696/receipt-processor/handlers/admin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCampaigns(t *testing.T) {
+	doritos := receipt.Campaign{
+		ID: "doritos", Name: "Doritos", Start: "2024-01-01", End: "2024-12-31",
+		Match: receipt.CampaignMatch{Items: []string{"Doritos"}}, Bonus: 100,
+	}
+
+	testCases := []struct {
+		title              string
+		method             string
+		path               string
+		body               string
+		expectedCode       int
+		expectedCampaigns  []receipt.Campaign
+		expectedViolations []domain.Violation
+	}{
+		{
+			title:             "GivenAListRequest_ReturnCampaigns",
+			method:            http.MethodGet,
+			path:              "/campaigns",
+			expectedCode:      http.StatusOK,
+			expectedCampaigns: []receipt.Campaign{doritos},
+		},
+		{
+			title:             "GivenAnUnknownID_ReturnNotFoundError",
+			method:            http.MethodGet,
+			path:              "/campaigns/other",
+			expectedCode:      http.StatusNotFound,
+			expectedCampaigns: []receipt.Campaign{doritos},
+		},
+		{
+			title:        "GivenANewCampaign_AddIt",
+			method:       http.MethodPut,
+			path:         "/campaigns/december-weekends",
+			body:         `{"Name": "December weekends", "Start": "2024-12-01", "End": "2024-12-31", "Match": {"Weekdays": ["saturday", "sunday"]}, "Factor": 2}`,
+			expectedCode: http.StatusOK,
+			expectedCampaigns: []receipt.Campaign{
+				{ID: "december-weekends", Name: "December weekends", Start: "2024-12-01", End: "2024-12-31", Match: receipt.CampaignMatch{Weekdays: []string{"saturday", "sunday"}}, Factor: 2},
+				doritos,
+			},
+		},
+		{
+			title:             "GivenACampaignWithoutEffect_ReturnBadRequestError",
+			method:            http.MethodPut,
+			path:              "/campaigns/doritos",
+			body:              `{"Name": "Doritos", "Start": "2024-01-01", "End": "2024-12-31"}`,
+			expectedCode:      http.StatusBadRequest,
+			expectedCampaigns: []receipt.Campaign{doritos},
+			expectedViolations: []domain.Violation{
+				{Path: "/Bonus", Rule: domain.RuleRequired, Value: "0"},
+			},
+		},
+		{
+			title:             "GivenInvalidJSON_ReturnBadRequestError",
+			method:            http.MethodPut,
+			path:              "/campaigns/doritos",
+			body:              `{`,
+			expectedCode:      http.StatusBadRequest,
+			expectedCampaigns: []receipt.Campaign{doritos},
+		},
+		{
+			title:             "GivenADeleteRequest_RemoveCampaign",
+			method:            http.MethodDelete,
+			path:              "/campaigns/doritos",
+			expectedCode:      http.StatusNoContent,
+			expectedCampaigns: []receipt.Campaign{},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.title, func(t *testing.T) {
+			registry, err := receipt.NewCampaignRegistry(nil, doritos)
+			assert.NoError(t, err)
+
+			router := http.NewServeMux()
+			router.HandleFunc("GET /campaigns", admin.ListCampaigns(registry))
+			router.HandleFunc("GET /campaigns/{id}", admin.GetCampaign(registry))
+			router.HandleFunc("PUT /campaigns/{id}", admin.PutCampaign(registry))
+			router.HandleFunc("DELETE /campaigns/{id}", admin.DeleteCampaign(registry))
+
+			request, err := http.NewRequest(tc.method, tc.path, bytes.NewBufferString(tc.body))
+			if err != nil {
+				t.Fatalf("Failed to build request: %v", err)
+			}
+
+			responseRecorder := httptest.NewRecorder()
+			router.ServeHTTP(responseRecorder, request)
+
+			assert.Equal(t, tc.expectedCode, responseRecorder.Code)
+			assert.Equal(t, tc.expectedCampaigns, registry.List())
+
+			if tc.expectedViolations != nil {
+				var problem struct{ Violations []domain.Violation }
+				assert.NoError(t, json.Unmarshal(responseRecorder.Body.Bytes(), &problem))
+				assert.Equal(t, tc.expectedViolations, problem.Violations)
+			}
+		})
+	}
+}
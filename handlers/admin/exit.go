@@ -4,14 +4,22 @@ import (
 	"fmt"
 	"log/slog"
 	"net/http"
-	"os"
 	"strconv"
 	"strings"
 
 	"github.com/kevin07696/receipt-processor/domain"
+	"github.com/kevin07696/receipt-processor/handlers"
 )
 
-func Exit() http.HandlerFunc {
+// Lifecycle ends the process gracefully once in-flight requests are done.
+type Lifecycle interface {
+	Shutdown(code int)
+	Restart()
+}
+
+// Exit requests a graceful shutdown with the exit code of the path.
+// With ?restart=true the process restarts in place instead and the code is ignored.
+func Exit(lifecycle Lifecycle) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		path := r.URL.Path
 		path = strings.Trim(path, "/")
@@ -21,11 +29,13 @@ func Exit() http.HandlerFunc {
 		code, err := strconv.Atoi(param)
 		if err != nil {
 			slog.Debug("StatusBadRequest: uuid is invalid", slog.String("code", param), slog.Any("error", err))
-			http.Error(w, domain.ErrorToCodes[domain.ErrBadRequest].Message, domain.ErrorToCodes[domain.ErrBadRequest].Code)
+			handlers.WriteProblem(w, r, domain.ErrBadRequest)
 			return
 		}
 
-		slog.Debug(fmt.Sprintf("exit code %d", code))
+		restart := r.URL.Query().Get("restart") == "true"
+
+		slog.Debug(fmt.Sprintf("exit code %d, restart %t", code, restart))
 		w.WriteHeader(http.StatusOK)
 		w.Write([]byte("OK"))
 
@@ -34,8 +44,11 @@ func Exit() http.HandlerFunc {
 			flusher.Flush()
 		}
 
-		go func(exitCode int) {
-			os.Exit(exitCode)
-		}(code)
+		// The server drains this request before the process ends
+		if restart {
+			lifecycle.Restart()
+		} else {
+			lifecycle.Shutdown(code)
+		}
 	}
 }
@@ -0,0 +1,70 @@
+package admin_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/kevin07696/receipt-processor/handlers/admin"
+	"github.com/stretchr/testify/assert"
+)
+
+type MockLifecycle struct {
+	ShutdownCodes []int
+	Restarts      int
+}
+
+func (m *MockLifecycle) Shutdown(code int) {
+	m.ShutdownCodes = append(m.ShutdownCodes, code)
+}
+
+func (m *MockLifecycle) Restart() {
+	m.Restarts++
+}
+
+func TestExit(t *testing.T) {
+	testCases := []struct {
+		title                 string
+		url                   string
+		expectedCode          int
+		expectedShutdownCodes []int
+		expectedRestarts      int
+	}{
+		{
+			title:                 "GivenAnExitCode_RequestShutdown",
+			url:                   "/exit/3",
+			expectedCode:          http.StatusOK,
+			expectedShutdownCodes: []int{3},
+		},
+		{
+			title:            "GivenRestart_RequestRestart",
+			url:              "/exit/0?restart=true",
+			expectedCode:     http.StatusOK,
+			expectedRestarts: 1,
+		},
+		{
+			title:        "GivenAnInvalidExitCode_ReturnBadRequestError",
+			url:          "/exit/abc",
+			expectedCode: http.StatusBadRequest,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.title, func(t *testing.T) {
+			lifecycle := &MockLifecycle{}
+			handler := admin.Exit(lifecycle)
+
+			request, err := http.NewRequest(http.MethodGet, tc.url, nil)
+			if err != nil {
+				t.Fatalf("Failed to build request: %v", err)
+			}
+
+			responseRecorder := httptest.NewRecorder()
+			handler.ServeHTTP(responseRecorder, request)
+
+			assert.Equal(t, tc.expectedCode, responseRecorder.Code)
+			assert.Equal(t, tc.expectedShutdownCodes, lifecycle.ShutdownCodes)
+			assert.Equal(t, tc.expectedRestarts, lifecycle.Restarts)
+		})
+	}
+}
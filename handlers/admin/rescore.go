@@ -0,0 +1,67 @@
+package admin
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+
+	"github.com/kevin07696/receipt-processor/domain"
+	"github.com/kevin07696/receipt-processor/domain/receipt"
+	"github.com/kevin07696/receipt-processor/handlers"
+)
+
+// Rescorer runs the re-score job of stored receipts.
+type Rescorer interface {
+	Start(version string) (receipt.RescoreStatus, domain.StatusCode)
+	Status() receipt.RescoreStatus
+	Cancel() receipt.RescoreStatus
+}
+
+type RescoreRequest struct {
+	Version string
+}
+
+// StartRescore starts re-scoring every stored receipt with the rule set version of the body.
+func StartRescore(rescorer Rescorer) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var request RescoreRequest
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+			slog.DebugContext(r.Context(), "Unmarshal Error: Failed to unmarshal rescore request.", slog.Any("error", err))
+			handlers.WriteProblem(w, r, domain.ErrBadRequest)
+			return
+		}
+		if request.Version == "" {
+			handlers.WriteProblem(w, r, domain.ErrBadRequest, domain.Violation{Path: "/Version", Rule: domain.RuleRequired})
+			return
+		}
+
+		status, code := rescorer.Start(request.Version)
+		if code == domain.ErrBadRequest {
+			handlers.WriteProblem(w, r, code, domain.Violation{Path: "/Version", Rule: domain.RuleOneOf, Value: request.Version})
+			return
+		}
+		if code > 0 {
+			handlers.WriteProblem(w, r, code)
+			return
+		}
+		writeRescoreStatus(w, r, http.StatusAccepted, status)
+	}
+}
+
+// GetRescore reports the progress of the latest re-score job.
+func GetRescore(rescorer Rescorer) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		writeRescoreStatus(w, r, http.StatusOK, rescorer.Status())
+	}
+}
+
+// CancelRescore stops a running re-score job. Receipts it already scored keep their new score.
+func CancelRescore(rescorer Rescorer) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		writeRescoreStatus(w, r, http.StatusOK, rescorer.Cancel())
+	}
+}
+
+func writeRescoreStatus(w http.ResponseWriter, r *http.Request, code int, status receipt.RescoreStatus) {
+	writeJSON(w, r, code, status)
+}
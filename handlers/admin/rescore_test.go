@@ -0,0 +1,137 @@
+package admin_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/kevin07696/receipt-processor/domain"
+	"github.com/kevin07696/receipt-processor/domain/receipt"
+	"github.com/kevin07696/receipt-processor/handlers/admin"
+	"github.com/stretchr/testify/assert"
+)
+
+type MockRescorer struct {
+	StartCode domain.StatusCode
+	Versions  []string
+	Cancels   int
+}
+
+func (m *MockRescorer) Start(version string) (receipt.RescoreStatus, domain.StatusCode) {
+	m.Versions = append(m.Versions, version)
+	if m.StartCode > 0 {
+		return receipt.RescoreStatus{}, m.StartCode
+	}
+	return receipt.RescoreStatus{State: receipt.RescoreRunning, Version: version}, domain.StatusOK
+}
+
+func (m *MockRescorer) Status() receipt.RescoreStatus {
+	return receipt.RescoreStatus{State: receipt.RescoreCompleted, Version: "v2", Total: 2, Done: 2}
+}
+
+func (m *MockRescorer) Cancel() receipt.RescoreStatus {
+	m.Cancels++
+	return receipt.RescoreStatus{State: receipt.RescoreCancelled, Version: "v2", Total: 2, Done: 1}
+}
+
+func TestRescore(t *testing.T) {
+	testCases := []struct {
+		title            string
+		method           string
+		body             string
+		startCode        domain.StatusCode
+		expectedCode     int
+		expectedState    receipt.RescoreState
+		expectedVersions []string
+		expectedCancels  int
+		// expectedViolations are checked when they are set
+		expectedViolations []domain.Violation
+	}{
+		{
+			title:            "GivenAVersion_StartJob",
+			method:           http.MethodPost,
+			body:             `{"Version":"v2"}`,
+			expectedCode:     http.StatusAccepted,
+			expectedState:    receipt.RescoreRunning,
+			expectedVersions: []string{"v2"},
+		},
+		{
+			title:        "GivenNoVersion_ReturnBadRequestError",
+			method:       http.MethodPost,
+			body:         `{}`,
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			title:        "GivenInvalidJSON_ReturnBadRequestError",
+			method:       http.MethodPost,
+			body:         `{`,
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			title:            "GivenARunningJob_ReturnConflictError",
+			method:           http.MethodPost,
+			body:             `{"Version":"v2"}`,
+			startCode:        domain.ErrConflict,
+			expectedCode:     http.StatusConflict,
+			expectedVersions: []string{"v2"},
+		},
+		{
+			title:              "GivenAnUnknownVersion_ReturnBadRequestError",
+			method:             http.MethodPost,
+			body:               `{"Version":"v3"}`,
+			startCode:          domain.ErrBadRequest,
+			expectedCode:       http.StatusBadRequest,
+			expectedVersions:   []string{"v3"},
+			expectedViolations: []domain.Violation{{Path: "/Version", Rule: domain.RuleOneOf, Value: "v3"}},
+		},
+		{
+			title:         "GivenAStatusRequest_ReturnProgress",
+			method:        http.MethodGet,
+			expectedCode:  http.StatusOK,
+			expectedState: receipt.RescoreCompleted,
+		},
+		{
+			title:           "GivenACancelRequest_CancelJob",
+			method:          http.MethodDelete,
+			expectedCode:    http.StatusOK,
+			expectedState:   receipt.RescoreCancelled,
+			expectedCancels: 1,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.title, func(t *testing.T) {
+			rescorer := &MockRescorer{StartCode: tc.startCode}
+			handlers := map[string]http.HandlerFunc{
+				http.MethodPost:   admin.StartRescore(rescorer),
+				http.MethodGet:    admin.GetRescore(rescorer),
+				http.MethodDelete: admin.CancelRescore(rescorer),
+			}
+
+			request, err := http.NewRequest(tc.method, "/rescore", bytes.NewBufferString(tc.body))
+			if err != nil {
+				t.Fatalf("Failed to build request: %v", err)
+			}
+
+			responseRecorder := httptest.NewRecorder()
+			handlers[tc.method].ServeHTTP(responseRecorder, request)
+
+			assert.Equal(t, tc.expectedCode, responseRecorder.Code)
+			assert.Equal(t, tc.expectedVersions, rescorer.Versions)
+			assert.Equal(t, tc.expectedCancels, rescorer.Cancels)
+
+			if tc.expectedViolations != nil {
+				var problem struct{ Violations []domain.Violation }
+				assert.NoError(t, json.Unmarshal(responseRecorder.Body.Bytes(), &problem))
+				assert.Equal(t, tc.expectedViolations, problem.Violations)
+			}
+			if tc.expectedState != "" {
+				var status receipt.RescoreStatus
+				assert.NoError(t, json.Unmarshal(responseRecorder.Body.Bytes(), &status))
+				assert.Equal(t, tc.expectedState, status.State)
+			}
+		})
+	}
+}
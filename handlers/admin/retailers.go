@@ -0,0 +1,85 @@
+package admin
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+
+	"github.com/kevin07696/receipt-processor/domain"
+	"github.com/kevin07696/receipt-processor/domain/receipt"
+	"github.com/kevin07696/receipt-processor/handlers"
+)
+
+// Retailers is the registry that maps retailer aliases to canonical retailers.
+type Retailers interface {
+	List() []receipt.Retailer
+	Get(id string) (receipt.Retailer, domain.StatusCode)
+	Put(ctx context.Context, retailer receipt.Retailer) ([]domain.Violation, domain.StatusCode)
+	Delete(ctx context.Context, id string) domain.StatusCode
+}
+
+type RetailerRequest struct {
+	Name    string
+	Aliases []string
+}
+
+// ListRetailers returns every retailer of the registry ordered by ID.
+func ListRetailers(retailers Retailers) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, r, http.StatusOK, retailers.List())
+	}
+}
+
+func GetRetailer(retailers Retailers) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		retailer, status := retailers.Get(r.PathValue("id"))
+		if status > 0 {
+			handlers.WriteProblem(w, r, status)
+			return
+		}
+		writeJSON(w, r, http.StatusOK, retailer)
+	}
+}
+
+// PutRetailer adds the retailer of the path or replaces its name and aliases. Scores are not changed until receipts are re-scored.
+func PutRetailer(retailers Retailers) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var request RetailerRequest
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+			slog.DebugContext(r.Context(), "Unmarshal Error: Failed to unmarshal retailer request.", slog.Any("error", err))
+			handlers.WriteProblem(w, r, domain.ErrBadRequest)
+			return
+		}
+
+		retailer := receipt.Retailer{ID: r.PathValue("id"), Name: request.Name, Aliases: request.Aliases}
+		violations, status := retailers.Put(r.Context(), retailer)
+		if status > 0 {
+			handlers.WriteProblem(w, r, status, violations...)
+			return
+		}
+		writeJSON(w, r, http.StatusOK, retailer)
+	}
+}
+
+func DeleteRetailer(retailers Retailers) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if status := retailers.Delete(r.Context(), r.PathValue("id")); status > 0 {
+			handlers.WriteProblem(w, r, status)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func writeJSON(w http.ResponseWriter, r *http.Request, code int, value any) {
+	jsonResponse, err := json.Marshal(value)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Marshal Error: Failed to marshal response.", slog.Any("error", err))
+		handlers.WriteProblem(w, r, domain.ErrInternal)
+		return
+	}
+
+	w.WriteHeader(code)
+	w.Write(jsonResponse)
+}
@@ -0,0 +1,117 @@
+package admin_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/kevin07696/receipt-processor/domain"
+	"github.com/kevin07696/receipt-processor/domain/receipt"
+	"github.com/kevin07696/receipt-processor/handlers/admin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRetailers(t *testing.T) {
+	target := receipt.Retailer{ID: "target", Name: "Target", Aliases: []string{"Target Store"}}
+
+	testCases := []struct {
+		title              string
+		method             string
+		path               string
+		body               string
+		expectedCode       int
+		expectedRetailers  []receipt.Retailer
+		expectedViolations []domain.Violation
+	}{
+		{
+			title:             "GivenAListRequest_ReturnRetailers",
+			method:            http.MethodGet,
+			path:              "/retailers",
+			expectedCode:      http.StatusOK,
+			expectedRetailers: []receipt.Retailer{target},
+		},
+		{
+			title:             "GivenAnID_ReturnRetailer",
+			method:            http.MethodGet,
+			path:              "/retailers/target",
+			expectedCode:      http.StatusOK,
+			expectedRetailers: []receipt.Retailer{target},
+		},
+		{
+			title:             "GivenAnUnknownID_ReturnNotFoundError",
+			method:            http.MethodGet,
+			path:              "/retailers/walmart",
+			expectedCode:      http.StatusNotFound,
+			expectedRetailers: []receipt.Retailer{target},
+		},
+		{
+			title:        "GivenANewRetailer_AddIt",
+			method:       http.MethodPut,
+			path:         "/retailers/walmart",
+			body:         `{"Name": "Walmart", "Aliases": ["Wal-Mart"]}`,
+			expectedCode: http.StatusOK,
+			expectedRetailers: []receipt.Retailer{
+				target,
+				{ID: "walmart", Name: "Walmart", Aliases: []string{"Wal-Mart"}},
+			},
+		},
+		{
+			title:             "GivenATakenAlias_ReturnBadRequestError",
+			method:            http.MethodPut,
+			path:              "/retailers/other",
+			body:              `{"Name": "Target Store"}`,
+			expectedCode:      http.StatusBadRequest,
+			expectedRetailers: []receipt.Retailer{target},
+			expectedViolations: []domain.Violation{
+				{Path: "/Name", Rule: domain.RuleUnique, Value: "Target Store"},
+			},
+		},
+		{
+			title:             "GivenInvalidJSON_ReturnBadRequestError",
+			method:            http.MethodPut,
+			path:              "/retailers/walmart",
+			body:              `{`,
+			expectedCode:      http.StatusBadRequest,
+			expectedRetailers: []receipt.Retailer{target},
+		},
+		{
+			title:             "GivenADeleteRequest_RemoveRetailer",
+			method:            http.MethodDelete,
+			path:              "/retailers/target",
+			expectedCode:      http.StatusNoContent,
+			expectedRetailers: []receipt.Retailer{},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.title, func(t *testing.T) {
+			registry, err := receipt.NewRetailerRegistry(nil, target)
+			assert.NoError(t, err)
+
+			router := http.NewServeMux()
+			router.HandleFunc("GET /retailers", admin.ListRetailers(registry))
+			router.HandleFunc("GET /retailers/{id}", admin.GetRetailer(registry))
+			router.HandleFunc("PUT /retailers/{id}", admin.PutRetailer(registry))
+			router.HandleFunc("DELETE /retailers/{id}", admin.DeleteRetailer(registry))
+
+			request, err := http.NewRequest(tc.method, tc.path, bytes.NewBufferString(tc.body))
+			if err != nil {
+				t.Fatalf("Failed to build request: %v", err)
+			}
+
+			responseRecorder := httptest.NewRecorder()
+			router.ServeHTTP(responseRecorder, request)
+
+			assert.Equal(t, tc.expectedCode, responseRecorder.Code)
+			assert.Equal(t, tc.expectedRetailers, registry.List())
+
+			if tc.expectedViolations != nil {
+				var problem struct{ Violations []domain.Violation }
+				assert.NoError(t, json.Unmarshal(responseRecorder.Body.Bytes(), &problem))
+				assert.Equal(t, tc.expectedViolations, problem.Violations)
+			}
+		})
+	}
+}
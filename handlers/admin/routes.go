@@ -1,8 +1,25 @@
 package admin
 
-import "net/http"
+import (
+	"net/http"
 
-func InitializeRoutes(router *http.ServeMux) {
+	"github.com/kevin07696/receipt-processor/infrastructure/metrics"
+)
+
+func InitializeRoutes(router *http.ServeMux, lifecycle Lifecycle, rescorer Rescorer, retailers Retailers, campaigns Campaigns) {
 	router.HandleFunc("GET /health", HealthCheck())
-	router.HandleFunc("GET /exit/{code}", Exit())
+	router.HandleFunc("GET /exit/{code}", Exit(lifecycle))
+	router.Handle("GET /metrics", metrics.Handler())
+	router.HandleFunc("POST /rescore", StartRescore(rescorer))
+	router.HandleFunc("GET /rescore", GetRescore(rescorer))
+	router.HandleFunc("DELETE /rescore", CancelRescore(rescorer))
+	router.HandleFunc("GET /retailers", ListRetailers(retailers))
+	router.HandleFunc("GET /retailers/{id}", GetRetailer(retailers))
+	router.HandleFunc("PUT /retailers/{id}", PutRetailer(retailers))
+	router.HandleFunc("DELETE /retailers/{id}", DeleteRetailer(retailers))
+	router.HandleFunc("GET /campaigns", ListCampaigns(campaigns))
+	router.HandleFunc("GET /campaigns/{id}", GetCampaign(campaigns))
+	router.HandleFunc("PUT /campaigns/{id}", PutCampaign(campaigns))
+	router.HandleFunc("DELETE /campaigns/{id}", DeleteCampaign(campaigns))
+	router.HandleFunc("POST /rules/validate", ValidateRule())
 }
@@ -0,0 +1,74 @@
+package admin
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/kevin07696/receipt-processor/domain"
+	"github.com/kevin07696/receipt-processor/domain/expression"
+	"github.com/kevin07696/receipt-processor/domain/receipt"
+	"github.com/kevin07696/receipt-processor/handlers"
+)
+
+// RuleValidationRequest holds a custom rule to compile and an optional Receipt to score with it.
+type RuleValidationRequest struct {
+	Name       string
+	Expression string
+	Receipt    json.RawMessage
+}
+
+type RuleValidationResponse struct {
+	Valid bool
+	// Error is why the rule does not compile. Column is where in the expression it failed, when it is known.
+	Error  string `json:",omitempty"`
+	Column int    `json:",omitempty"`
+	// Score is what the rule awards the receipt of the request.
+	Score *receipt.RuleScore `json:",omitempty"`
+}
+
+// ValidateRule compiles a custom rule as CUSTOM_RULES_FILE would at startup, without registering it.
+// A rule that does not compile is answered with 200 and Valid false.
+func ValidateRule() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var request RuleValidationRequest
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+			slog.DebugContext(r.Context(), "Unmarshal Error: Failed to unmarshal rule validation request.", slog.Any("error", err))
+			handlers.WriteProblem(w, r, domain.ErrBadRequest)
+			return
+		}
+
+		rule, err := receipt.CompileRule(receipt.CustomRule{Name: request.Name, Expression: request.Expression})
+		if err != nil {
+			response := RuleValidationResponse{Error: err.Error()}
+			var compileErr *expression.Error
+			if errors.As(err, &compileErr) {
+				response.Error, response.Column = compileErr.Message, compileErr.Column
+			}
+			writeJSON(w, r, http.StatusOK, response)
+			return
+		}
+
+		response := RuleValidationResponse{Valid: true}
+		if len(request.Receipt) > 0 {
+			var rcpt receipt.Receipt
+			if err := json.Unmarshal(request.Receipt, &rcpt); err != nil {
+				slog.DebugContext(r.Context(), "Unmarshal Error: Failed to unmarshal receipt.", slog.Any("error", err))
+				handlers.WriteProblem(w, r, domain.ErrBadRequest)
+				return
+			}
+			if violations := rcpt.Validate(r.Context()); len(violations) > 0 {
+				for i := range violations {
+					violations[i].Path = "/Receipt" + violations[i].Path
+				}
+				handlers.WriteProblem(w, r, domain.ErrBadRequest, violations...)
+				return
+			}
+
+			points, reason := rule.Points(r.Context(), rcpt)
+			response.Score = &receipt.RuleScore{Rule: rule.Name(), Points: points, Reason: reason}
+		}
+		writeJSON(w, r, http.StatusOK, response)
+	}
+}
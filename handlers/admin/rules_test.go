@@ -0,0 +1,95 @@
+package admin_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/kevin07696/receipt-processor/domain"
+	"github.com/kevin07696/receipt-processor/domain/receipt"
+	"github.com/kevin07696/receipt-processor/handlers/admin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateRule(t *testing.T) {
+	const rcpt = `{"retailer": "Target", "purchaseDate": "2022-01-01", "purchaseTime": "13:01", "total": "22.25",
+		"items": [{"shortDescription": "Pizza", "price": "12.25"}, {"shortDescription": "Soda", "price": "10.00"}]}`
+
+	testCases := []struct {
+		title              string
+		body               string
+		expectedCode       int
+		expectedResponse   admin.RuleValidationResponse
+		expectedViolations []domain.Violation
+	}{
+		{
+			title:            "GivenAValidRule_ReturnValid",
+			body:             `{"Name": "big_items", "Expression": "5 * count(items, item.price > 10.00)"}`,
+			expectedCode:     http.StatusOK,
+			expectedResponse: admin.RuleValidationResponse{Valid: true},
+		},
+		{
+			title:        "GivenAReceipt_ReturnItsScore",
+			body:         `{"Name": "big_items", "Expression": "5 * count(items, item.price > 10.00)", "Receipt": ` + rcpt + `}`,
+			expectedCode: http.StatusOK,
+			expectedResponse: admin.RuleValidationResponse{
+				Valid: true,
+				Score: &receipt.RuleScore{Rule: "big_items", Points: 5, Reason: "5 points - 5 * count(items, item.price > 10.00) = 5"},
+			},
+		},
+		{
+			title:            "GivenACompileError_ReturnItsColumn",
+			body:             `{"Name": "big_items", "Expression": "5 * count(items, item.price > \"10\")"}`,
+			expectedCode:     http.StatusOK,
+			expectedResponse: admin.RuleValidationResponse{Error: "can not compare number with string", Column: 29},
+		},
+		{
+			title:            "GivenAnInvalidName_ReturnError",
+			body:             `{"Name": "", "Expression": "1"}`,
+			expectedCode:     http.StatusOK,
+			expectedResponse: admin.RuleValidationResponse{Error: "custom rule name \"\" must match ^[a-z][a-z0-9_]*$"},
+		},
+		{
+			title:        "GivenAnInvalidReceipt_ReturnBadRequestError",
+			body:         `{"Name": "big_items", "Expression": "1", "Receipt": {"retailer": "Target"}}`,
+			expectedCode: http.StatusBadRequest,
+			expectedViolations: []domain.Violation{
+				{Path: "/Receipt/purchaseDate", Rule: domain.RuleRequired, Value: ""},
+				{Path: "/Receipt/purchaseTime", Rule: domain.RuleRequired, Value: ""},
+				{Path: "/Receipt/items", Rule: domain.RuleMin, Value: "[]"},
+				{Path: "/Receipt/total", Rule: domain.RuleRequired, Value: ""},
+			},
+		},
+		{
+			title:        "GivenInvalidJSON_ReturnBadRequestError",
+			body:         `{`,
+			expectedCode: http.StatusBadRequest,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.title, func(t *testing.T) {
+			request, err := http.NewRequest(http.MethodPost, "/rules/validate", bytes.NewBufferString(tc.body))
+			if err != nil {
+				t.Fatalf("Failed to build request: %v", err)
+			}
+
+			responseRecorder := httptest.NewRecorder()
+			admin.ValidateRule().ServeHTTP(responseRecorder, request)
+
+			assert.Equal(t, tc.expectedCode, responseRecorder.Code)
+			if tc.expectedCode == http.StatusOK {
+				var response admin.RuleValidationResponse
+				assert.NoError(t, json.Unmarshal(responseRecorder.Body.Bytes(), &response))
+				assert.Equal(t, tc.expectedResponse, response)
+			}
+			if tc.expectedViolations != nil {
+				var problem struct{ Violations []domain.Violation }
+				assert.NoError(t, json.Unmarshal(responseRecorder.Body.Bytes(), &problem))
+				assert.Equal(t, tc.expectedViolations, problem.Violations)
+			}
+		})
+	}
+}
@@ -0,0 +1,52 @@
+package handlers
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+
+	"github.com/kevin07696/receipt-processor/domain"
+)
+
+const ProblemContentType = "application/problem+json"
+
+// Problem is an RFC 7807 problem details body. Code and Violations are extension members.
+type Problem struct {
+	Type       string             `json:"type"`
+	Title      string             `json:"title"`
+	Status     int                `json:"status"`
+	Detail     string             `json:"detail"`
+	Instance   string             `json:"instance,omitempty"`
+	Code       string             `json:"code"`
+	Violations []domain.Violation `json:"violations,omitempty"`
+}
+
+func NewProblem(status domain.StatusCode, instance string, violations ...domain.Violation) Problem {
+	message := domain.ErrorToCodes[status]
+	return Problem{
+		Type:       "about:blank",
+		Title:      http.StatusText(message.Code),
+		Status:     message.Code,
+		Detail:     message.Message,
+		Instance:   instance,
+		Code:       message.Name,
+		Violations: violations,
+	}
+}
+
+// WriteProblem answers the request with the problem details of the status code.
+func WriteProblem(w http.ResponseWriter, r *http.Request, status domain.StatusCode, violations ...domain.Violation) {
+	problem := NewProblem(status, r.URL.Path, violations...)
+
+	body, err := json.Marshal(problem)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Marshal Error: Failed to marshal problem.", slog.Any("error", err))
+		http.Error(w, problem.Detail, problem.Status)
+		return
+	}
+
+	w.Header().Set("Content-Type", ProblemContentType)
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(problem.Status)
+	w.Write(body)
+}
@@ -0,0 +1,228 @@
+package receipt
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/kevin07696/receipt-processor/domain"
+	"github.com/kevin07696/receipt-processor/domain/receipt"
+	"github.com/kevin07696/receipt-processor/handlers"
+	"github.com/kevin07696/receipt-processor/infrastructure/metrics"
+)
+
+var errBatchTooLarge = errors.New("receipt batch is too large")
+
+type BatchOptions struct {
+	// Workers bounds how many receipts of a batch are scored at the same time.
+	Workers int
+	// MaxSize is the largest number of receipts accepted in one batch.
+	MaxSize int
+}
+
+// BatchResult holds either the ID of a processed receipt or the problem that stopped it.
+type BatchResult struct {
+	ID       string             `json:",omitempty"`
+	Error    *handlers.Problem  `json:",omitempty"`
+	Warnings []domain.Violation `json:",omitempty"`
+}
+
+type BatchResponse struct {
+	Results   []BatchResult
+	Succeeded int
+	Failed    int
+}
+
+// ProcessReceiptBatch accepts a JSON array or newline delimited JSON of receipts.
+// Every receipt gets a result in input order, so one invalid receipt does not fail the batch.
+func ProcessReceiptBatch(receiptAPI receipt.IReceiptProcessorService, opts BatchOptions) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		body := bufio.NewReader(r.Body)
+		var raws []json.RawMessage
+		var err error
+		if isJSONArray(body) {
+			raws, err = decodeJSONArray(body, opts.MaxSize)
+		} else {
+			raws, err = decodeNDJSON(body, opts.MaxSize)
+		}
+		if err != nil {
+			slog.DebugContext(ctx, "Unmarshal Error: Failed to read receipt batch.", slog.Any("error", err))
+			handlers.WriteProblem(w, r, domain.ErrBadRequest)
+			return
+		}
+		if len(raws) == 0 {
+			slog.DebugContext(ctx, "StatusBadRequest: receipt batch is empty")
+			handlers.WriteProblem(w, r, domain.ErrBadRequest)
+			return
+		}
+
+		results := make([]BatchResult, len(raws))
+		requests := make([]receipt.ReceiptProcessorRequest, len(raws))
+		warnings := make([][]domain.Violation, len(raws))
+		var valid []int
+		// Duplicates of a receipt share its ID, so only the first is scored and the rest copy its result
+		first := make(map[string]int)
+		duplicateOf := make(map[int]int)
+		for i, raw := range raws {
+			var input receipt.Receipt
+			if err := json.Unmarshal(raw, &input); err != nil {
+				slog.DebugContext(ctx, "Unmarshal Error: Failed to unmarshal receipt.", slog.Int("index", i), slog.Any("error", err))
+				results[i] = batchError(domain.ErrBadRequest, unmarshalViolations(err)...)
+				continue
+			}
+			violations, inconsistencies := receiptAPI.ValidateReceipt(ctx, &input)
+			if len(violations) > 0 {
+				metrics.ObserveViolations(violations)
+				results[i] = batchError(domain.ErrBadRequest, violations...)
+				continue
+			}
+			warnings[i] = inconsistencies
+
+			requests[i] = receipt.ReceiptProcessorRequest{ID: receiptAPI.GenerateID(ctx, string(raw)), Receipt: input}
+			if j, ok := first[requests[i].ID]; ok {
+				duplicateOf[i] = j
+				continue
+			}
+			first[requests[i].ID] = i
+			valid = append(valid, i)
+		}
+
+		workers := opts.Workers
+		if workers < 1 {
+			workers = 1
+		}
+
+		jobs := make(chan int)
+		var wg sync.WaitGroup
+		for n := 0; n < workers; n++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for i := range jobs {
+					results[i] = processBatchReceipt(ctx, receiptAPI, requests[i])
+					if results[i].Error == nil {
+						results[i].Warnings = warnings[i]
+					}
+				}
+			}()
+		}
+		for _, i := range valid {
+			jobs <- i
+		}
+		close(jobs)
+		wg.Wait()
+		for i, j := range duplicateOf {
+			results[i] = results[j]
+		}
+
+		response := BatchResponse{Results: results}
+		for _, result := range results {
+			if result.Error != nil {
+				response.Failed++
+			} else {
+				response.Succeeded++
+			}
+		}
+
+		jsonResponse, err := json.Marshal(response)
+		if err != nil {
+			slog.ErrorContext(ctx, "Marshal Error: Failed to marshal response.", slog.Any("error", err))
+			handlers.WriteProblem(w, r, domain.ErrInternal)
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+		w.Write(jsonResponse)
+	}
+}
+
+func processBatchReceipt(ctx context.Context, receiptAPI receipt.IReceiptProcessorService, request receipt.ReceiptProcessorRequest) BatchResult {
+	if ctx.Err() != nil {
+		return batchError(domain.ErrInternal)
+	}
+
+	// Each receipt gets the same deadline as a single process request
+	ctx, cancel := context.WithTimeout(ctx, time.Second)
+	defer cancel()
+
+	response, status := receiptAPI.ProcessReceipt(ctx, request)
+	if status > 0 {
+		return batchError(status)
+	}
+	return BatchResult{ID: response.ID}
+}
+
+func batchError(status domain.StatusCode, violations ...domain.Violation) BatchResult {
+	problem := handlers.NewProblem(status, "", violations...)
+	return BatchResult{Error: &problem}
+}
+
+// isJSONArray peeks past leading whitespace to tell a JSON array from newline delimited JSON.
+func isJSONArray(r *bufio.Reader) bool {
+	for {
+		b, err := r.Peek(1)
+		if err != nil {
+			return false
+		}
+		switch b[0] {
+		case ' ', '\t', '\r', '\n':
+			r.ReadByte()
+		default:
+			return b[0] == '['
+		}
+	}
+}
+
+func decodeJSONArray(r io.Reader, maxSize int) ([]json.RawMessage, error) {
+	decoder := json.NewDecoder(r)
+	if _, err := decoder.Token(); err != nil {
+		return nil, err
+	}
+
+	var raws []json.RawMessage
+	for decoder.More() {
+		if maxSize > 0 && len(raws) == maxSize {
+			return nil, errBatchTooLarge
+		}
+
+		var raw json.RawMessage
+		if err := decoder.Decode(&raw); err != nil {
+			return nil, err
+		}
+		raws = append(raws, raw)
+	}
+
+	if _, err := decoder.Token(); err != nil {
+		return nil, err
+	}
+	return raws, nil
+}
+
+// decodeNDJSON reads one receipt per line into memory, like decodeJSONArray. A malformed line only fails its own
+// receipt, but a line over 1 MiB fails the whole batch.
+func decodeNDJSON(r io.Reader, maxSize int) ([]json.RawMessage, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+
+	var raws []json.RawMessage
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		if maxSize > 0 && len(raws) == maxSize {
+			return nil, errBatchTooLarge
+		}
+		raws = append(raws, json.RawMessage(bytes.Clone(line)))
+	}
+	return raws, scanner.Err()
+}
@@ -0,0 +1,153 @@
+package receipt_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/kevin07696/receipt-processor/domain"
+	receiptDomain "github.com/kevin07696/receipt-processor/domain/receipt"
+	"github.com/kevin07696/receipt-processor/handlers"
+	receiptHandler "github.com/kevin07696/receipt-processor/handlers/receipt"
+	"github.com/stretchr/testify/assert"
+)
+
+func batchReceipt(retailer string) string {
+	return fmt.Sprintf(`{"retailer": "%s", "purchaseDate": "2022-01-02", "purchaseTime": "08:13", "total": "1.25", "items": [{"shortDescription": "Pepsi - 12-oz", "price": "1.25"}]}`, retailer)
+}
+
+func TestProcessReceiptBatch(t *testing.T) {
+	malformed := handlers.NewProblem(domain.ErrBadRequest, "")
+	invalidRetailer := handlers.NewProblem(domain.ErrBadRequest, "", domain.Violation{Path: "/retailer", Rule: domain.RulePattern, Value: "!@#"})
+	retailerType := handlers.NewProblem(domain.ErrBadRequest, "", domain.Violation{Path: "/retailer", Rule: domain.RuleType, Value: "number"})
+	internal := handlers.NewProblem(domain.ErrInternal, "")
+
+	// The retailer is echoed as the ID so the order of the results can be checked
+	batchAPI := &MockReceiptService{
+		ProcessReceiptMock: func(ctx context.Context, request receiptDomain.ReceiptProcessorRequest) (receiptDomain.ReceiptProcessorResponse, domain.StatusCode) {
+			if request.Receipt.Retailer == "Broken" {
+				return receiptDomain.ReceiptProcessorResponse{}, domain.ErrInternal
+			}
+			return receiptDomain.ReceiptProcessorResponse{ID: request.Receipt.Retailer}, domain.StatusOK
+		},
+		GenerateIDMock: func(ctx context.Context, input string) string {
+			return input
+		},
+	}
+
+	tests := []struct {
+		name             string
+		requestBody      string
+		opts             receiptHandler.BatchOptions
+		expectedCode     int
+		expectedResponse receiptHandler.BatchResponse
+	}{
+		{
+			name:         "GivenAJSONArray_ReturnResultsInOrder",
+			requestBody:  "[" + strings.Join([]string{batchReceipt("A"), batchReceipt("B"), batchReceipt("C"), batchReceipt("D")}, ",") + "]",
+			opts:         receiptHandler.BatchOptions{Workers: 2},
+			expectedCode: http.StatusOK,
+			expectedResponse: receiptHandler.BatchResponse{
+				Results:   []receiptHandler.BatchResult{{ID: "A"}, {ID: "B"}, {ID: "C"}, {ID: "D"}},
+				Succeeded: 4,
+			},
+		},
+		{
+			name:         "GivenAJSONArrayWithInvalidReceipts_ReturnPartialSuccess",
+			requestBody:  "[" + strings.Join([]string{batchReceipt("A"), batchReceipt("!@#"), `{"retailer": 7}`, batchReceipt("Broken")}, ",") + "]",
+			opts:         receiptHandler.BatchOptions{Workers: 4},
+			expectedCode: http.StatusOK,
+			expectedResponse: receiptHandler.BatchResponse{
+				Results:   []receiptHandler.BatchResult{{ID: "A"}, {Error: &invalidRetailer}, {Error: &retailerType}, {Error: &internal}},
+				Succeeded: 1,
+				Failed:    3,
+			},
+		},
+		{
+			name:         "GivenNDJSONWithAMalformedLine_ReturnPartialSuccess",
+			requestBody:  batchReceipt("A") + "\n{\"retailer\": \n\n" + batchReceipt("C") + "\n",
+			opts:         receiptHandler.BatchOptions{Workers: 1},
+			expectedCode: http.StatusOK,
+			expectedResponse: receiptHandler.BatchResponse{
+				Results:   []receiptHandler.BatchResult{{ID: "A"}, {Error: &malformed}, {ID: "C"}},
+				Succeeded: 2,
+				Failed:    1,
+			},
+		},
+		{
+			name:         "GivenAMalformedJSONArray_ReturnBadRequestError",
+			requestBody:  "[" + batchReceipt("A") + ",",
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "GivenAnEmptyBatch_ReturnBadRequestError",
+			requestBody:  " [ ] ",
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "GivenABatchOverMaxSize_ReturnBadRequestError",
+			requestBody:  "[" + strings.Join([]string{batchReceipt("A"), batchReceipt("B")}, ",") + "]",
+			opts:         receiptHandler.BatchOptions{MaxSize: 1},
+			expectedCode: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := receiptHandler.ProcessReceiptBatch(batchAPI, tt.opts)
+
+			request, err := http.NewRequest(http.MethodPost, "/receipts/process:batch", strings.NewReader(tt.requestBody))
+			if err != nil {
+				t.Fatalf("Failed to build request: %v", err)
+			}
+
+			responseRecorder := httptest.NewRecorder()
+			handler.ServeHTTP(responseRecorder, request)
+
+			assert.Equal(t, tt.expectedCode, responseRecorder.Code)
+			if responseRecorder.Code == http.StatusOK {
+				jsonResponse, err := json.Marshal(tt.expectedResponse)
+				if err != nil {
+					t.Fatalf("Failed to marshal response: %v", err)
+				}
+
+				assert.Equal(t, string(jsonResponse), responseRecorder.Body.String())
+			}
+		})
+	}
+}
+
+func TestProcessReceiptBatchGivenDuplicates_ProcessEachReceiptOnce(t *testing.T) {
+	var processed atomic.Int32
+	batchAPI := &MockReceiptService{
+		ProcessReceiptMock: func(ctx context.Context, request receiptDomain.ReceiptProcessorRequest) (receiptDomain.ReceiptProcessorResponse, domain.StatusCode) {
+			processed.Add(1)
+			return receiptDomain.ReceiptProcessorResponse{ID: request.Receipt.Retailer}, domain.StatusOK
+		},
+		GenerateIDMock: func(ctx context.Context, input string) string {
+			return input
+		},
+	}
+	handler := receiptHandler.ProcessReceiptBatch(batchAPI, receiptHandler.BatchOptions{Workers: 4})
+
+	requestBody := "[" + strings.Join([]string{batchReceipt("A"), batchReceipt("B"), batchReceipt("A"), batchReceipt("A")}, ",") + "]"
+	request := httptest.NewRequest(http.MethodPost, "/receipts/process:batch", strings.NewReader(requestBody))
+	responseRecorder := httptest.NewRecorder()
+	handler.ServeHTTP(responseRecorder, request)
+
+	jsonResponse, err := json.Marshal(receiptHandler.BatchResponse{
+		Results:   []receiptHandler.BatchResult{{ID: "A"}, {ID: "B"}, {ID: "A"}, {ID: "A"}},
+		Succeeded: 4,
+	})
+	if err != nil {
+		t.Fatalf("Failed to marshal response: %v", err)
+	}
+	assert.Equal(t, http.StatusOK, responseRecorder.Code)
+	assert.Equal(t, string(jsonResponse), responseRecorder.Body.String())
+	assert.Equal(t, int32(2), processed.Load())
+}
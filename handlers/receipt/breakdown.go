@@ -0,0 +1,50 @@
+package receipt
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/kevin07696/receipt-processor/domain"
+	"github.com/kevin07696/receipt-processor/domain/receipt"
+	"github.com/kevin07696/receipt-processor/handlers"
+)
+
+func GetBreakdown(receiptAPI receipt.IReceiptProcessorService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx, cancel := context.WithTimeout(r.Context(), time.Second)
+		defer cancel()
+
+		path := r.URL.Path
+		path = strings.Trim(path, "/")
+		segments := strings.Split(path, "/")
+
+		// Assuming the route is always valid
+		id := segments[1]
+		if err := uuid.Validate(id); err != nil {
+			slog.DebugContext(ctx, "StatusBadRequest: uuid is invalid", slog.String("id", id), slog.Any("error", err))
+			handlers.WriteProblem(w, r, domain.ErrBadRequest)
+			return
+		}
+
+		response, status := receiptAPI.GetReceiptBreakdown(ctx, receipt.ReceiptBreakdownRequest{ID: id})
+		if status > 0 {
+			handlers.WriteProblem(w, r, status)
+			return
+		}
+
+		jsonResponse, err := json.Marshal(response)
+		if err != nil {
+			slog.ErrorContext(ctx, "Marshal Error: Failed to marshal response.", slog.Any("error", err))
+			handlers.WriteProblem(w, r, domain.ErrInternal)
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+		w.Write(jsonResponse)
+	}
+}
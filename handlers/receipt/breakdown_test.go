@@ -0,0 +1,77 @@
+package receipt_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/kevin07696/receipt-processor/domain"
+	receiptDomain "github.com/kevin07696/receipt-processor/domain/receipt"
+	receioptHandler "github.com/kevin07696/receipt-processor/handlers/receipt"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetBreakdown(t *testing.T) {
+	testCases := []struct {
+		title            string
+		id               string
+		expectedResponse receiptDomain.ReceiptBreakdownResponse
+		expectedCode     int
+		receiptAPI       receiptDomain.IReceiptProcessorService
+	}{
+		{
+			title:            "GivenAValidID_ReturnBreakdown",
+			id:               "af523d7a-e8d0-4af0-8bbd-d2340a4da5a4",
+			expectedResponse: receiptDomain.ReceiptBreakdownResponse{Points: 65535, Breakdown: []receiptDomain.RuleScore{{Rule: receiptDomain.RuleRetailer, Points: 65535, Reason: "65535 points - retailer name has 65535 characters"}}},
+			expectedCode:     http.StatusOK,
+			receiptAPI: &MockReceiptService{
+				GetReceiptBreakdownMock: func(ctx context.Context, request receiptDomain.ReceiptBreakdownRequest) (receiptDomain.ReceiptBreakdownResponse, domain.StatusCode) {
+					return receiptDomain.ReceiptBreakdownResponse{Points: 65535, Breakdown: []receiptDomain.RuleScore{{Rule: receiptDomain.RuleRetailer, Points: 65535, Reason: "65535 points - retailer name has 65535 characters"}}}, domain.StatusOK
+				},
+			},
+		},
+		{
+			title:        "GivenAInvalidID_ReturnBadRequestError",
+			id:           "af523d7a",
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			title:        "GivenAValidID_ReturnNotFound",
+			id:           "af523d7a-e8d0-4af0-8bbd-d2340a4da5a4",
+			expectedCode: http.StatusNotFound,
+			receiptAPI: &MockReceiptService{
+				GetReceiptBreakdownMock: func(ctx context.Context, request receiptDomain.ReceiptBreakdownRequest) (receiptDomain.ReceiptBreakdownResponse, domain.StatusCode) {
+					return receiptDomain.ReceiptBreakdownResponse{}, domain.ErrNotFound
+				},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.title, func(t *testing.T) {
+			handler := receioptHandler.GetBreakdown(tc.receiptAPI)
+			url := fmt.Sprintf("/receipts/%s/breakdown", tc.id)
+
+			request, err := http.NewRequest(http.MethodGet, url, nil)
+			if err != nil {
+				t.Fatalf("Failed to build request: %v", err)
+			}
+
+			responseRecorder := httptest.NewRecorder()
+			handler.ServeHTTP(responseRecorder, request)
+
+			assert.Equal(t, tc.expectedCode, responseRecorder.Code)
+			if responseRecorder.Code == http.StatusOK {
+				jsonResponse, err := json.Marshal(tc.expectedResponse)
+				if err != nil {
+					t.Fatalf("Failed to marshal response: %v", err)
+				}
+
+				assert.Equal(t, jsonResponse, responseRecorder.Body.Bytes())
+			}
+		})
+	}
+}
@@ -3,7 +3,6 @@ package receipt
 import (
 	"context"
 	"encoding/json"
-	"log"
 	"log/slog"
 	"net/http"
 	"strings"
@@ -12,6 +11,7 @@ import (
 	"github.com/google/uuid"
 	"github.com/kevin07696/receipt-processor/domain"
 	"github.com/kevin07696/receipt-processor/domain/receipt"
+	"github.com/kevin07696/receipt-processor/handlers"
 )
 
 func GetScore(receiptAPI receipt.IReceiptProcessorService) http.HandlerFunc {
@@ -27,19 +27,21 @@ func GetScore(receiptAPI receipt.IReceiptProcessorService) http.HandlerFunc {
 		id := segments[1]
 		if err := uuid.Validate(id); err != nil {
 			slog.DebugContext(ctx, "StatusBadRequest: uuid is invalid", slog.String("id", id), slog.Any("error", err))
-			http.Error(w, domain.ErrorToCodes[domain.ErrBadRequest].Message, domain.ErrorToCodes[domain.ErrBadRequest].Code)
+			handlers.WriteProblem(w, r, domain.ErrBadRequest)
 			return
 		}
 
 		response, status := receiptAPI.GetReceiptScore(ctx, receipt.ReceiptScoreRequest{ID: id})
 		if status > 0 {
-			http.Error(w, domain.ErrorToCodes[status].Message, domain.ErrorToCodes[status].Code)
+			handlers.WriteProblem(w, r, status)
 			return
 		}
 
 		jsonResponse, err := json.Marshal(response)
 		if err != nil {
-			log.Fatalf("Failed to marshal response: %v", err)
+			slog.ErrorContext(ctx, "Marshal Error: Failed to marshal response.", slog.Any("error", err))
+			handlers.WriteProblem(w, r, domain.ErrInternal)
+			return
 		}
 
 		w.WriteHeader(http.StatusOK)
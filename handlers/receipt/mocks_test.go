@@ -21,9 +21,12 @@ type MockItem struct {
 }
 
 type MockReceiptService struct {
-	ProcessReceiptMock  func(ctx context.Context, request receipt.ReceiptProcessorRequest) (receipt.ReceiptProcessorResponse, domain.StatusCode)
-	GetReceiptScoreMock func(ctx context.Context, request receipt.ReceiptScoreRequest) (receipt.ReceiptScoreResponse, domain.StatusCode)
-	GenerateIDMock      func(ctx context.Context, input string) string
+	ProcessReceiptMock      func(ctx context.Context, request receipt.ReceiptProcessorRequest) (receipt.ReceiptProcessorResponse, domain.StatusCode)
+	GetReceiptScoreMock     func(ctx context.Context, request receipt.ReceiptScoreRequest) (receipt.ReceiptScoreResponse, domain.StatusCode)
+	GetReceiptBreakdownMock func(ctx context.Context, request receipt.ReceiptBreakdownRequest) (receipt.ReceiptBreakdownResponse, domain.StatusCode)
+	SimulateReceiptMock     func(ctx context.Context, request receipt.ReceiptSimulationRequest) (receipt.ReceiptSimulationResponse, domain.StatusCode, []domain.Violation)
+	ValidateReceiptMock     func(ctx context.Context, receipt *receipt.Receipt) ([]domain.Violation, []domain.Violation)
+	GenerateIDMock          func(ctx context.Context, input string) string
 }
 
 func (m *MockReceiptService) ProcessReceipt(ctx context.Context, request receipt.ReceiptProcessorRequest) (receipt.ReceiptProcessorResponse, domain.StatusCode) {
@@ -32,6 +35,20 @@ func (m *MockReceiptService) ProcessReceipt(ctx context.Context, request receipt
 func (m MockReceiptService) GetReceiptScore(ctx context.Context, request receipt.ReceiptScoreRequest) (receipt.ReceiptScoreResponse, domain.StatusCode) {
 	return m.GetReceiptScoreMock(ctx, request)
 }
+func (m MockReceiptService) GetReceiptBreakdown(ctx context.Context, request receipt.ReceiptBreakdownRequest) (receipt.ReceiptBreakdownResponse, domain.StatusCode) {
+	return m.GetReceiptBreakdownMock(ctx, request)
+}
+
+// ValidateReceipt only validates the receipt unless ValidateReceiptMock is set.
+func (m MockReceiptService) ValidateReceipt(ctx context.Context, receipt *receipt.Receipt) ([]domain.Violation, []domain.Violation) {
+	if m.ValidateReceiptMock == nil {
+		return receipt.Validate(ctx), nil
+	}
+	return m.ValidateReceiptMock(ctx, receipt)
+}
+func (m MockReceiptService) SimulateReceipt(ctx context.Context, request receipt.ReceiptSimulationRequest) (receipt.ReceiptSimulationResponse, domain.StatusCode, []domain.Violation) {
+	return m.SimulateReceiptMock(ctx, request)
+}
 func (m MockReceiptService) GenerateID(ctx context.Context, input string) string {
 	return m.GenerateIDMock(ctx, input)
 }
@@ -3,14 +3,17 @@ package receipt
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"io"
 	"log/slog"
 	"net/http"
-	"os"
+	"strings"
 	"time"
 
 	"github.com/kevin07696/receipt-processor/domain"
 	"github.com/kevin07696/receipt-processor/domain/receipt"
+	"github.com/kevin07696/receipt-processor/handlers"
+	"github.com/kevin07696/receipt-processor/infrastructure/metrics"
 )
 
 func ProcessReceipt(receiptAPI receipt.IReceiptProcessorService) http.HandlerFunc {
@@ -20,19 +23,22 @@ func ProcessReceipt(receiptAPI receipt.IReceiptProcessorService) http.HandlerFun
 
 		body, err := io.ReadAll(r.Body)
 		if err != nil {
-			slog.ErrorContext(ctx, "Failed to read request body.", slog.Any("error", err))
-			os.Exit(1)
+			slog.DebugContext(ctx, "Failed to read request body.", slog.Any("error", err))
+			handlers.WriteProblem(w, r, domain.ErrBadRequest)
+			return
 		}
 
 		var input receipt.Receipt
 		if err := json.Unmarshal(body, &input); err != nil {
 			slog.DebugContext(ctx, "Unmarshal Error: Failed to unmarshal receipt.", slog.Any("error", err))
-			http.Error(w, domain.ErrorToCodes[domain.ErrBadRequest].Message, domain.ErrorToCodes[domain.ErrBadRequest].Code)
+			handlers.WriteProblem(w, r, domain.ErrBadRequest, unmarshalViolations(err)...)
 			return
 		}
 
-		if !input.Validate(ctx) {
-			http.Error(w, domain.ErrorToCodes[domain.ErrBadRequest].Message, domain.ErrorToCodes[domain.ErrBadRequest].Code)
+		violations, warnings := receiptAPI.ValidateReceipt(ctx, &input)
+		if len(violations) > 0 {
+			metrics.ObserveViolations(violations)
+			handlers.WriteProblem(w, r, domain.ErrBadRequest, violations...)
 			return
 		}
 
@@ -40,17 +46,33 @@ func ProcessReceipt(receiptAPI receipt.IReceiptProcessorService) http.HandlerFun
 
 		response, status := receiptAPI.ProcessReceipt(ctx, receipt.ReceiptProcessorRequest{ID: id, Receipt: input})
 		if status > 0 {
-			http.Error(w, domain.ErrorToCodes[status].Message, domain.ErrorToCodes[status].Code)
+			handlers.WriteProblem(w, r, status)
 			return
 		}
+		response.Warnings = warnings
 
 		jsonResponse, err := json.Marshal(response)
 		if err != nil {
 			slog.ErrorContext(ctx, "Marshal Error: Failed to marshal response.", slog.Any("error", err))
-			os.Exit(1)
+			handlers.WriteProblem(w, r, domain.ErrInternal)
+			return
 		}
 
 		w.WriteHeader(http.StatusOK)
 		w.Write(jsonResponse)
 	}
 }
+
+// unmarshalViolations points at the field whose JSON type does not match the receipt.
+func unmarshalViolations(err error) []domain.Violation {
+	var typeErr *json.UnmarshalTypeError
+	if !errors.As(err, &typeErr) || typeErr.Field == "" {
+		return nil
+	}
+
+	return []domain.Violation{{
+		Path:  "/" + strings.ReplaceAll(typeErr.Field, ".", "/"),
+		Rule:  domain.RuleType,
+		Value: typeErr.Value,
+	}}
+}
@@ -11,6 +11,7 @@ import (
 
 	"github.com/kevin07696/receipt-processor/domain"
 	receiptDomain "github.com/kevin07696/receipt-processor/domain/receipt"
+	"github.com/kevin07696/receipt-processor/handlers"
 	receiptHandler "github.com/kevin07696/receipt-processor/handlers/receipt"
 	"github.com/stretchr/testify/assert"
 )
@@ -112,6 +113,34 @@ func TestReceiptProcessorHandler(t *testing.T) {
 			expectedCode:     http.StatusInternalServerError,
 			expectedResponse: receiptDomain.ReceiptProcessorResponse{},
 		},
+		{
+			name: "GivenAnInconsistentRequestInLenientMode_ReturnWarnings",
+			request: receiptDomain.Receipt{
+				Retailer:     "Target",
+				PurchaseDate: "2024-01-01",
+				PurchaseTime: "14:00",
+				Items: []receiptDomain.Item{
+					{ShortDescription: "desc", Price: "2.00"},
+				},
+				Total: "9999.00",
+			},
+			service: &MockReceiptService{
+				ProcessReceiptMock: func(ctx context.Context, request receiptDomain.ReceiptProcessorRequest) (receiptDomain.ReceiptProcessorResponse, domain.StatusCode) {
+					return receiptDomain.ReceiptProcessorResponse{ID: "ID"}, domain.StatusOK
+				},
+				ValidateReceiptMock: func(ctx context.Context, receipt *receiptDomain.Receipt) ([]domain.Violation, []domain.Violation) {
+					return nil, []domain.Violation{{Path: "/total", Rule: domain.RuleSum, Value: receipt.Total}}
+				},
+				GenerateIDMock: func(ctx context.Context, input string) string {
+					return "ID"
+				},
+			},
+			expectedCode: http.StatusOK,
+			expectedResponse: receiptDomain.ReceiptProcessorResponse{
+				ID:       "ID",
+				Warnings: []domain.Violation{{Path: "/total", Rule: domain.RuleSum, Value: "9999.00"}},
+			},
+		},
 		{
 			name: "GivenAnEmptyRetailer_ReturnBadRequestError",
 			request: receiptDomain.Receipt{
@@ -336,3 +365,86 @@ func TestReceiptProcessorHandler(t *testing.T) {
 		})
 	}
 }
+
+func TestProcessReceiptProblem(t *testing.T) {
+	tests := []struct {
+		name               string
+		requestBody        string
+		service            receiptDomain.IReceiptProcessorService
+		expectedStatus     domain.StatusCode
+		expectedViolations []domain.Violation
+	}{
+		{
+			name:           "GivenInvalidFields_ReturnViolations",
+			requestBody:    `{"retailer": "Target", "purchaseDate": "2022-01-32", "purchaseTime": "08:13", "total": "", "items": [{"shortDescription": "Pepsi", "price": "$1.25"}]}`,
+			service:        receiptAPI,
+			expectedStatus: domain.ErrBadRequest,
+			expectedViolations: []domain.Violation{
+				{Path: "/purchaseDate", Rule: domain.RulePattern, Value: "2022-01-32"},
+				{Path: "/items/0/price", Rule: domain.RulePattern, Value: "$1.25"},
+				{Path: "/total", Rule: domain.RuleRequired, Value: ""},
+			},
+		},
+		{
+			name:           "GivenAWrongFieldType_ReturnTypeViolation",
+			requestBody:    `{"retailer": 7, "purchaseDate": "2022-01-02", "purchaseTime": "08:13", "total": "1.25", "items": [{"shortDescription": "Pepsi", "price": "1.25"}]}`,
+			service:        receiptAPI,
+			expectedStatus: domain.ErrBadRequest,
+			expectedViolations: []domain.Violation{
+				{Path: "/retailer", Rule: domain.RuleType, Value: "number"},
+			},
+		},
+		{
+			name:        "GivenAServiceError_ReturnProblemWithoutViolations",
+			requestBody: `{"retailer": "Target", "purchaseDate": "2022-01-02", "purchaseTime": "08:13", "total": "1.25", "items": [{"shortDescription": "Pepsi", "price": "1.25"}]}`,
+			service: &MockReceiptService{
+				ProcessReceiptMock: func(ctx context.Context, request receiptDomain.ReceiptProcessorRequest) (receiptDomain.ReceiptProcessorResponse, domain.StatusCode) {
+					return receiptDomain.ReceiptProcessorResponse{}, domain.ErrInternal
+				},
+				GenerateIDMock: func(ctx context.Context, input string) string {
+					return ""
+				},
+			},
+			expectedStatus: domain.ErrInternal,
+		},
+		{
+			name:        "GivenAnInconsistentReceiptInStrictMode_ReturnViolations",
+			requestBody: `{"retailer": "Target", "purchaseDate": "2023-02-31", "purchaseTime": "08:13", "total": "9999.00", "items": [{"shortDescription": "Pepsi", "price": "1.25"}]}`,
+			service: &MockReceiptService{
+				ValidateReceiptMock: func(ctx context.Context, receipt *receiptDomain.Receipt) ([]domain.Violation, []domain.Violation) {
+					return []domain.Violation{
+						{Path: "/purchaseDate", Rule: domain.RuleDate, Value: receipt.PurchaseDate},
+						{Path: "/total", Rule: domain.RuleSum, Value: receipt.Total},
+					}, nil
+				},
+			},
+			expectedStatus: domain.ErrBadRequest,
+			expectedViolations: []domain.Violation{
+				{Path: "/purchaseDate", Rule: domain.RuleDate, Value: "2023-02-31"},
+				{Path: "/total", Rule: domain.RuleSum, Value: "9999.00"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := receiptHandler.ProcessReceipt(tt.service)
+
+			request, err := http.NewRequest(http.MethodPost, "/receipts/process", strings.NewReader(tt.requestBody))
+			if err != nil {
+				t.Fatalf("Failed to build request: %v", err)
+			}
+
+			responseRecorder := httptest.NewRecorder()
+			handler.ServeHTTP(responseRecorder, request)
+
+			var problem handlers.Problem
+			if err := json.Unmarshal(responseRecorder.Body.Bytes(), &problem); err != nil {
+				t.Fatalf("Failed to unmarshal problem: %v", err)
+			}
+
+			assert.Equal(t, handlers.ProblemContentType, responseRecorder.Header().Get("Content-Type"))
+			assert.Equal(t, handlers.NewProblem(tt.expectedStatus, "/receipts/process", tt.expectedViolations...), problem)
+		})
+	}
+}
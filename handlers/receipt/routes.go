@@ -6,7 +6,10 @@ import (
 	"github.com/kevin07696/receipt-processor/domain/receipt"
 )
 
-func InitializeRoutes(router *http.ServeMux, receiptAPI receipt.IReceiptProcessorService) {
+func InitializeRoutes(router *http.ServeMux, receiptAPI receipt.IReceiptProcessorService, batchOpts BatchOptions) {
 	router.HandleFunc("POST /receipts/process", ProcessReceipt(receiptAPI))
+	router.HandleFunc("POST /receipts/process:batch", ProcessReceiptBatch(receiptAPI, batchOpts))
+	router.HandleFunc("POST /receipts/simulate", SimulateReceipt(receiptAPI, batchOpts))
 	router.HandleFunc("GET /receipts/{id}/points", GetScore(receiptAPI))
+	router.HandleFunc("GET /receipts/{id}/breakdown", GetBreakdown(receiptAPI))
 }
@@ -0,0 +1,173 @@
+package receipt
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/kevin07696/receipt-processor/domain"
+	"github.com/kevin07696/receipt-processor/domain/receipt"
+	"github.com/kevin07696/receipt-processor/handlers"
+)
+
+// SimulationRequest holds either one Receipt or a batch of Receipts.
+// Options and Multipliers are overlaid on the live rule set, fields they leave out keep their live value.
+type SimulationRequest struct {
+	Receipt     json.RawMessage
+	Receipts    []json.RawMessage
+	Options     json.RawMessage
+	Multipliers json.RawMessage
+}
+
+// SimulationResult holds either the simulation of a receipt or the problem that stopped it.
+type SimulationResult struct {
+	Simulation *receipt.ReceiptSimulationResponse `json:",omitempty"`
+	Error      *handlers.Problem                  `json:",omitempty"`
+}
+
+type SimulationBatchResponse struct {
+	Results        []SimulationResult
+	Succeeded      int
+	Failed         int
+	LivePoints     int64
+	ProposedPoints int64
+}
+
+// SimulateReceipt scores receipts with the live and the proposed configuration. Nothing is stored.
+func SimulateReceipt(receiptAPI receipt.IReceiptProcessorService, opts BatchOptions) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx, cancel := context.WithTimeout(r.Context(), time.Second)
+		defer cancel()
+
+		var request SimulationRequest
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+			slog.DebugContext(ctx, "Unmarshal Error: Failed to unmarshal simulation request.", slog.Any("error", err))
+			handlers.WriteProblem(w, r, domain.ErrBadRequest, unmarshalViolations(err)...)
+			return
+		}
+		if (len(request.Receipt) == 0) == (len(request.Receipts) == 0) {
+			slog.DebugContext(ctx, "StatusBadRequest: simulation needs either a receipt or receipts")
+			handlers.WriteProblem(w, r, domain.ErrBadRequest, domain.Violation{Path: "/Receipt", Rule: domain.RuleRequired})
+			return
+		}
+		if opts.MaxSize > 0 && len(request.Receipts) > opts.MaxSize {
+			slog.DebugContext(ctx, "StatusBadRequest: receipt batch is too large", slog.Int("size", len(request.Receipts)))
+			handlers.WriteProblem(w, r, domain.ErrBadRequest)
+			return
+		}
+
+		propose, violations := proposal(request.Options, request.Multipliers)
+		if len(violations) > 0 {
+			handlers.WriteProblem(w, r, domain.ErrBadRequest, violations...)
+			return
+		}
+
+		var response any
+		if len(request.Receipt) > 0 {
+			simulation, status, violations := simulateReceipt(ctx, receiptAPI, request.Receipt, "/Receipt", propose)
+			if status > 0 {
+				handlers.WriteProblem(w, r, status, violations...)
+				return
+			}
+			response = simulation
+		} else {
+			response = simulateReceiptBatch(ctx, receiptAPI, request.Receipts, propose)
+		}
+
+		jsonResponse, err := json.Marshal(response)
+		if err != nil {
+			slog.ErrorContext(ctx, "Marshal Error: Failed to marshal response.", slog.Any("error", err))
+			handlers.WriteProblem(w, r, domain.ErrInternal)
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+		w.Write(jsonResponse)
+	}
+}
+
+func simulateReceiptBatch(ctx context.Context, receiptAPI receipt.IReceiptProcessorService, raws []json.RawMessage, propose func(*receipt.Options, *receipt.Multipliers) error) SimulationBatchResponse {
+	response := SimulationBatchResponse{Results: make([]SimulationResult, len(raws))}
+	for i, raw := range raws {
+		simulation, status, violations := simulateReceipt(ctx, receiptAPI, raw, "", propose)
+		if status > 0 {
+			problem := handlers.NewProblem(status, "", violations...)
+			response.Results[i] = SimulationResult{Error: &problem}
+			response.Failed++
+			continue
+		}
+
+		response.Results[i] = SimulationResult{Simulation: &simulation}
+		response.Succeeded++
+		response.LivePoints += simulation.Live.Points
+		response.ProposedPoints += simulation.Proposed.Points
+	}
+	return response
+}
+
+// simulateReceipt prefixes the paths of the violations of the receipt with path.
+func simulateReceipt(ctx context.Context, receiptAPI receipt.IReceiptProcessorService, raw json.RawMessage, path string, propose func(*receipt.Options, *receipt.Multipliers) error) (receipt.ReceiptSimulationResponse, domain.StatusCode, []domain.Violation) {
+	var input receipt.Receipt
+	if err := json.Unmarshal(raw, &input); err != nil {
+		slog.DebugContext(ctx, "Unmarshal Error: Failed to unmarshal receipt.", slog.Any("error", err))
+		return receipt.ReceiptSimulationResponse{}, domain.ErrBadRequest, prefixViolations(path, unmarshalViolations(err))
+	}
+	violations, warnings := receiptAPI.ValidateReceipt(ctx, &input)
+	if len(violations) > 0 {
+		return receipt.ReceiptSimulationResponse{}, domain.ErrBadRequest, prefixViolations(path, violations)
+	}
+
+	simulation, status, violations := receiptAPI.SimulateReceipt(ctx, receipt.ReceiptSimulationRequest{Receipt: input, Propose: propose})
+	simulation.Warnings = warnings
+	return simulation, status, violations
+}
+
+func prefixViolations(prefix string, violations []domain.Violation) []domain.Violation {
+	for i := range violations {
+		violations[i].Path = prefix + violations[i].Path
+	}
+	return violations
+}
+
+// proposal checks that the JSON options and multipliers decode and returns a function that overlays them.
+// The options they make are validated per receipt, against the rule set that scores it.
+func proposal(options, multipliers json.RawMessage) (func(*receipt.Options, *receipt.Multipliers) error, []domain.Violation) {
+	var violations []domain.Violation
+	var opts receipt.Options
+	var mults receipt.Multipliers
+	if len(options) > 0 {
+		if err := json.Unmarshal(options, &opts); err != nil {
+			violations = append(violations, proposalViolations("/Options", err)...)
+		}
+	}
+	if len(multipliers) > 0 {
+		if err := json.Unmarshal(multipliers, &mults); err != nil {
+			violations = append(violations, proposalViolations("/Multipliers", err)...)
+		}
+	}
+	if len(violations) > 0 {
+		return nil, violations
+	}
+
+	return func(opts *receipt.Options, mults *receipt.Multipliers) error {
+		if len(options) > 0 {
+			if err := json.Unmarshal(options, opts); err != nil {
+				return err
+			}
+		}
+		if len(multipliers) > 0 {
+			return json.Unmarshal(multipliers, mults)
+		}
+		return nil
+	}, nil
+}
+
+func proposalViolations(prefix string, err error) []domain.Violation {
+	violations := unmarshalViolations(err)
+	if len(violations) == 0 {
+		return []domain.Violation{{Path: prefix, Rule: domain.RuleType}}
+	}
+	return prefixViolations(prefix, violations)
+}
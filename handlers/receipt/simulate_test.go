@@ -0,0 +1,191 @@
+package receipt_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/kevin07696/receipt-processor/domain"
+	receiptDomain "github.com/kevin07696/receipt-processor/domain/receipt"
+	"github.com/kevin07696/receipt-processor/handlers"
+	receiptHandler "github.com/kevin07696/receipt-processor/handlers/receipt"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSimulateReceipt(t *testing.T) {
+	invalidRetailer := handlers.NewProblem(domain.ErrBadRequest, "", domain.Violation{Path: "/retailer", Rule: domain.RulePattern, Value: "!@#"})
+
+	// Live scores 6 points per retailer multiplier of 1, the proposal scales it
+	simulation := func(multiplier int64) *receiptDomain.ReceiptSimulationResponse {
+		return &receiptDomain.ReceiptSimulationResponse{
+			RuleSetVersion: "v1",
+			Live:           receiptDomain.ScoreSimulation{Points: 6},
+			Proposed:       receiptDomain.ScoreSimulation{Points: 6 * multiplier},
+			Difference:     6*multiplier - 6,
+		}
+	}
+	simulateAPI := &MockReceiptService{
+		SimulateReceiptMock: func(ctx context.Context, request receiptDomain.ReceiptSimulationRequest) (receiptDomain.ReceiptSimulationResponse, domain.StatusCode, []domain.Violation) {
+			opts := receiptDomain.Options{
+				StartPurchaseTime:   "14:00",
+				EndPurchaseTime:     "16:00",
+				TotalMultiple:       0.25,
+				ItemsMultiple:       2,
+				DescriptionMultiple: 3,
+				Rules:               []string{receiptDomain.RuleRetailer},
+			}
+			mults := receiptDomain.Multipliers{Retailer: 1}
+			if err := request.Propose(&opts, &mults); err != nil {
+				return receiptDomain.ReceiptSimulationResponse{}, domain.ErrBadRequest, nil
+			}
+			if violations := opts.Validate(); len(violations) > 0 {
+				for i := range violations {
+					violations[i].Path = "/Options" + violations[i].Path
+				}
+				return receiptDomain.ReceiptSimulationResponse{}, domain.ErrBadRequest, violations
+			}
+			return *simulation(mults.Retailer), domain.StatusOK, nil
+		},
+		ProcessReceiptMock: func(ctx context.Context, request receiptDomain.ReceiptProcessorRequest) (receiptDomain.ReceiptProcessorResponse, domain.StatusCode) {
+			t.Fatal("A simulation must not process the receipt")
+			return receiptDomain.ReceiptProcessorResponse{}, domain.ErrInternal
+		},
+	}
+
+	tests := []struct {
+		name             string
+		requestBody      string
+		opts             receiptHandler.BatchOptions
+		expectedCode     int
+		expectedResponse any
+	}{
+		{
+			name:             "GivenAReceipt_ReturnLiveAndProposedScores",
+			requestBody:      `{"Receipt": ` + batchReceipt("A") + `, "Multipliers": {"Retailer": 3}}`,
+			expectedCode:     http.StatusOK,
+			expectedResponse: simulation(3),
+		},
+		{
+			name:             "GivenNoProposal_ReturnLiveScoreTwice",
+			requestBody:      `{"Receipt": ` + batchReceipt("A") + `}`,
+			expectedCode:     http.StatusOK,
+			expectedResponse: simulation(1),
+		},
+		{
+			name:         "GivenReceipts_ReturnResultsInOrder",
+			requestBody:  `{"Receipts": [` + batchReceipt("A") + `,` + batchReceipt("!@#") + `,` + batchReceipt("C") + `], "Multipliers": {"Retailer": 2}}`,
+			expectedCode: http.StatusOK,
+			expectedResponse: receiptHandler.SimulationBatchResponse{
+				Results:        []receiptHandler.SimulationResult{{Simulation: simulation(2)}, {Error: &invalidRetailer}, {Simulation: simulation(2)}},
+				Succeeded:      2,
+				Failed:         1,
+				LivePoints:     12,
+				ProposedPoints: 24,
+			},
+		},
+		{
+			name:         "GivenAnInvalidReceipt_ReturnBadRequestError",
+			requestBody:  `{"Receipt": ` + batchReceipt("!@#") + `}`,
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "GivenNoReceipt_ReturnBadRequestError",
+			requestBody:  `{"Multipliers": {"Retailer": 3}}`,
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "GivenAReceiptAndReceipts_ReturnBadRequestError",
+			requestBody:  `{"Receipt": ` + batchReceipt("A") + `, "Receipts": [` + batchReceipt("B") + `]}`,
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "GivenAMultiplierOfTheWrongType_ReturnBadRequestError",
+			requestBody:  `{"Receipt": ` + batchReceipt("A") + `, "Multipliers": {"Retailer": "3"}}`,
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "GivenAnUnknownRule_ReturnBadRequestError",
+			requestBody:  `{"Receipt": ` + batchReceipt("A") + `, "Options": {"Rules": ["retailer", "unknown"]}}`,
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "GivenAnUnknownItemsCount_ReturnBadRequestError",
+			requestBody:  `{"Receipt": ` + batchReceipt("A") + `, "Options": {"ItemsCount": "boxes"}}`,
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "GivenAnUnknownRetailerName_ReturnBadRequestError",
+			requestBody:  `{"Receipt": ` + batchReceipt("A") + `, "Options": {"RetailerName": "alias"}}`,
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:             "GivenAZeroDescriptionMultiple_ReturnBadRequestError",
+			requestBody:      `{"Receipt": ` + batchReceipt("A") + `, "Options": {"DescriptionMultiple": 0}}`,
+			expectedCode:     http.StatusBadRequest,
+			expectedResponse: handlers.NewProblem(domain.ErrBadRequest, "/receipts/simulate", domain.Violation{Path: "/Options/DescriptionMultiple", Rule: domain.RuleMin, Value: "0"}),
+		},
+		{
+			name:             "GivenAnEmptyStartPurchaseTime_ReturnBadRequestError",
+			requestBody:      `{"Receipt": ` + batchReceipt("A") + `, "Options": {"startPurchaseTime": ""}}`,
+			expectedCode:     http.StatusBadRequest,
+			expectedResponse: handlers.NewProblem(domain.ErrBadRequest, "/receipts/simulate", domain.Violation{Path: "/Options/StartPurchaseTime", Rule: domain.RuleRequired, Value: ""}),
+		},
+		{
+			name:         "GivenAZeroItemsMultipleAndTotalMultiple_ReturnBadRequestError",
+			requestBody:  `{"Receipt": ` + batchReceipt("A") + `, "Options": {"ItemsMultiple": 0, "TotalMultiple": 0.001}}`,
+			expectedCode: http.StatusBadRequest,
+			expectedResponse: handlers.NewProblem(domain.ErrBadRequest, "/receipts/simulate",
+				domain.Violation{Path: "/Options/ItemsMultiple", Rule: domain.RuleMin, Value: "0"},
+				domain.Violation{Path: "/Options/TotalMultiple", Rule: domain.RuleMin, Value: "0.001"},
+			),
+		},
+		{
+			name:         "GivenInvalidCaps_ReturnBadRequestError",
+			requestBody:  `{"Receipt": ` + batchReceipt("A") + `, "Options": {"Caps": {"Rules": {"unknown": 5}, "Receipt": 10, "Floor": 20}}}`,
+			expectedCode: http.StatusBadRequest,
+			expectedResponse: handlers.NewProblem(domain.ErrBadRequest, "/receipts/simulate",
+				domain.Violation{Path: "/Options/Caps/Rules/unknown", Rule: domain.RuleOneOf, Value: "unknown"},
+				domain.Violation{Path: "/Options/Caps/Floor", Rule: domain.RuleMax, Value: "20"},
+			),
+		},
+		{
+			name:             "GivenAStartPurchaseTimeAfterTheLiveEnd_ReturnBadRequestError",
+			requestBody:      `{"Receipt": ` + batchReceipt("A") + `, "Options": {"StartPurchaseTime": "17:00"}}`,
+			expectedCode:     http.StatusBadRequest,
+			expectedResponse: handlers.NewProblem(domain.ErrBadRequest, "/receipts/simulate", domain.Violation{Path: "/Options/EndPurchaseTime", Rule: domain.RuleMin, Value: "16:00"}),
+		},
+		{
+			name:         "GivenReceiptsOverMaxSize_ReturnBadRequestError",
+			requestBody:  `{"Receipts": [` + batchReceipt("A") + `,` + batchReceipt("B") + `]}`,
+			opts:         receiptHandler.BatchOptions{MaxSize: 1},
+			expectedCode: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := receiptHandler.SimulateReceipt(simulateAPI, tt.opts)
+
+			request, err := http.NewRequest(http.MethodPost, "/receipts/simulate", strings.NewReader(tt.requestBody))
+			if err != nil {
+				t.Fatalf("Failed to build request: %v", err)
+			}
+
+			responseRecorder := httptest.NewRecorder()
+			handler.ServeHTTP(responseRecorder, request)
+
+			assert.Equal(t, tt.expectedCode, responseRecorder.Code)
+			if tt.expectedResponse != nil {
+				jsonResponse, err := json.Marshal(tt.expectedResponse)
+				if err != nil {
+					t.Fatalf("Failed to marshal response: %v", err)
+				}
+
+				assert.Equal(t, string(jsonResponse), responseRecorder.Body.String())
+			}
+		})
+	}
+}
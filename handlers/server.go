@@ -1,19 +1,71 @@
 package handlers
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
+	"sync"
+	"time"
 )
 
-func StartServer(port int, handler http.Handler) {
-	server := &http.Server{
-		Addr:    fmt.Sprintf(":%d", port),
-		Handler: handler,
+type Server struct {
+	Name    string
+	Port    int
+	Handler http.Handler
+}
+
+// Servers are served together by StartServer.
+type Servers []Server
+
+func (s Servers) Serve(ctx context.Context, drainTimeout time.Duration) error {
+	return StartServer(ctx, drainTimeout, s...)
+}
+
+// StartServer serves every server until ctx is done or one of them fails.
+// The servers are then shut down together, giving in-flight requests up to drainTimeout to finish.
+func StartServer(ctx context.Context, drainTimeout time.Duration, servers ...Server) error {
+	httpServers := make([]*http.Server, len(servers))
+	errs := make(chan error, len(servers))
+
+	for i, s := range servers {
+		httpServers[i] = &http.Server{
+			Addr:    fmt.Sprintf(":%d", s.Port),
+			Handler: s.Handler,
+		}
+
+		go func(name string, server *http.Server) {
+			log.Printf("Starting %s server at %s\n", name, server.Addr)
+			if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+				errs <- fmt.Errorf("%s server failed to start: %w", name, err)
+			}
+		}(s.Name, httpServers[i])
 	}
 
-	log.Printf("Starting server at :%d\n", port)
-	if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-		log.Fatalf("Server failed to start: %v", err)
+	var serveErr error
+	select {
+	case <-ctx.Done():
+		log.Printf("Shutting down servers, draining requests for up to %s\n", drainTimeout)
+	case serveErr = <-errs:
+		log.Printf("Shutting down servers: %v\n", serveErr)
 	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), drainTimeout)
+	defer cancel()
+
+	var wg sync.WaitGroup
+	shutdownErrs := make([]error, len(httpServers))
+	for i, server := range httpServers {
+		wg.Add(1)
+		go func(i int, server *http.Server) {
+			defer wg.Done()
+			if err := server.Shutdown(shutdownCtx); err != nil {
+				shutdownErrs[i] = fmt.Errorf("%s server failed to shut down: %w", servers[i].Name, err)
+			}
+		}(i, server)
+	}
+	wg.Wait()
+
+	return errors.Join(append([]error{serveErr}, shutdownErrs...)...)
 }
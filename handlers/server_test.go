@@ -0,0 +1,97 @@
+package handlers_test
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/kevin07696/receipt-processor/handlers"
+	"github.com/stretchr/testify/assert"
+)
+
+func freePort(t *testing.T) int {
+	listener, err := net.Listen("tcp", ":0")
+	if err != nil {
+		t.Fatalf("Failed to find a free port: %v", err)
+	}
+	defer listener.Close()
+	return listener.Addr().(*net.TCPAddr).Port
+}
+
+func waitForServer(t *testing.T, port int) {
+	for i := 0; i < 100; i++ {
+		conn, err := net.Dial("tcp", fmt.Sprintf("localhost:%d", port))
+		if err == nil {
+			conn.Close()
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("Server on port %d did not start", port)
+}
+
+func TestStartServerDrainsRequests(t *testing.T) {
+	publicPort, adminPort := freePort(t), freePort(t)
+	started := make(chan struct{})
+	slow := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		time.Sleep(100 * time.Millisecond)
+		w.Write([]byte("done"))
+	})
+	health := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("OK"))
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	stopped := make(chan error)
+	go func() {
+		stopped <- handlers.StartServer(ctx, time.Second,
+			handlers.Server{Name: "public", Port: publicPort, Handler: slow},
+			handlers.Server{Name: "admin", Port: adminPort, Handler: health},
+		)
+	}()
+	waitForServer(t, publicPort)
+	waitForServer(t, adminPort)
+
+	response, err := http.Get(fmt.Sprintf("http://localhost:%d/health", adminPort))
+	if err != nil {
+		t.Fatalf("Failed to call admin server: %v", err)
+	}
+	response.Body.Close()
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+
+	body := make(chan string)
+	go func() {
+		response, err := http.Get(fmt.Sprintf("http://localhost:%d/", publicPort))
+		if err != nil {
+			body <- err.Error()
+			return
+		}
+		defer response.Body.Close()
+		b, _ := io.ReadAll(response.Body)
+		body <- string(b)
+	}()
+
+	<-started
+	cancel()
+
+	assert.Equal(t, "done", <-body)
+	assert.NoError(t, <-stopped)
+}
+
+func TestStartServerFailsOnBusyPort(t *testing.T) {
+	listener, err := net.Listen("tcp", ":0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	defer listener.Close()
+
+	err = handlers.StartServer(context.Background(), time.Second,
+		handlers.Server{Name: "public", Port: listener.Addr().(*net.TCPAddr).Port, Handler: http.NotFoundHandler()},
+	)
+	assert.Error(t, err)
+}
@@ -1,21 +1,75 @@
 package config
 
 import (
+	"encoding/json"
+	"fmt"
 	"log"
+	"maps"
 	"os"
+	"regexp"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/joho/godotenv"
 	receiptDomain "github.com/kevin07696/receipt-processor/domain/receipt"
 )
 
+const (
+	RepositoryLRU      = "lru"
+	RepositoryFile     = "file"
+	RepositorySQLite   = "sqlite"
+	RepositoryBigCache = "bigcache"
+)
+
+type FileStoreConfig struct {
+	Dir          string
+	CompactEvery int
+}
+
+type BigCacheConfig struct {
+	Shards     int
+	LifeWindow time.Duration
+	MaxSizeMB  int
+}
+
+// TieredConfig puts the REPOSITORY cache in front of a durable Cold repository when Cold is set.
+type TieredConfig struct {
+	Cold         string
+	WriteMode    receiptDomain.WriteMode
+	QueueSize    int
+	Retries      int
+	RetryBackoff time.Duration
+	NegativeTTL  time.Duration
+}
+
 type Config struct {
-	AppEnv      string
-	AppPort     int
-	AdminPort   int
-	CacheCap    int
-	Multipliers receiptDomain.Multipliers
-	Options     receiptDomain.Options
+	AppEnv    string
+	AppPort   int
+	AdminPort int
+	CacheCap  int
+	// CacheTTL is how long a score stays in the lru repository, 0 keeps it until it is evicted.
+	CacheTTL time.Duration
+	// CacheShards is the number of independently locked shards of the lru repository, 0 or 1 does not shard it.
+	CacheShards  int
+	Repository   string
+	FileStore    FileStoreConfig
+	BigCache     BigCacheConfig
+	Tiered       TieredConfig
+	SQLitePath   string
+	BatchWorkers int
+	BatchMaxSize int
+	// ShutdownTimeout is how long in-flight requests may run after SIGINT or SIGTERM.
+	ShutdownTimeout time.Duration
+	Multipliers     receiptDomain.Multipliers
+	Options         receiptDomain.Options
+	// RuleSets are the rule set of Multipliers and Options followed by the versions of RULE_SETS_FILE.
+	RuleSets        []receiptDomain.RuleSet
+	RuleSetSelectBy receiptDomain.SelectBy
+	// RetailersFile is the JSON file of the retailer registry. Empty keeps the registry in memory.
+	RetailersFile string
+	// CampaignsFile is the JSON file of the campaign registry. Empty keeps the registry in memory.
+	CampaignsFile string
 }
 
 func LoadEnvConfig() Config {
@@ -25,22 +79,58 @@ func LoadEnvConfig() Config {
 	}
 
 	env := map[string]interface{}{
-		"APP_ENV":              "",
-		"APP_PORT":             int(0),
-		"ADMIN_PORT":           int(0),
-		"MULT_RECEIPT":         int64(0),
-		"MULT_ROUND_TOTAL":     int64(0),
-		"MULT_DIVISIBLE_TOTAL": int64(0),
-		"MULT_ITEMS":           float64(0),
-		"MULT_DESCRIPTION":     float64(0),
-		"MULT_PURCHASE_TIME":   int64(0),
-		"MULT_PURCHASE_DATE":   int64(0),
-		"START_TIME":           "",
-		"END_TIME":             "",
-		"TOTAL_MULTIPLE":       float64(0),
-		"ITEMS_MULTIPLE":       int64(0),
-		"DESCRIPTION_MULTIPLE": int64(0),
-		"CACHE_CAP":            int(0),
+		"APP_ENV":                  "",
+		"APP_PORT":                 int(0),
+		"ADMIN_PORT":               int(0),
+		"MULT_RECEIPT":             int64(0),
+		"MULT_ROUND_TOTAL":         int64(0),
+		"MULT_DIVISIBLE_TOTAL":     int64(0),
+		"MULT_ITEMS":               float64(0),
+		"MULT_DESCRIPTION":         float64(0),
+		"MULT_PURCHASE_TIME":       int64(0),
+		"MULT_PURCHASE_DATE":       int64(0),
+		"MULT_COUPON":              int64(0),
+		"ITEMS_COUNT":              "",
+		"START_TIME":               "",
+		"END_TIME":                 "",
+		"TOTAL_MULTIPLE":           float64(0),
+		"ITEMS_MULTIPLE":           int64(0),
+		"DESCRIPTION_MULTIPLE":     int64(0),
+		"CACHE_CAP":                int(0),
+		"CACHE_TTL":                int(0),
+		"CACHE_SHARDS":             int(0),
+		"SCORING_RULES":            "",
+		"RULE_SET_VERSION":         "",
+		"RULE_SET_SELECT_BY":       "",
+		"RULE_SETS_FILE":           "",
+		"RETAILER_OVERRIDES_FILE":  "",
+		"REPOSITORY":               "",
+		"FILE_STORE_DIR":           "",
+		"FILE_STORE_COMPACT":       int(0),
+		"SQLITE_PATH":              "",
+		"BIGCACHE_SHARDS":          int(0),
+		"BIGCACHE_LIFE_WINDOW":     int(0),
+		"BIGCACHE_MAX_SIZE":        int(0),
+		"TIERED_COLD":              "",
+		"TIERED_WRITE_MODE":        "",
+		"TIERED_QUEUE_SIZE":        int(0),
+		"TIERED_RETRIES":           int(0),
+		"TIERED_RETRY_BACKOFF":     int(0),
+		"NEGATIVE_CACHE_TTL":       int(0),
+		"BATCH_WORKERS":            int(0),
+		"BATCH_MAX_SIZE":           int(0),
+		"SHUTDOWN_TIMEOUT":         int(0),
+		"CONSISTENCY_MODE":         "",
+		"CONSISTENCY_TOLERANCE":    float64(0),
+		"RETAILERS_FILE":           "",
+		"RETAILER_NAME":            "",
+		"CAMPAIGNS_FILE":           "",
+		"CUSTOM_RULES_FILE":        "",
+		"POINTS_RULE_CAPS":         "",
+		"POINTS_MAX_PER_RECEIPT":   int(0),
+		"POINTS_MIN_PER_RECEIPT":   int(0),
+		"POINTS_USER_DAILY_CAP":    int(0),
+		"POINTS_USER_RETAILER_CAP": int(0),
 	}
 
 	for k := range env {
@@ -76,11 +166,38 @@ func LoadEnvConfig() Config {
 		}
 	}
 
+	// Custom rules are registered first, so SCORING_RULES and the rule set files can enable them by name
+	registerCustomRules(env["CUSTOM_RULES_FILE"].(string))
+
 	config := Config{
-		AppEnv:    env["APP_ENV"].(string),
-		AppPort:   env["APP_PORT"].(int),
-		AdminPort: env["ADMIN_PORT"].(int),
-		CacheCap:  env["CACHE_CAP"].(int),
+		AppEnv:      env["APP_ENV"].(string),
+		AppPort:     env["APP_PORT"].(int),
+		AdminPort:   env["ADMIN_PORT"].(int),
+		CacheCap:    env["CACHE_CAP"].(int),
+		CacheTTL:    time.Duration(env["CACHE_TTL"].(int)) * time.Second,
+		CacheShards: env["CACHE_SHARDS"].(int),
+		Repository:  parseRepository(env["REPOSITORY"].(string)),
+		FileStore: FileStoreConfig{
+			Dir:          env["FILE_STORE_DIR"].(string),
+			CompactEvery: env["FILE_STORE_COMPACT"].(int),
+		},
+		BigCache: BigCacheConfig{
+			Shards:     env["BIGCACHE_SHARDS"].(int),
+			LifeWindow: time.Duration(env["BIGCACHE_LIFE_WINDOW"].(int)) * time.Second,
+			MaxSizeMB:  env["BIGCACHE_MAX_SIZE"].(int),
+		},
+		SQLitePath: env["SQLITE_PATH"].(string),
+		Tiered: TieredConfig{
+			Cold:         env["TIERED_COLD"].(string),
+			WriteMode:    parseWriteMode(env["TIERED_WRITE_MODE"].(string)),
+			QueueSize:    env["TIERED_QUEUE_SIZE"].(int),
+			Retries:      env["TIERED_RETRIES"].(int),
+			RetryBackoff: time.Duration(env["TIERED_RETRY_BACKOFF"].(int)) * time.Millisecond,
+			NegativeTTL:  time.Duration(env["NEGATIVE_CACHE_TTL"].(int)) * time.Second,
+		},
+		BatchWorkers:    env["BATCH_WORKERS"].(int),
+		BatchMaxSize:    env["BATCH_MAX_SIZE"].(int),
+		ShutdownTimeout: time.Duration(env["SHUTDOWN_TIMEOUT"].(int)) * time.Second,
 		Multipliers: receiptDomain.Multipliers{
 			Retailer:       env["MULT_RECEIPT"].(int64),
 			RoundTotal:     env["MULT_ROUND_TOTAL"].(int64),
@@ -89,15 +206,364 @@ func LoadEnvConfig() Config {
 			Description:    env["MULT_DESCRIPTION"].(float64),
 			PurchaseTime:   env["MULT_PURCHASE_TIME"].(int64),
 			PurchaseDate:   env["MULT_PURCHASE_DATE"].(int64),
+			Coupon:         env["MULT_COUPON"].(int64),
 		},
 		Options: receiptDomain.Options{
 			StartPurchaseTime:   env["START_TIME"].(string),
 			EndPurchaseTime:     env["END_TIME"].(string),
 			TotalMultiple:       env["TOTAL_MULTIPLE"].(float64),
 			ItemsMultiple:       env["ITEMS_MULTIPLE"].(int64),
+			ItemsCount:          parseItemCount(env["ITEMS_COUNT"].(string)),
 			DescriptionMultiple: env["DESCRIPTION_MULTIPLE"].(int64),
+			Rules:               parseRules(env["SCORING_RULES"].(string)),
+			Consistency: receiptDomain.ConsistencyOptions{
+				Mode:      parseConsistencyMode(env["CONSISTENCY_MODE"].(string)),
+				Tolerance: receiptDomain.MoneyFromFloat(env["CONSISTENCY_TOLERANCE"].(float64)),
+			},
+			RetailerName: parseRetailerName(env["RETAILER_NAME"].(string)),
+			Caps: receiptDomain.Caps{
+				Rules:        parseRuleCaps(env["POINTS_RULE_CAPS"].(string)),
+				Receipt:      int64(env["POINTS_MAX_PER_RECEIPT"].(int)),
+				Floor:        int64(env["POINTS_MIN_PER_RECEIPT"].(int)),
+				UserDaily:    int64(env["POINTS_USER_DAILY_CAP"].(int)),
+				UserRetailer: int64(env["POINTS_USER_RETAILER_CAP"].(int)),
+			},
 		},
+		RetailersFile: env["RETAILERS_FILE"].(string),
+		CampaignsFile: env["CAMPAIGNS_FILE"].(string),
 	}
 
+	validateTiered(config.Repository, config.Tiered.Cold)
+	if err := validateOptions(config.Options); err != nil {
+		log.Fatalf("Error validating the score rule and POINTS variables: %v", err)
+	}
+
+	base := receiptDomain.RuleSet{
+		Version:     env["RULE_SET_VERSION"].(string),
+		Options:     config.Options,
+		Multipliers: config.Multipliers,
+	}
+	if base.Version == "" {
+		base.Version = receiptDomain.DefaultRuleSetVersion
+	}
+	config.RuleSets = append([]receiptDomain.RuleSet{base}, parseRuleSets(env["RULE_SETS_FILE"].(string), base)...)
+	overrides := readOverrideFiles(env["RETAILER_OVERRIDES_FILE"].(string))
+	for i := range config.RuleSets {
+		config.RuleSets[i].Overrides = parseOverrides(overrides, config.RuleSets[i])
+	}
+	config.RuleSetSelectBy = parseSelectBy(env["RULE_SET_SELECT_BY"].(string))
+
 	return config
 }
+
+// parseRules splits a comma separated list of scoring rule names and checks each one is registered.
+func parseRules(val string) []string {
+	var rules []string
+	for _, name := range strings.Split(val, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		if !receiptDomain.DefaultRules.Has(name) {
+			log.Fatalf("Error parsing SCORING_RULES: unknown rule %s, expected one of %v", name, receiptDomain.DefaultRules.Names())
+		}
+		rules = append(rules, name)
+	}
+	return rules
+}
+
+// parseRuleCaps splits a comma separated list of rule:cap pairs, e.g. retailer:50,items:100.
+func parseRuleCaps(val string) map[string]int64 {
+	caps := make(map[string]int64)
+	for _, pair := range strings.Split(val, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		name, limit, ok := strings.Cut(pair, ":")
+		if !ok {
+			log.Fatalf("Error parsing POINTS_RULE_CAPS: %s is not rule:cap", pair)
+		}
+		parsed, err := strconv.ParseInt(strings.TrimSpace(limit), 10, 64)
+		if err != nil {
+			log.Fatalf("Error parsing POINTS_RULE_CAPS: %s: %v", pair, err)
+		}
+		caps[strings.TrimSpace(name)] = parsed
+	}
+	return caps
+}
+
+// registerCustomRules compiles the custom rules of path into the default rules. An empty path has no custom rules.
+func registerCustomRules(path string) {
+	if path == "" {
+		return
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		log.Fatalf("Error reading CUSTOM_RULES_FILE: %v", err)
+	}
+	var rules []receiptDomain.CustomRule
+	if err := json.Unmarshal(data, &rules); err != nil {
+		log.Fatalf("Error parsing CUSTOM_RULES_FILE: %v", err)
+	}
+	if err := receiptDomain.DefaultRules.RegisterCustom(rules...); err != nil {
+		log.Fatalf("Error compiling CUSTOM_RULES_FILE: %v", err)
+	}
+}
+
+// ruleSetFile is a rule set version in RULE_SETS_FILE. Options and Multipliers only override the fields they set.
+type ruleSetFile struct {
+	Version       string
+	EffectiveFrom string
+	Options       json.RawMessage
+	Multipliers   json.RawMessage
+}
+
+// parseRuleSets reads the rule set versions of path on top of the base rule set. An empty path has no versions.
+func parseRuleSets(path string, base receiptDomain.RuleSet) []receiptDomain.RuleSet {
+	if path == "" {
+		return nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		log.Fatalf("Error reading RULE_SETS_FILE: %v", err)
+	}
+	var files []ruleSetFile
+	if err := json.Unmarshal(data, &files); err != nil {
+		log.Fatalf("Error parsing RULE_SETS_FILE: %v", err)
+	}
+
+	ruleSets := make([]receiptDomain.RuleSet, len(files))
+	for i, file := range files {
+		ruleSet, err := parseRuleSet(file, base)
+		if err != nil {
+			log.Fatalf("Error parsing RULE_SETS_FILE: rule set %d: %v", i, err)
+		}
+		ruleSets[i] = ruleSet
+	}
+	return ruleSets
+}
+
+func parseRuleSet(file ruleSetFile, base receiptDomain.RuleSet) (receiptDomain.RuleSet, error) {
+	ruleSet := receiptDomain.RuleSet{
+		Version:     file.Version,
+		Options:     base.Options,
+		Multipliers: base.Multipliers,
+	}
+	// Copied, because decoding Rules reuses the array of the base rules and decoding Caps.Rules writes to the base map
+	ruleSet.Options.Rules = append([]string(nil), base.Options.Rules...)
+	ruleSet.Options.Caps.Rules = maps.Clone(base.Options.Caps.Rules)
+
+	effectiveFrom, err := time.Parse(time.DateOnly, file.EffectiveFrom)
+	if err != nil {
+		return ruleSet, fmt.Errorf("EffectiveFrom: %w", err)
+	}
+	ruleSet.EffectiveFrom = effectiveFrom
+
+	if len(file.Options) > 0 {
+		if err := json.Unmarshal(file.Options, &ruleSet.Options); err != nil {
+			return ruleSet, fmt.Errorf("Options: %w", err)
+		}
+	}
+	if len(file.Multipliers) > 0 {
+		if err := json.Unmarshal(file.Multipliers, &ruleSet.Multipliers); err != nil {
+			return ruleSet, fmt.Errorf("Multipliers: %w", err)
+		}
+	}
+
+	return ruleSet, validateOptions(ruleSet.Options)
+}
+
+// validateOptions checks the options a file may set, so a rule set never fails while scoring a receipt.
+func validateOptions(opts receiptDomain.Options) error {
+	if violations := opts.Validate(); len(violations) > 0 {
+		return fmt.Errorf("Options%s: %s %q", violations[0].Path, violations[0].Rule, violations[0].Value)
+	}
+	return nil
+}
+
+// HasUserCaps reports whether a rule set or override caps the points of users, so their points must be summed.
+func (c Config) HasUserCaps() bool {
+	for _, ruleSet := range c.RuleSets {
+		if ruleSet.Options.Caps.UserDaily > 0 || ruleSet.Options.Caps.UserRetailer > 0 {
+			return true
+		}
+		for _, override := range ruleSet.Overrides {
+			if override.Options.Caps.UserDaily > 0 || override.Options.Caps.UserRetailer > 0 {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// overrideFile is a retailer override in RETAILER_OVERRIDES_FILE. Options and Multipliers only override the fields they set.
+type overrideFile struct {
+	Name        string
+	Retailers   []string
+	Pattern     string
+	Options     json.RawMessage
+	Multipliers json.RawMessage
+	Factor      float64
+	Bonus       int64
+}
+
+// readOverrideFiles reads the retailer overrides of path. An empty path has no overrides.
+func readOverrideFiles(path string) []overrideFile {
+	if path == "" {
+		return nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		log.Fatalf("Error reading RETAILER_OVERRIDES_FILE: %v", err)
+	}
+	var files []overrideFile
+	if err := json.Unmarshal(data, &files); err != nil {
+		log.Fatalf("Error parsing RETAILER_OVERRIDES_FILE: %v", err)
+	}
+	return files
+}
+
+// parseOverrides applies the overrides on top of the options and multipliers of each rule set version.
+func parseOverrides(files []overrideFile, ruleSet receiptDomain.RuleSet) []receiptDomain.RetailerOverride {
+	overrides := make([]receiptDomain.RetailerOverride, len(files))
+	for i, file := range files {
+		override, err := parseOverride(file, ruleSet)
+		if err != nil {
+			log.Fatalf("Error parsing RETAILER_OVERRIDES_FILE: override %d: %v", i, err)
+		}
+		overrides[i] = override
+	}
+	return overrides
+}
+
+func parseOverride(file overrideFile, ruleSet receiptDomain.RuleSet) (receiptDomain.RetailerOverride, error) {
+	override := receiptDomain.RetailerOverride{
+		Name:        file.Name,
+		Retailers:   file.Retailers,
+		Options:     ruleSet.Options,
+		Multipliers: ruleSet.Multipliers,
+		Factor:      file.Factor,
+		Bonus:       file.Bonus,
+	}
+	override.Options.Rules = append([]string(nil), ruleSet.Options.Rules...)
+	override.Options.Caps.Rules = maps.Clone(ruleSet.Options.Caps.Rules)
+
+	if file.Pattern != "" {
+		pattern, err := regexp.Compile(file.Pattern)
+		if err != nil {
+			return override, fmt.Errorf("Pattern: %w", err)
+		}
+		override.Pattern = pattern
+	}
+	if len(file.Options) > 0 {
+		if err := json.Unmarshal(file.Options, &override.Options); err != nil {
+			return override, fmt.Errorf("Options: %w", err)
+		}
+	}
+	if len(file.Multipliers) > 0 {
+		if err := json.Unmarshal(file.Multipliers, &override.Multipliers); err != nil {
+			return override, fmt.Errorf("Multipliers: %w", err)
+		}
+	}
+	if file.Factor < 0 {
+		return override, fmt.Errorf("Factor: %v is negative", file.Factor)
+	}
+	if file.Factor > receiptDomain.MaxFactor {
+		return override, fmt.Errorf("Factor: %v is over %d", file.Factor, receiptDomain.MaxFactor)
+	}
+	return override, validateOptions(override.Options)
+}
+
+// parseRetailerName defaults to scoring the retailer as printed on the receipt.
+func parseRetailerName(val string) receiptDomain.RetailerName {
+	name := receiptDomain.RetailerName(val)
+	if name == "" {
+		return receiptDomain.RetailerNameRaw
+	}
+	if !name.Valid() {
+		log.Fatalf("Error parsing RETAILER_NAME: unknown name %s, expected %s or %s", val, receiptDomain.RetailerNameRaw, receiptDomain.RetailerNameCanonical)
+	}
+	return name
+}
+
+// parseItemCount defaults to counting item lines, as the challenge does.
+func parseItemCount(val string) receiptDomain.ItemCount {
+	count := receiptDomain.ItemCount(val)
+	if count == "" {
+		return receiptDomain.ItemCountLines
+	}
+	if !count.Valid() {
+		log.Fatalf("Error parsing ITEMS_COUNT: unknown count %s, expected %s or %s", val, receiptDomain.ItemCountLines, receiptDomain.ItemCountUnits)
+	}
+	return count
+}
+
+// parseConsistencyMode defaults to reporting inconsistent receipts as warnings.
+func parseConsistencyMode(val string) receiptDomain.ConsistencyMode {
+	switch receiptDomain.ConsistencyMode(val) {
+	case "":
+		return receiptDomain.ConsistencyLenient
+	case receiptDomain.ConsistencyOff, receiptDomain.ConsistencyLenient, receiptDomain.ConsistencyStrict:
+		return receiptDomain.ConsistencyMode(val)
+	default:
+		log.Fatalf("Error parsing CONSISTENCY_MODE: unknown mode %s, expected %s, %s or %s", val, receiptDomain.ConsistencyOff, receiptDomain.ConsistencyLenient, receiptDomain.ConsistencyStrict)
+		return ""
+	}
+}
+
+// parseSelectBy defaults to selecting rule sets by purchase date.
+func parseSelectBy(val string) receiptDomain.SelectBy {
+	switch receiptDomain.SelectBy(val) {
+	case "":
+		return receiptDomain.SelectByPurchaseDate
+	case receiptDomain.SelectByPurchaseDate, receiptDomain.SelectByProcessedAt:
+		return receiptDomain.SelectBy(val)
+	default:
+		log.Fatalf("Error parsing RULE_SET_SELECT_BY: unknown selection %s, expected %s or %s", val, receiptDomain.SelectByPurchaseDate, receiptDomain.SelectByProcessedAt)
+		return ""
+	}
+}
+
+// parseWriteMode defaults to write through when no write mode is set.
+func parseWriteMode(val string) receiptDomain.WriteMode {
+	switch receiptDomain.WriteMode(val) {
+	case "":
+		return receiptDomain.WriteThrough
+	case receiptDomain.WriteThrough, receiptDomain.WriteBehind:
+		return receiptDomain.WriteMode(val)
+	default:
+		log.Fatalf("Error parsing TIERED_WRITE_MODE: unknown write mode %s, expected %s or %s", val, receiptDomain.WriteThrough, receiptDomain.WriteBehind)
+		return ""
+	}
+}
+
+// validateTiered checks a tiered repository has a cache in front of a durable repository.
+func validateTiered(repository string, cold string) {
+	switch cold {
+	case "":
+		return
+	case RepositoryFile, RepositorySQLite:
+	default:
+		log.Fatalf("Error parsing TIERED_COLD: unknown repository %s, expected %s or %s", cold, RepositoryFile, RepositorySQLite)
+	}
+	if repository != RepositoryLRU && repository != RepositoryBigCache {
+		log.Fatalf("Error parsing REPOSITORY: %s can not be the hot tier in front of %s, expected %s or %s", repository, cold, RepositoryLRU, RepositoryBigCache)
+	}
+}
+
+// parseRepository defaults to the in-memory LRU cache when no repository is set.
+func parseRepository(val string) string {
+	switch val {
+	case "":
+		return RepositoryLRU
+	case RepositoryLRU, RepositoryFile, RepositorySQLite, RepositoryBigCache:
+		return val
+	default:
+		log.Fatalf("Error parsing REPOSITORY: unknown repository %s, expected %s, %s, %s or %s", val, RepositoryLRU, RepositoryFile, RepositorySQLite, RepositoryBigCache)
+		return ""
+	}
+}
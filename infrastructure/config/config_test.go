@@ -0,0 +1,220 @@
+package config
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	receiptDomain "github.com/kevin07696/receipt-processor/domain/receipt"
+	"github.com/stretchr/testify/assert"
+)
+
+func baseRuleSet() receiptDomain.RuleSet {
+	return receiptDomain.RuleSet{
+		Version: "base",
+		Options: receiptDomain.Options{
+			StartPurchaseTime:   "14:00",
+			EndPurchaseTime:     "16:00",
+			TotalMultiple:       0.25,
+			ItemsMultiple:       2,
+			DescriptionMultiple: 3,
+			Rules:               []string{receiptDomain.RuleRetailer, receiptDomain.RuleRoundTotal},
+			Caps:                receiptDomain.Caps{Rules: map[string]int64{receiptDomain.RuleRetailer: 10}, Receipt: 100},
+		},
+		Multipliers: receiptDomain.Multipliers{Retailer: 1, RoundTotal: 50},
+	}
+}
+
+func TestParseRuleSet(t *testing.T) {
+	testCases := []struct {
+		title         string
+		file          string
+		expectedError string
+		expected      func(ruleSet receiptDomain.RuleSet) bool
+	}{
+		{
+			title: "GivenAPartialOverlay_KeepTheOtherBaseFields",
+			file:  `{"Version": "v2", "EffectiveFrom": "2024-01-01", "Options": {"ItemsMultiple": 4, "Caps": {"Rules": {"round_total": 20}}}, "Multipliers": {"Retailer": 2}}`,
+			expected: func(ruleSet receiptDomain.RuleSet) bool {
+				return ruleSet.Version == "v2" &&
+					ruleSet.Options.ItemsMultiple == 4 &&
+					ruleSet.Options.DescriptionMultiple == 3 &&
+					ruleSet.Options.StartPurchaseTime == "14:00" &&
+					ruleSet.Options.Caps.Rules[receiptDomain.RuleRetailer] == 10 &&
+					ruleSet.Options.Caps.Rules[receiptDomain.RuleRoundTotal] == 20 &&
+					ruleSet.Options.Caps.Receipt == 100 &&
+					ruleSet.Multipliers.Retailer == 2 &&
+					ruleSet.Multipliers.RoundTotal == 50
+			},
+		},
+		{
+			title:    "GivenNoOptions_KeepTheBase",
+			file:     `{"Version": "v2", "EffectiveFrom": "2024-01-01"}`,
+			expected: func(ruleSet receiptDomain.RuleSet) bool { return ruleSet.Options.TotalMultiple == 0.25 },
+		},
+		{
+			title:         "GivenAnInvalidEffectiveFrom_ReturnError",
+			file:          `{"Version": "v2", "EffectiveFrom": "2024-13-01"}`,
+			expectedError: "EffectiveFrom",
+		},
+		{
+			title:         "GivenAnUnknownRule_ReturnError",
+			file:          `{"Version": "v2", "EffectiveFrom": "2024-01-01", "Options": {"Rules": ["retailer", "unknown"]}}`,
+			expectedError: `Options/Rules/1: oneof "unknown"`,
+		},
+		{
+			title:         "GivenAZeroDescriptionMultiple_ReturnError",
+			file:          `{"Version": "v2", "EffectiveFrom": "2024-01-01", "Options": {"DescriptionMultiple": 0}}`,
+			expectedError: `Options/DescriptionMultiple: min "0"`,
+		},
+		{
+			title:         "GivenAnInvalidStartPurchaseTime_ReturnError",
+			file:          `{"Version": "v2", "EffectiveFrom": "2024-01-01", "Options": {"StartPurchaseTime": "2pm"}}`,
+			expectedError: `Options/StartPurchaseTime: pattern "2pm"`,
+		},
+		{
+			title:         "GivenANegativeRuleCap_ReturnError",
+			file:          `{"Version": "v2", "EffectiveFrom": "2024-01-01", "Options": {"Caps": {"Rules": {"round_total": -1}}}}`,
+			expectedError: `Options/Caps/Rules/round_total: min "-1"`,
+		},
+		{
+			title:         "GivenANegativeReceiptCap_ReturnError",
+			file:          `{"Version": "v2", "EffectiveFrom": "2024-01-01", "Options": {"Caps": {"Receipt": -5}}}`,
+			expectedError: `Options/Caps/Receipt: min "-5"`,
+		},
+		{
+			title:         "GivenAFloorAboveTheReceiptCap_ReturnError",
+			file:          `{"Version": "v2", "EffectiveFrom": "2024-01-01", "Options": {"Caps": {"Floor": 150}}}`,
+			expectedError: `Options/Caps/Floor: max "150"`,
+		},
+		{
+			title:         "GivenMalformedMultipliers_ReturnError",
+			file:          `{"Version": "v2", "EffectiveFrom": "2024-01-01", "Multipliers": {"Retailer": "one"}}`,
+			expectedError: "Multipliers",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.title, func(t *testing.T) {
+			base := baseRuleSet()
+			var file ruleSetFile
+			if err := json.Unmarshal([]byte(tc.file), &file); err != nil {
+				t.Fatalf("Failed to unmarshal rule set file: %v", err)
+			}
+
+			ruleSet, err := parseRuleSet(file, base)
+			if tc.expectedError != "" {
+				assert.ErrorContains(t, err, tc.expectedError)
+				return
+			}
+			assert.NoError(t, err)
+			assert.True(t, tc.expected(ruleSet))
+			// The base is shared by every version, so overlays must not write to it
+			assert.Equal(t, baseRuleSet(), base)
+		})
+	}
+}
+
+func TestParseRuleSets(t *testing.T) {
+	t.Run("GivenNoPath_ReturnNoVersions", func(t *testing.T) {
+		assert.Nil(t, parseRuleSets("", baseRuleSet()))
+	})
+
+	t.Run("GivenAFile_OverlayEachVersionOnTheBase", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "rulesets.json")
+		data := `[
+			{"Version": "v1", "EffectiveFrom": "2023-01-01", "Options": {"Rules": ["retailer"]}},
+			{"Version": "v2", "EffectiveFrom": "2024-01-01", "Multipliers": {"RoundTotal": 75}}
+		]`
+		if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+			t.Fatalf("Failed to write rule sets: %v", err)
+		}
+
+		ruleSets := parseRuleSets(path, baseRuleSet())
+		assert.Len(t, ruleSets, 2)
+		assert.Equal(t, []string{receiptDomain.RuleRetailer}, ruleSets[0].Options.Rules)
+		assert.Equal(t, int64(50), ruleSets[0].Multipliers.RoundTotal)
+		// A version does not inherit from the one before it, only from the base
+		assert.Equal(t, []string{receiptDomain.RuleRetailer, receiptDomain.RuleRoundTotal}, ruleSets[1].Options.Rules)
+		assert.Equal(t, int64(75), ruleSets[1].Multipliers.RoundTotal)
+	})
+}
+
+func TestParseOverride(t *testing.T) {
+	testCases := []struct {
+		title         string
+		file          string
+		expectedError string
+		expected      func(override receiptDomain.RetailerOverride) bool
+	}{
+		{
+			title: "GivenAPartialOverlay_KeepTheOtherRuleSetFields",
+			file:  `{"Name": "partner", "Retailers": ["target"], "Pattern": "^Target", "Options": {"Caps": {"Receipt": 40}}, "Multipliers": {"RoundTotal": 10}, "Factor": 2}`,
+			expected: func(override receiptDomain.RetailerOverride) bool {
+				return override.Name == "partner" &&
+					override.Pattern.MatchString("Target Store") &&
+					override.Options.Caps.Receipt == 40 &&
+					override.Options.Caps.Rules[receiptDomain.RuleRetailer] == 10 &&
+					override.Options.ItemsMultiple == 2 &&
+					override.Multipliers.RoundTotal == 10 &&
+					override.Multipliers.Retailer == 1 &&
+					override.Factor == 2
+			},
+		},
+		{
+			title:         "GivenAnInvalidPattern_ReturnError",
+			file:          `{"Name": "partner", "Pattern": "("}`,
+			expectedError: "Pattern",
+		},
+		{
+			title:         "GivenANegativeFactor_ReturnError",
+			file:          `{"Name": "partner", "Factor": -1}`,
+			expectedError: "Factor: -1 is negative",
+		},
+		{
+			title:         "GivenAFactorOverTheMax_ReturnError",
+			file:          `{"Name": "partner", "Factor": 1e300}`,
+			expectedError: "Factor: 1e+300 is over 100",
+		},
+		{
+			title:         "GivenAnUnknownRule_ReturnError",
+			file:          `{"Name": "partner", "Options": {"Rules": ["unknown"]}}`,
+			expectedError: `Options/Rules/0: oneof "unknown"`,
+		},
+		{
+			title:         "GivenAZeroItemsMultiple_ReturnError",
+			file:          `{"Name": "partner", "Options": {"ItemsMultiple": 0}}`,
+			expectedError: `Options/ItemsMultiple: min "0"`,
+		},
+		{
+			title:         "GivenANegativeUserCap_ReturnError",
+			file:          `{"Name": "partner", "Options": {"Caps": {"UserDaily": -1}}}`,
+			expectedError: `Options/Caps/UserDaily: min "-1"`,
+		},
+		{
+			title:         "GivenAFloorAboveTheReceiptCap_ReturnError",
+			file:          `{"Name": "partner", "Options": {"Caps": {"Receipt": 20, "Floor": 30}}}`,
+			expectedError: `Options/Caps/Floor: max "30"`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.title, func(t *testing.T) {
+			ruleSet := baseRuleSet()
+			var file overrideFile
+			if err := json.Unmarshal([]byte(tc.file), &file); err != nil {
+				t.Fatalf("Failed to unmarshal override file: %v", err)
+			}
+
+			override, err := parseOverride(file, ruleSet)
+			if tc.expectedError != "" {
+				assert.ErrorContains(t, err, tc.expectedError)
+				return
+			}
+			assert.NoError(t, err)
+			assert.True(t, tc.expected(override))
+			assert.Equal(t, baseRuleSet(), ruleSet)
+		})
+	}
+}
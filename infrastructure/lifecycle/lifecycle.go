@@ -0,0 +1,132 @@
+package lifecycle
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"log/slog"
+	"os"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// Servers serve requests until ctx is done, then give in-flight requests up to drainTimeout to finish.
+type Servers interface {
+	Serve(ctx context.Context, drainTimeout time.Duration) error
+}
+
+type hook struct {
+	name string
+	fn   func(ctx context.Context) error
+}
+
+// Manager owns the servers of the process and decides how the process ends.
+// Shutdown is requested through a signal, Shutdown or Restart. The servers are then drained,
+// the shutdown hooks run and Exit either exits with the requested code or restarts in place.
+type Manager struct {
+	mu           sync.Mutex
+	hooks        []hook
+	requested    chan struct{}
+	once         sync.Once
+	exitCode     int
+	restart      bool
+	drainTimeout time.Duration
+}
+
+func NewManager(drainTimeout time.Duration) *Manager {
+	return &Manager{
+		requested:    make(chan struct{}),
+		drainTimeout: drainTimeout,
+	}
+}
+
+// OnShutdown registers a hook that runs after the servers stop. Hooks run in reverse order of registration.
+func (m *Manager) OnShutdown(name string, fn func(ctx context.Context) error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.hooks = append(m.hooks, hook{name: name, fn: fn})
+}
+
+// Shutdown requests a graceful shutdown that exits with code. Only the first request counts.
+func (m *Manager) Shutdown(code int) {
+	m.request(code, false)
+}
+
+// Restart requests a graceful shutdown that starts the same binary again in place of the process.
+func (m *Manager) Restart() {
+	m.request(0, true)
+}
+
+func (m *Manager) request(code int, restart bool) {
+	m.once.Do(func() {
+		m.mu.Lock()
+		m.exitCode = code
+		m.restart = restart
+		m.mu.Unlock()
+		close(m.requested)
+	})
+}
+
+// Run serves until ctx is done or shutdown is requested, then drains the servers and runs the hooks.
+// It returns the exit code of the process.
+func (m *Manager) Run(ctx context.Context, servers Servers) int {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	go func() {
+		select {
+		case <-m.requested:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	serveErr := servers.Serve(ctx, m.drainTimeout)
+	if serveErr != nil {
+		log.Printf("Server failed: %v\n", serveErr)
+	}
+
+	hookCtx, hookCancel := context.WithTimeout(context.Background(), m.drainTimeout)
+	defer hookCancel()
+
+	m.mu.Lock()
+	hooks := append([]hook(nil), m.hooks...)
+	m.mu.Unlock()
+
+	for i := len(hooks) - 1; i >= 0; i-- {
+		if err := hooks[i].fn(hookCtx); err != nil {
+			slog.Error(fmt.Sprintf("Shutdown hook %s failed", hooks[i].name), slog.Any("error", err))
+		}
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if serveErr != nil && m.exitCode == 0 {
+		m.exitCode = 1
+		m.restart = false
+	}
+	return m.exitCode
+}
+
+// Restarting reports whether the process restarts in place instead of exiting.
+func (m *Manager) Restarting() bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.restart
+}
+
+// Exit ends the process after Run returns, either with code or by restarting in place.
+func (m *Manager) Exit(code int) {
+	if m.Restarting() {
+		executable, err := os.Executable()
+		if err == nil {
+			log.Printf("Restarting %s\n", executable)
+			err = syscall.Exec(executable, os.Args, os.Environ())
+		}
+		log.Printf("Failed to restart: %v\n", err)
+		os.Exit(1)
+	}
+
+	log.Printf("Exiting with code %d\n", code)
+	os.Exit(code)
+}
@@ -0,0 +1,98 @@
+package lifecycle_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/kevin07696/receipt-processor/infrastructure/lifecycle"
+	"github.com/stretchr/testify/assert"
+)
+
+// MockServers serve until ctx is done, or fail at once with Err when it is set.
+type MockServers struct {
+	Err error
+}
+
+func (m MockServers) Serve(ctx context.Context, drainTimeout time.Duration) error {
+	if m.Err != nil {
+		return m.Err
+	}
+	<-ctx.Done()
+	return nil
+}
+
+func TestManagerRun(t *testing.T) {
+	testCases := []struct {
+		title              string
+		request            func(m *lifecycle.Manager, cancel context.CancelFunc)
+		expectedCode       int
+		expectedRestarting bool
+	}{
+		{
+			title:        "GivenShutdownRequest_ReturnRequestedCode",
+			request:      func(m *lifecycle.Manager, cancel context.CancelFunc) { m.Shutdown(3) },
+			expectedCode: 3,
+		},
+		{
+			title:        "GivenRepeatedShutdownRequests_ReturnFirstCode",
+			request:      func(m *lifecycle.Manager, cancel context.CancelFunc) { m.Shutdown(2); m.Shutdown(5); m.Restart() },
+			expectedCode: 2,
+		},
+		{
+			title:              "GivenRestartRequest_ReturnRestarting",
+			request:            func(m *lifecycle.Manager, cancel context.CancelFunc) { m.Restart() },
+			expectedCode:       0,
+			expectedRestarting: true,
+		},
+		{
+			title:        "GivenASignal_ReturnZero",
+			request:      func(m *lifecycle.Manager, cancel context.CancelFunc) { cancel() },
+			expectedCode: 0,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.title, func(t *testing.T) {
+			manager := lifecycle.NewManager(time.Second)
+
+			var order []string
+			manager.OnShutdown("first", func(ctx context.Context) error {
+				order = append(order, "first")
+				return nil
+			})
+			manager.OnShutdown("second", func(ctx context.Context) error {
+				order = append(order, "second")
+				return errors.New("hook errors are logged and do not stop other hooks")
+			})
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			go func() {
+				time.Sleep(50 * time.Millisecond)
+				tc.request(manager, cancel)
+			}()
+
+			code := manager.Run(ctx, MockServers{})
+
+			assert.Equal(t, tc.expectedCode, code)
+			assert.Equal(t, tc.expectedRestarting, manager.Restarting())
+			assert.Equal(t, []string{"second", "first"}, order)
+		})
+	}
+}
+
+func TestManagerRunGivenFailingServers_ReturnOne(t *testing.T) {
+	manager := lifecycle.NewManager(time.Second)
+	hookRan := false
+	manager.OnShutdown("repository", func(ctx context.Context) error {
+		hookRan = true
+		return nil
+	})
+
+	code := manager.Run(context.Background(), MockServers{Err: errors.New("test server failed to start")})
+
+	assert.Equal(t, 1, code)
+	assert.True(t, hookRan)
+}
@@ -0,0 +1,202 @@
+package metrics
+
+import (
+	"context"
+	"net/http"
+	"regexp"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+
+	"github.com/kevin07696/receipt-processor/adapters/caches"
+	"github.com/kevin07696/receipt-processor/domain"
+	"github.com/kevin07696/receipt-processor/domain/receipt"
+)
+
+// Registry holds every metric of the application and the Go runtime and process collectors.
+var Registry = prometheus.NewRegistry()
+
+var (
+	requestsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "http_requests_total",
+		Help: "HTTP requests by route, method and status code.",
+	}, []string{"route", "method", "status"})
+
+	requestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "http_request_duration_seconds",
+		Help:    "HTTP request latency by route, method and status code.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"route", "method", "status"})
+
+	receiptsScored = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "receipts_scored_total",
+		Help: "Receipts scored and stored.",
+	})
+
+	receiptPoints = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Name:    "receipt_points",
+		Help:    "Distribution of the total points of scored receipts.",
+		Buckets: []float64{0, 10, 25, 50, 75, 100, 150, 200, 300, 500, 1000},
+	})
+
+	ruleHits = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "receipt_rule_hits_total",
+		Help: "Scored receipts that a rule awarded points to, by rule.",
+	}, []string{"rule"})
+
+	validationFailures = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "receipt_validation_failures_total",
+		Help: "Receipt validation violations by field and rule.",
+	}, []string{"field", "rule"})
+)
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		requestsTotal,
+		requestDuration,
+		receiptsScored,
+		receiptPoints,
+		ruleHits,
+		validationFailures,
+	)
+}
+
+// Handler serves the registry in the Prometheus text exposition format.
+func Handler() http.Handler {
+	return promhttp.HandlerFor(Registry, promhttp.HandlerOpts{Registry: Registry})
+}
+
+// Middleware counts and times requests. It has to wrap the router directly,
+// because the route pattern is only known once the router matched the request.
+func Middleware(next http.Handler) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		recorder := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+
+		next.ServeHTTP(recorder, r)
+
+		route := r.Pattern
+		if route == "" {
+			route = "unmatched"
+		}
+		status := strconv.Itoa(recorder.status)
+		requestsTotal.WithLabelValues(route, r.Method, status).Inc()
+		requestDuration.WithLabelValues(route, r.Method, status).Observe(time.Since(start).Seconds())
+	}
+}
+
+type statusRecorder struct {
+	http.ResponseWriter
+	status      int
+	wroteHeader bool
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	if !r.wroteHeader {
+		r.status = status
+		r.wroteHeader = true
+	}
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *statusRecorder) Write(b []byte) (int, error) {
+	r.wroteHeader = true
+	return r.ResponseWriter.Write(b)
+}
+
+func (r *statusRecorder) Flush() {
+	if flusher, ok := r.ResponseWriter.(http.Flusher); ok {
+		r.wroteHeader = true
+		flusher.Flush()
+	}
+}
+
+func (r *statusRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
+
+var indexPattern = regexp.MustCompile(`/\d+`)
+
+// ObserveViolations counts validation failures. Item indexes are replaced so every item shares one field label.
+func ObserveViolations(violations []domain.Violation) {
+	for _, violation := range violations {
+		validationFailures.WithLabelValues(indexPattern.ReplaceAllString(violation.Path, "/*"), violation.Rule).Inc()
+	}
+}
+
+// Repository observes the points and rule hits of every score written to the wrapped repository.
+type Repository struct {
+	receipt.IReceiptProcessorRepository
+}
+
+func NewRepository(repository receipt.IReceiptProcessorRepository) Repository {
+	return Repository{IReceiptProcessorRepository: repository}
+}
+
+func (r Repository) WriteReceiptScore(ctx context.Context, id string, record receipt.ScoreRecord) domain.StatusCode {
+	status := r.IReceiptProcessorRepository.WriteReceiptScore(ctx, id, record)
+	if status > 0 {
+		return status
+	}
+
+	receiptsScored.Inc()
+	receiptPoints.Observe(float64(record.Points))
+	for _, score := range record.Breakdown {
+		if score.Points > 0 {
+			ruleHits.WithLabelValues(score.Rule).Inc()
+		}
+	}
+	return status
+}
+
+// RegisterTiered exposes the write behind values of a tiered repository that have not reached its cold tier,
+// and the writes that failed every retry.
+func RegisterTiered(stats func() receipt.TierStats) {
+	Registry.MustRegister(
+		prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Name: "tiered_pending_writes",
+			Help: "Write behind values that have not reached the cold tier.",
+		}, func() float64 { return float64(stats().Pending) }),
+		prometheus.NewCounterFunc(prometheus.CounterOpts{
+			Name: "tiered_write_behind_failures_total",
+			Help: "Write behind values that failed to reach the cold tier after every retry.",
+		}, func() float64 { return float64(stats().Failures) }),
+	)
+}
+
+// RegisterCache exposes the size, hits, misses, evictions and expirations of a cache under the given name.
+func RegisterCache(name string, stats func() caches.Stats) {
+	labels := prometheus.Labels{"cache": name}
+	Registry.MustRegister(
+		prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Name:        "cache_size",
+			Help:        "Entries held by the cache.",
+			ConstLabels: labels,
+		}, func() float64 { return float64(stats().Size) }),
+		prometheus.NewCounterFunc(prometheus.CounterOpts{
+			Name:        "cache_hits_total",
+			Help:        "Cache lookups that found an entry.",
+			ConstLabels: labels,
+		}, func() float64 { return float64(stats().Hits) }),
+		prometheus.NewCounterFunc(prometheus.CounterOpts{
+			Name:        "cache_misses_total",
+			Help:        "Cache lookups that found no entry.",
+			ConstLabels: labels,
+		}, func() float64 { return float64(stats().Misses) }),
+		prometheus.NewCounterFunc(prometheus.CounterOpts{
+			Name:        "cache_evictions_total",
+			Help:        "Entries evicted to stay within the cache capacity.",
+			ConstLabels: labels,
+		}, func() float64 { return float64(stats().Evictions) }),
+		prometheus.NewCounterFunc(prometheus.CounterOpts{
+			Name:        "cache_expirations_total",
+			Help:        "Entries removed because their TTL expired.",
+			ConstLabels: labels,
+		}, func() float64 { return float64(stats().Expirations) }),
+	)
+}
@@ -0,0 +1,102 @@
+package metrics_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/kevin07696/receipt-processor/adapters/caches"
+	"github.com/kevin07696/receipt-processor/domain"
+	"github.com/kevin07696/receipt-processor/domain/receipt"
+	"github.com/kevin07696/receipt-processor/infrastructure/metrics"
+	"github.com/stretchr/testify/assert"
+)
+
+type stubRepository struct {
+	receipt.IReceiptProcessorRepository
+}
+
+func (stubRepository) WriteReceiptScore(ctx context.Context, id string, record receipt.ScoreRecord) domain.StatusCode {
+	return domain.StatusOK
+}
+
+func scrape(t *testing.T) string {
+	t.Helper()
+	w := httptest.NewRecorder()
+	metrics.Handler().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	body, err := io.ReadAll(w.Result().Body)
+	assert.NoError(t, err)
+	return string(body)
+}
+
+func TestMetrics(t *testing.T) {
+	router := http.NewServeMux()
+	router.HandleFunc("GET /receipts/{id}/points", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	})
+	handler := metrics.Middleware(router)
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/receipts/abc/points", nil))
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/missing", nil))
+
+	metrics.ObserveViolations([]domain.Violation{
+		{Path: "/items/0/price", Rule: domain.RulePattern, Value: "1"},
+		{Path: "/items/12/price", Rule: domain.RulePattern, Value: "2"},
+	})
+
+	repository := metrics.NewRepository(stubRepository{})
+	repository.WriteReceiptScore(context.Background(), "id", receipt.ScoreRecord{
+		Points:    35,
+		Breakdown: []receipt.RuleScore{{Rule: receipt.RuleRetailer, Points: 35}, {Rule: receipt.RuleRoundTotal}},
+	})
+
+	cache := caches.NewLRUCache(1)
+	metrics.RegisterCache("test", cache.Stats)
+	cache.Get(context.Background(), "missing")
+
+	metrics.RegisterTiered(func() receipt.TierStats { return receipt.TierStats{Pending: 3, Failures: 2} })
+
+	body := scrape(t)
+	testCases := []struct {
+		title    string
+		expected string
+	}{
+		{
+			title:    "GivenAMatchedRoute_LabelWithPattern",
+			expected: `http_requests_total{method="GET",route="GET /receipts/{id}/points",status="404"} 1`,
+		},
+		{
+			title:    "GivenAnUnmatchedRoute_LabelAsUnmatched",
+			expected: `http_requests_total{method="GET",route="unmatched",status="404"} 1`,
+		},
+		{
+			title:    "GivenItemViolations_LabelWithoutIndex",
+			expected: `receipt_validation_failures_total{field="/items/*/price",rule="pattern"} 2`,
+		},
+		{
+			title:    "GivenAWrittenScore_CountReceipt",
+			expected: "receipts_scored_total 1",
+		},
+		{
+			title:    "GivenARuleWithPoints_CountRuleHit",
+			expected: `receipt_rule_hits_total{rule="retailer"} 1`,
+		},
+		{
+			title:    "GivenACacheMiss_CountMiss",
+			expected: `cache_misses_total{cache="test"} 1`,
+		},
+		{
+			title:    "GivenAFailedWriteBehind_CountFailure",
+			expected: "tiered_write_behind_failures_total 2",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.title, func(t *testing.T) {
+			assert.Contains(t, body, tc.expected)
+		})
+	}
+
+	assert.NotContains(t, body, `receipt_rule_hits_total{rule="round_total"}`)
+}
@@ -1,7 +1,10 @@
 package main
 
 import (
+	"context"
 	"crypto/sha256"
+	"encoding/gob"
+	"errors"
 	"log"
 	"log/slog"
 	"net/http"
@@ -12,12 +15,15 @@ import (
 	"github.com/google/uuid"
 
 	"github.com/kevin07696/receipt-processor/adapters/caches"
+	"github.com/kevin07696/receipt-processor/adapters/stores"
 	receiptDomain "github.com/kevin07696/receipt-processor/domain/receipt"
 	"github.com/kevin07696/receipt-processor/handlers"
 	"github.com/kevin07696/receipt-processor/handlers/admin"
 	receiptHandlers "github.com/kevin07696/receipt-processor/handlers/receipt"
 	"github.com/kevin07696/receipt-processor/infrastructure/config"
+	"github.com/kevin07696/receipt-processor/infrastructure/lifecycle"
 	"github.com/kevin07696/receipt-processor/infrastructure/loggers"
+	"github.com/kevin07696/receipt-processor/infrastructure/metrics"
 )
 
 func main() {
@@ -32,8 +38,39 @@ func main() {
 	logger := slog.New(h)
 	slog.SetDefault(logger)
 
-	cache := caches.NewLRUCache(env.CacheCap)
-	var repository receiptDomain.IReceiptProcessorRepository = receiptDomain.NewReceiptProcessorRepository(&cache)
+	manager := lifecycle.NewManager(env.ShutdownTimeout)
+	// Hooks run in reverse, so logs are flushed after every other hook has logged
+	manager.OnShutdown("logs", func(ctx context.Context) error {
+		// Pipes and terminals can not be synced, only files can
+		if err := os.Stderr.Sync(); err != nil && !errors.Is(err, syscall.EINVAL) {
+			return err
+		}
+		return nil
+	})
+
+	var repository receiptDomain.IReceiptProcessorRepository
+	switch {
+	case env.Tiered.Cold != "":
+		hot := newCache(env, manager)
+		cold := newStore(env.Tiered.Cold, env, manager)
+		opts := receiptDomain.TierOptions{WriteMode: env.Tiered.WriteMode, QueueSize: env.Tiered.QueueSize, Retries: env.Tiered.Retries, RetryBackoff: env.Tiered.RetryBackoff}
+		if env.Tiered.NegativeTTL > 0 {
+			negative := caches.NewLRUCacheWithTTL(env.CacheCap, env.Tiered.NegativeTTL)
+			opts.Negative = &negative
+		}
+		tiered := receiptDomain.NewTieredRepository(hot, cold, opts)
+		metrics.RegisterTiered(tiered.Stats)
+		// Registered after the cold repository, so write behind values are flushed before it closes
+		manager.OnShutdown("tiered repository", tiered.Close)
+		repository = receiptDomain.NewReceiptProcessorRepository(tiered)
+	case env.Repository == config.RepositorySQLite:
+		// The sqlite repository stores the full receipt in its own tables instead of a single value
+		repository = newSQLiteRepository(env, manager)
+	case env.Repository == config.RepositoryFile:
+		repository = receiptDomain.NewReceiptProcessorRepository(newStore(env.Repository, env, manager))
+	default:
+		repository = receiptDomain.NewReceiptProcessorRepository(newCache(env, manager))
+	}
 
 	env.Options.GenerateID = func(input string) string {
 		if len(input) == 0 {
@@ -50,15 +87,149 @@ func main() {
 		return hashUUID.String()
 	}
 
-	receiptAPI := receiptDomain.NewReceiptProcessorService(repository, env.Options, env.Multipliers)
+	env.Options.Retailers = newRetailerRegistry(env.RetailersFile)
+	env.Options.Campaigns = newCampaignRegistry(env.CampaignsFile)
+	if env.HasUserCaps() {
+		env.Options.Ledger = receiptDomain.NewPointsLedger()
+	}
+
+	ruleSets, err := receiptDomain.NewRuleSets(env.RuleSetSelectBy, env.RuleSets...)
+	if err != nil {
+		log.Fatalf("Failed to create rule sets. Check config: %v", err)
+	}
+	receiptAPI := receiptDomain.NewReceiptProcessorServiceWithRuleSets(metrics.NewRepository(repository), env.Options, ruleSets)
+	if status := receiptAPI.RestoreLedger(context.Background()); status > 0 {
+		slog.Warn("Failed to restore the points of users. User caps only count receipts scored from now on.", slog.Int("status", int(status)))
+	}
+
+	// Rescores write to the repository without metrics, so receipts_scored_total counts each receipt once
+	rescoreAPI := receiptDomain.NewReceiptProcessorServiceWithRuleSets(repository, env.Options, ruleSets)
+	rescorer := receiptDomain.NewRescorer(&rescoreAPI)
+	// Registered after the repository, so a running job stops before the repository closes
+	manager.OnShutdown("rescore", rescorer.Close)
 
 	receiptRouter := http.NewServeMux()
-	receiptHandlers.InitializeRoutes(receiptRouter, &receiptAPI)
-	
+	receiptHandlers.InitializeRoutes(receiptRouter, &receiptAPI, receiptHandlers.BatchOptions{Workers: env.BatchWorkers, MaxSize: env.BatchMaxSize})
+
 	adminRouter := http.NewServeMux()
-	admin.InitializeRoutes(adminRouter)
-	
-	handler := handlers.ChainMiddlewaresToHandler(receiptRouter, handlers.RequestIDMiddleware, handlers.RequestLoggerMiddleware)
+	admin.InitializeRoutes(adminRouter, manager, rescorer, env.Options.Retailers, env.Options.Campaigns)
+
+	handler := handlers.ChainMiddlewaresToHandler(receiptRouter, handlers.RequestIDMiddleware, handlers.RequestLoggerMiddleware, metrics.Middleware)
+	adminHandler := handlers.ChainMiddlewaresToHandler(adminRouter, handlers.RequestIDMiddleware, handlers.RequestLoggerMiddleware, metrics.Middleware)
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+
+	code := manager.Run(ctx, handlers.Servers{
+		{Name: "receipt", Port: env.AppPort, Handler: handler},
+		{Name: "admin", Port: env.AdminPort, Handler: adminHandler},
+	})
+	stop()
+
+	manager.Exit(code)
+}
+
+// newCache creates the in-memory repository selected by REPOSITORY.
+func newCache(env config.Config, manager *lifecycle.Manager) receiptDomain.IRepository {
+	if env.Repository == config.RepositoryBigCache {
+		gob.Register(receiptDomain.ScoreRecord{})
+		bigCache, err := caches.NewBigCacheFromConfig(context.Background(), caches.BigCacheConfig{
+			Shards:     env.BigCache.Shards,
+			LifeWindow: env.BigCache.LifeWindow,
+			MaxEntries: env.CacheCap,
+			MaxSizeMB:  env.BigCache.MaxSizeMB,
+		}, caches.GobCodec{})
+		if err != nil {
+			log.Fatalf("Failed to create bigcache: %v", err)
+		}
+		manager.OnShutdown("cache", func(ctx context.Context) error {
+			return bigCache.Close()
+		})
+		metrics.RegisterCache("bigcache", bigCache.Stats)
+		return &bigCache
+	}
+
+	var cache caches.Cache
+	if env.CacheShards > 1 {
+		sharded := caches.NewShardedLRUCache(env.CacheCap, env.CacheShards, env.CacheTTL)
+		cache = &sharded
+	} else {
+		lru := caches.NewLRUCacheWithTTL(env.CacheCap, env.CacheTTL)
+		cache = &lru
+	}
+	if env.CacheTTL > 0 {
+		stopJanitor := cache.StartJanitor(env.CacheTTL)
+		manager.OnShutdown("cache janitor", func(ctx context.Context) error {
+			stopJanitor()
+			return nil
+		})
+	}
+	metrics.RegisterCache("lru", cache.Stats)
+	return cache
+}
+
+// newStore creates the durable repository named kind.
+func newStore(kind string, env config.Config, manager *lifecycle.Manager) receiptDomain.IRepository {
+	if kind == config.RepositorySQLite {
+		return newSQLiteRepository(env, manager)
+	}
+
+	gob.Register(receiptDomain.ScoreRecord{})
+	fileStore, err := stores.NewFileStore(env.FileStore.Dir, env.FileStore.CompactEvery)
+	if err != nil {
+		log.Fatalf("Failed to open file store: %v", err)
+	}
+	manager.OnShutdown("repository", func(ctx context.Context) error {
+		return fileStore.Close()
+	})
+	return fileStore
+}
+
+// newRetailerRegistry loads the retailers of path and saves changes to it. An empty path keeps them in memory.
+func newRetailerRegistry(path string) *receiptDomain.RetailerRegistry {
+	var store receiptDomain.IRetailerStore
+	var retailers []receiptDomain.Retailer
+	if path != "" {
+		retailerFile := stores.NewRetailerFile(path)
+		loaded, err := retailerFile.LoadRetailers()
+		if err != nil {
+			log.Fatalf("Failed to load retailers: %v", err)
+		}
+		store, retailers = retailerFile, loaded
+	}
 
-	handlers.StartServer(env.AppPort, handler)
+	registry, err := receiptDomain.NewRetailerRegistry(store, retailers...)
+	if err != nil {
+		log.Fatalf("Failed to create retailer registry. Check RETAILERS_FILE: %v", err)
+	}
+	return registry
+}
+
+func newCampaignRegistry(path string) *receiptDomain.CampaignRegistry {
+	var store receiptDomain.ICampaignStore
+	var campaigns []receiptDomain.Campaign
+	if path != "" {
+		campaignFile := stores.NewCampaignFile(path)
+		loaded, err := campaignFile.LoadCampaigns()
+		if err != nil {
+			log.Fatalf("Failed to load campaigns: %v", err)
+		}
+		store, campaigns = campaignFile, loaded
+	}
+
+	registry, err := receiptDomain.NewCampaignRegistry(store, campaigns...)
+	if err != nil {
+		log.Fatalf("Failed to create campaign registry. Check CAMPAIGNS_FILE: %v", err)
+	}
+	return registry
+}
+
+func newSQLiteRepository(env config.Config, manager *lifecycle.Manager) *stores.SQLiteRepository {
+	sqliteRepository, err := stores.NewSQLiteRepository(context.Background(), env.SQLitePath)
+	if err != nil {
+		log.Fatalf("Failed to open sqlite repository: %v", err)
+	}
+	manager.OnShutdown("repository", func(ctx context.Context) error {
+		return sqliteRepository.Close()
+	})
+	return sqliteRepository
 }